@@ -0,0 +1,83 @@
+package filter
+
+import (
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// isAlwaysFalse returns true if the WHERE clause of the given DB, including the conditions
+// added by its pending scopes, can be proven to always be false. This is the case when the
+// always-false condition of the dialect (`Dialect.False`), generated by operators that cannot
+// be applied to a field or whose arguments cannot be converted, is required by all the `OR`
+// alternatives of the clause. The given DB is not modified.
+func isAlwaysFalse(db *gorm.DB) bool {
+	// Clone the statement, including the pending scopes. Building a condition
+	// from the clone executes its scopes without running any callback.
+	probe := db.Session(&gorm.Session{}).Scopes()
+	probe.Statement.BuildCondition(probe)
+	where, ok := probe.Statement.Clauses["WHERE"].Expression.(clause.Where)
+	if !ok {
+		return false
+	}
+	return isFalseExpression(where, getDialect(db).False)
+}
+
+func isFalseExpression(expr clause.Expression, falseSQL string) bool {
+	switch e := expr.(type) {
+	case clause.Expr:
+		return e.SQL == falseSQL && len(e.Vars) == 0
+	case clause.AndConditions:
+		return isFalseSequence(e.Exprs, falseSQL)
+	case clause.OrConditions:
+		for _, x := range e.Exprs {
+			if !isFalseExpression(x, falseSQL) {
+				return false
+			}
+		}
+		return len(e.Exprs) > 0
+	case clause.Where:
+		exprs := e.Exprs
+		if len(exprs) == 1 {
+			if and, ok := exprs[0].(clause.AndConditions); ok {
+				exprs = and.Exprs
+			}
+		}
+		// GORM moves the first expression that is not a single OR condition to the front
+		for i, expr := range exprs {
+			if or, ok := expr.(clause.OrConditions); !ok || len(or.Exprs) > 1 {
+				if i != 0 {
+					exprs = slices.Clone(exprs)
+					exprs[0], exprs[i] = exprs[i], exprs[0]
+				}
+				break
+			}
+		}
+		return isFalseSequence(exprs, falseSQL)
+	}
+	return false
+}
+
+// isFalseSequence evaluates expressions built the same way as GORM builds the expressions of
+// a WHERE clause: they are combined with `AND`, except the `OrConditions` containing a single
+// expression, which are combined with `OR`. As `AND` has precedence over `OR`, the sequence is
+// false if each of its `OR` alternatives contains a false expression.
+func isFalseSequence(exprs []clause.Expression, falseSQL string) bool {
+	if len(exprs) == 0 {
+		return false
+	}
+	alternativeFalse := false
+	for i, expr := range exprs {
+		if or, ok := expr.(clause.OrConditions); ok && len(or.Exprs) == 1 && i > 0 {
+			if !alternativeFalse {
+				return false
+			}
+			alternativeFalse = false
+		}
+		if isFalseExpression(expr, falseSQL) {
+			alternativeFalse = true
+		}
+	}
+	return alternativeFalse
+}
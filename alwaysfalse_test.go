@@ -0,0 +1,155 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+func TestIsFalseExpression(t *testing.T) {
+	f := clause.Expr{SQL: "FALSE"}
+	a := clause.Expr{SQL: "a = ?", Vars: []any{1}}
+
+	cases := []struct {
+		expr clause.Expression
+		desc string
+		want bool
+	}{
+		{desc: "false", expr: f, want: true},
+		{desc: "false_with_vars", expr: clause.Expr{SQL: "FALSE", Vars: []any{1}}, want: false},
+		{desc: "other", expr: a, want: false},
+		{desc: "and", expr: clause.And(a, f), want: true},
+		{desc: "and_true", expr: clause.And(a, a), want: false},
+		{desc: "or", expr: clause.OrConditions{Exprs: []clause.Expression{f, a}}, want: false},
+		{desc: "or_all_false", expr: clause.OrConditions{Exprs: []clause.Expression{f, f}}, want: true},
+		{desc: "empty_or", expr: clause.OrConditions{}, want: false},
+		{desc: "sequence_or", expr: clause.AndConditions{Exprs: []clause.Expression{f, clause.Or(a)}}, want: false},
+		{desc: "sequence_or_false", expr: clause.AndConditions{Exprs: []clause.Expression{f, a, clause.Or(f), a}}, want: true},
+		{desc: "sequence_or_group", expr: clause.AndConditions{Exprs: []clause.Expression{f, clause.Or(a, a)}}, want: true},
+		{desc: "where", expr: clause.Where{Exprs: []clause.Expression{clause.And(a, f)}}, want: true},
+		{desc: "where_swap", expr: clause.Where{Exprs: []clause.Expression{clause.Or(f), a, f}}, want: false},
+		{desc: "where_swap_false", expr: clause.Where{Exprs: []clause.Expression{clause.Or(f), f, a}}, want: true},
+		{desc: "empty_where", expr: clause.Where{}, want: false},
+		{desc: "not", expr: clause.Not(a), want: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.want, isFalseExpression(c.expr, "FALSE"))
+		})
+	}
+}
+
+func TestIsAlwaysFalse(t *testing.T) {
+	cases := []struct {
+		request *Request
+		desc    string
+		want    bool
+	}{
+		{desc: "no_filter", request: &Request{}, want: false},
+		{
+			desc:    "valid_filter",
+			request: &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "id", Operator: Operators["$eq"], Args: []string{"1"}}})},
+			want:    false,
+		},
+		{
+			desc: "invalid_filter",
+			request: &Request{Filter: typeutil.NewUndefined([]*Filter{
+				{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}},
+				{Field: "id", Operator: Operators["$eq"], Args: []string{"a"}},
+			})},
+			want: true,
+		},
+		{
+			desc:    "invalid_relation_filter",
+			request: &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "Relation.id", Operator: Operators["$eq"], Args: []string{"a"}}})},
+			want:    true,
+		},
+		{
+			desc: "invalid_or_alternative",
+			request: &Request{
+				Filter: typeutil.NewUndefined([]*Filter{{Field: "id", Operator: Operators["$eq"], Args: []string{"a"}}}),
+				Or:     typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}, Or: true}}),
+			},
+			want: false,
+		},
+		{
+			desc: "all_or_alternatives_invalid",
+			request: &Request{
+				Filter: typeutil.NewUndefined([]*Filter{{Field: "id", Operator: Operators["$eq"], Args: []string{"a"}}}),
+				Or:     typeutil.NewUndefined([]*Filter{{Field: "id", Operator: Operators["$gt"], Args: []string{"b"}, Or: true}}),
+			},
+			want: true,
+		},
+		{
+			desc: "invalid_filter_group",
+			request: &Request{
+				FilterGroups: typeutil.NewUndefined([][]*Filter{{
+					{Field: "id", Operator: Operators["$eq"], Args: []string{"a"}},
+					{Field: "id", Operator: Operators["$eq"], Args: []string{"b"}},
+				}}),
+			},
+			want: true,
+		},
+		{
+			desc: "filter_group_valid_alternative",
+			request: &Request{
+				FilterGroups: typeutil.NewUndefined([][]*Filter{{
+					{Field: "id", Operator: Operators["$eq"], Args: []string{"a"}},
+					{Field: "name", Operator: Operators["$eq"], Args: []string{"b"}},
+				}}),
+			},
+			want: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			db, schema, _ := (&Settings[*FilterTestModel]{}).scopeCommon(db, c.request, &[]*FilterTestModel{})
+			require.NotNil(t, schema)
+			assert.Equal(t, c.want, isAlwaysFalse(db))
+
+			// The pending scopes are not executed on the given DB
+			assert.NotContains(t, db.Statement.Clauses, "WHERE")
+		})
+	}
+
+	t.Run("existing_conditions", func(t *testing.T) {
+		db := openDryRunDB(t)
+		assert.False(t, isAlwaysFalse(db.Where("id = ?", 1)))
+		assert.True(t, isAlwaysFalse(db.Where("id = ?", 1).Where("FALSE")))
+		assert.True(t, isAlwaysFalse(db.Scopes(func(tx *gorm.DB) *gorm.DB { return tx.Where("FALSE") })))
+	})
+}
+
+func TestScopeShortCircuitFalse(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "id", Operator: Operators["$eq"], Args: []string{"a"}}}),
+		Page:   typeutil.NewUndefined(2),
+	}
+	db := openDryRunDB(t)
+	queries := 0
+	err := db.Callback().Query().Before("gorm:query").Register("test:count_queries", func(_ *gorm.DB) {
+		queries++
+	})
+	require.NoError(t, err)
+
+	results := []*FilterTestModel{{ID: 1}}
+	paginator, err := (&Settings[*FilterTestModel]{ShortCircuitFalse: true}).Scope(db, request, &results)
+	require.NoError(t, err)
+	assert.Equal(t, 0, queries)
+	assert.Empty(t, results)
+	assert.Equal(t, int64(0), paginator.Total)
+	assert.Equal(t, int64(1), paginator.MaxPage)
+	assert.Equal(t, 2, paginator.CurrentPage)
+	assert.Equal(t, DefaultPageSize, paginator.PageSize)
+
+	_, err = (&Settings[*FilterTestModel]{}).Scope(db, request, &results)
+	require.NoError(t, err)
+	assert.Equal(t, 2, queries)
+}
@@ -0,0 +1,171 @@
+package filter
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// BatchResult the result of one of the queries executed by `Batch.Scope()`.
+// The records are added to the destination slice of the query.
+type BatchResult struct {
+	// Error the error that occurred while executing the query, if any.
+	Error       error `json:"-"`
+	MaxPage     int64 `json:"maxPage"`
+	Total       int64 `json:"total"`
+	PageSize    int   `json:"pageSize"`
+	CurrentPage int   `json:"currentPage"`
+}
+
+// BatchSettings the settings applied to a query of a batch. It is implemented by `*Settings[T]`.
+type BatchSettings interface {
+	scopeBatch(db *gorm.DB, request *Request, dest any) *BatchResult
+}
+
+// Batch executes several filtered and paginated list queries in one call, for example
+// for dashboard endpoints aggregating multiple filtered widgets in one round trip.
+// Each query is identified by a key.
+type Batch struct {
+	// Settings the settings of the queries, identified by their key (e.g. `&filter.Settings[*model.User]{}`).
+	// The queries without settings use the default settings.
+	Settings map[string]BatchSettings
+
+	// Concurrency the maximum number of queries executed at the same time.
+	// If lower than 2, the queries are executed one after the other, in the order of their keys.
+	Concurrency int
+}
+
+// ScopeBatch using the default batch settings. See `Batch.Scope()` for more details.
+func ScopeBatch(db *gorm.DB, requests map[string]*Request, dest map[string]any) map[string]*BatchResult {
+	return (&Batch{}).Scope(db, requests, dest)
+}
+
+// Scope executes the given requests like `Settings.Scope()` and returns their result, identified
+// by the key of the request. The records of each request are added to the destination with the
+// same key, which must be a pointer to a slice of models (e.g. `&[]*model.User{}`). A request
+// without destination results in an error.
+//
+// The queries don't share a transaction. Errors are reported in the result of each query
+// and don't prevent the other queries from being executed. The settings whose results are
+// exposed in statement settings (`CountUnfiltered`, `DiagnoseEmpty`, `DeletionFeed`,
+// `ExportEnqueuer` and `Snapshots`) are not supported in batches: the queries using them
+// result in an error wrapping `ErrUnsupportedBatchSetting`.
+// The given requests are expected to be validated using `ApplyValidation`.
+func (b *Batch) Scope(db *gorm.DB, requests map[string]*Request, dest map[string]any) map[string]*BatchResult {
+	keys := lo.Keys(requests)
+	slices.Sort(keys)
+
+	results := make(map[string]*BatchResult, len(requests))
+	mu := sync.Mutex{}
+	execute := func(key string) {
+		result := b.scope(db.Session(&gorm.Session{}), key, requests[key], dest[key])
+		mu.Lock()
+		results[key] = result
+		mu.Unlock()
+	}
+
+	if b.Concurrency < 2 {
+		for _, key := range keys {
+			execute(key)
+		}
+		return results
+	}
+
+	wg := sync.WaitGroup{}
+	semaphore := make(chan struct{}, b.Concurrency)
+	for _, key := range keys {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			execute(key)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (b *Batch) scope(db *gorm.DB, key string, request *Request, dest any) *BatchResult {
+	if dest == nil {
+		return &BatchResult{Error: errors.Errorf("batch query %q: no destination", key)}
+	}
+	settings, ok := b.Settings[key]
+	if !ok || settings == nil {
+		settings = &Settings[any]{}
+	}
+	return settings.scopeBatch(db, request, dest)
+}
+
+func (s *Settings[T]) scopeBatch(db *gorm.DB, request *Request, dest any) *BatchResult {
+	page, pageSize, all, err := s.pageParams(request)
+	if err != nil {
+		return &BatchResult{Error: err}
+	}
+	result := &BatchResult{CurrentPage: page, PageSize: pageSize}
+
+	if unsupported := s.unsupportedInBatch(); len(unsupported) > 0 {
+		result.Error = errors.Errorf("%w: %s", ErrUnsupportedBatchSetting, strings.Join(unsupported, ", "))
+		return result
+	}
+
+	tx, schema, hasJoins := s.scopeCommon(db, request, dest)
+	if schema == nil {
+		result.Error = errors.New(tx.Error)
+		return result
+	}
+	if s.ShortCircuitFalse && isAlwaysFalse(tx) {
+		result.PageSize, result.MaxPage = shortCircuitPage(pageSize, all, s.MaxExportRows)
+		return result
+	}
+
+	result.Total, result.PageSize, result.MaxPage, err = s.countPages(tx, request, schema, pageSize, all)
+	if err != nil {
+		result.Error = errors.New(err)
+		return result
+	}
+
+	tx = s.scopeSort(tx, request, schema)
+	fieldsDB := s.scopeFields(tx, request, schema, hasJoins)
+	if fieldsDB == nil {
+		result.Error = errors.New(tx.Error)
+		return result
+	}
+	tx = findPage(fieldsDB, result.CurrentPage, result.PageSize, dest)
+	if tx.Error != nil {
+		result.Error = errors.New(tx.Error)
+		return result
+	}
+	if err := s.checkPreloads(tx, dest); err != nil {
+		result.Error = err
+	}
+	return result
+}
+
+// unsupportedInBatch returns the names of the settings used by these settings
+// that cannot be applied in a batch.
+func (s *Settings[T]) unsupportedInBatch() []string {
+	unsupported := []string{}
+	if s.CountUnfiltered {
+		unsupported = append(unsupported, "CountUnfiltered")
+	}
+	if s.DiagnoseEmpty {
+		unsupported = append(unsupported, "DiagnoseEmpty")
+	}
+	if s.DeletionFeed != nil {
+		unsupported = append(unsupported, "DeletionFeed")
+	}
+	if s.ExportEnqueuer != nil {
+		unsupported = append(unsupported, "ExportEnqueuer")
+	}
+	if s.Snapshots != nil {
+		unsupported = append(unsupported, "Snapshots")
+	}
+	return unsupported
+}
@@ -0,0 +1,123 @@
+package filter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type BatchTestUser struct {
+	Name     string
+	Password string
+	ID       uint
+}
+
+type BatchTestOrder struct {
+	Status string
+	ID     uint
+}
+
+func TestScopeBatch(t *testing.T) {
+	requests := func() map[string]*Request {
+		return map[string]*Request{
+			"users": {
+				Filter:  typeutil.NewUndefined([]*Filter{{Field: "password", Operator: Operators["$eq"], Args: []string{"a"}}}),
+				Sort:    typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortAscending}}),
+				PerPage: typeutil.NewUndefined(5),
+			},
+			"orders": {
+				Filter: typeutil.NewUndefined([]*Filter{{Field: "status", Operator: Operators["$eq"], Args: []string{"pending"}}}),
+				Page:   typeutil.NewUndefined(2),
+			},
+			"missing": {},
+		}
+	}
+	expectedQueries := []string{
+		"SELECT count(*) FROM `batch_test_orders` WHERE `batch_test_orders`.`status` = \"pending\"",
+		"SELECT `batch_test_orders`.`status`,`batch_test_orders`.`id` FROM `batch_test_orders` WHERE `batch_test_orders`.`status` = \"pending\" LIMIT 10 OFFSET 10",
+		"SELECT count(*) FROM `batch_test_users`",
+		"SELECT `batch_test_users`.`name`,`batch_test_users`.`id` FROM `batch_test_users` ORDER BY `batch_test_users`.`name` LIMIT 5",
+	}
+
+	for _, concurrency := range []int{0, 2} {
+		db := openDryRunDB(t)
+		mu := sync.Mutex{}
+		queries := []string{}
+		err := db.Callback().Query().After("gorm:query").Register("test:queries", func(tx *gorm.DB) {
+			mu.Lock()
+			defer mu.Unlock()
+			queries = append(queries, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+		})
+		require.NoError(t, err)
+
+		users := []*BatchTestUser{}
+		orders := []*BatchTestOrder{}
+		batch := &Batch{
+			Settings: map[string]BatchSettings{
+				"users": &Settings[*BatchTestUser]{Blacklist: Blacklist{FieldsBlacklist: []string{"password"}}},
+			},
+			Concurrency: concurrency,
+		}
+		results := batch.Scope(db, requests(), map[string]any{"users": &users, "orders": &orders})
+
+		require.Len(t, results, 3)
+		assert.Equal(t, &BatchResult{MaxPage: 1, PageSize: 5, CurrentPage: 1}, results["users"])
+		assert.Equal(t, &BatchResult{MaxPage: 1, PageSize: DefaultPageSize, CurrentPage: 2}, results["orders"])
+		require.Error(t, results["missing"].Error)
+		assert.Equal(t, `batch query "missing": no destination`, results["missing"].Error.Error())
+		if concurrency == 0 {
+			assert.Equal(t, expectedQueries, queries)
+		} else {
+			assert.ElementsMatch(t, expectedQueries, queries)
+		}
+	}
+}
+
+func TestScopeBatchError(t *testing.T) {
+	orders := []*BatchTestOrder{}
+	request := &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "status", Operator: Operators["$eq"], Args: []string{"pending"}}})}
+	batch := &Batch{Settings: map[string]BatchSettings{"orders": &Settings[*BatchTestOrder]{MaxArgLength: 3}}}
+	results := batch.Scope(openDryRunDB(t), map[string]*Request{"orders": request}, map[string]any{"orders": &orders})
+	require.ErrorIs(t, results["orders"].Error, ErrArgTooLong)
+
+	results = ScopeBatch(openDryRunDB(t), map[string]*Request{"orders": request}, map[string]any{"orders": &orders})
+	require.NoError(t, results["orders"].Error)
+}
+
+func TestScopeBatchUnsupportedSettings(t *testing.T) {
+	snapshots := &Snapshots{}
+	t.Cleanup(snapshots.Close)
+	orders := []*BatchTestOrder{}
+	batch := &Batch{Settings: map[string]BatchSettings{
+		"orders": &Settings[*BatchTestOrder]{CountUnfiltered: true, Snapshots: snapshots},
+	}}
+	results := batch.Scope(openDryRunDB(t), map[string]*Request{"orders": {}}, map[string]any{"orders": &orders})
+	require.ErrorIs(t, results["orders"].Error, ErrUnsupportedBatchSetting)
+	assert.Equal(t, "setting not supported in batches: CountUnfiltered, Snapshots", results["orders"].Error.Error())
+}
+
+func TestScopeBatchShortCircuitFalse(t *testing.T) {
+	db := openDryRunDB(t)
+	queries := 0
+	err := db.Callback().Query().After("gorm:query").Register("test:queries", func(_ *gorm.DB) {
+		queries++
+	})
+	require.NoError(t, err)
+
+	orders := []*BatchTestOrder{}
+	request := &Request{
+		Filter:  typeutil.NewUndefined([]*Filter{{Field: "id", Operator: Operators["$cont"], Args: []string{"a"}}}),
+		PerPage: typeutil.NewUndefined(PerPageAll),
+	}
+	batch := &Batch{Settings: map[string]BatchSettings{
+		"orders": &Settings[*BatchTestOrder]{ShortCircuitFalse: true, AllowAll: true},
+	}}
+	results := batch.Scope(db, map[string]*Request{"orders": request}, map[string]any{"orders": &orders})
+	require.NoError(t, results["orders"].Error)
+	assert.Equal(t, &BatchResult{MaxPage: 1, PageSize: 1, CurrentPage: 1}, results["orders"])
+	assert.Equal(t, 0, queries)
+}
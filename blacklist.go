@@ -0,0 +1,213 @@
+package filter
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm/schema"
+)
+
+// Blacklist definition of blacklisted relations and fields.
+type Blacklist struct {
+	Relations map[string]*Blacklist
+
+	// FieldsBlacklist prevent the fields in this list to be selected or to
+	// be used in filters and sorts.
+	FieldsBlacklist []string
+	// RelationsBlacklist prevent joining the relations in this list.
+	// Elements can be relation paths relative to this blacklist containing
+	// wildcards matching any relation name:
+	//   - "*.AuditLogs" prevents joining "AuditLogs" from any relation.
+	//   - "User.*" prevents joining any relation of "User".
+	RelationsBlacklist []string
+	// DeniedRelations prevent joining the relations in this list at any depth.
+	// Elements can be relation names or relation paths containing wildcards,
+	// matched against the end of the joined relation path. For example
+	// "AuditLogs" prevents joining any relation named "AuditLogs", and "User.Articles"
+	// prevents joining "Articles" from any relation named "User".
+	DeniedRelations []string
+
+	// DenyBackReferences if true, prevent joining the reverse side of a relation
+	// right after joining it, at any depth. For example, when joining "Articles.User"
+	// from a user, "User" is the reverse side of "Articles" and is denied.
+	// This neutralizes infinite depth requests on bidirectional relations without
+	// having to blacklist every relation path manually.
+	DenyBackReferences bool
+
+	// IsFinal if true, prevent joining any relation
+	IsFinal bool
+
+	// AllowTrashed if true, the soft-deleted records of the relation this blacklist
+	// applies to can be included using the "withTrashed" join option
+	// (e.g. "Comments||*||withTrashed"). The option is ignored otherwise.
+	AllowTrashed bool
+}
+
+// blacklistPath keeps track of the blacklists and relations encountered while walking
+// through a relation path, so wildcard patterns and denied relations defined in parent
+// blacklists can be matched against the rest of the path.
+// The blacklists of the levels that are not defined are nil.
+type blacklistPath struct {
+	blacklists []*Blacklist
+	relations  []*schema.Relationship
+}
+
+func newBlacklistPath(blacklist *Blacklist) *blacklistPath {
+	return &blacklistPath{blacklists: []*Blacklist{blacklist}}
+}
+
+// current returns the blacklist of the current relation. May be nil.
+func (p *blacklistPath) current() *Blacklist {
+	return p.blacklists[len(p.blacklists)-1]
+}
+
+// next returns a new path walking into the given relation.
+func (p *blacklistPath) next(relation *schema.Relationship) *blacklistPath {
+	var next *Blacklist
+	if current := p.current(); current != nil {
+		next = current.Relations[relation.Name]
+	}
+	return &blacklistPath{
+		blacklists: append(slices.Clip(p.blacklists), next),
+		relations:  append(slices.Clip(p.relations), relation),
+	}
+}
+
+// isDenied returns true if joining the given relation from the current relation
+// is not allowed.
+func (p *blacklistPath) isDenied(relation *schema.Relationship) bool {
+	if current := p.current(); current != nil && current.IsFinal {
+		return true
+	}
+	path := make([]string, 0, len(p.relations)+1)
+	for _, r := range p.relations {
+		path = append(path, r.Name)
+	}
+	path = append(path, relation.Name)
+	for i, b := range p.blacklists {
+		if b == nil {
+			continue
+		}
+		relativePath := path[i:]
+		for _, pattern := range b.RelationsBlacklist {
+			if matchRelationPattern(pattern, relativePath) {
+				return true
+			}
+		}
+		for _, pattern := range b.DeniedRelations {
+			n := strings.Count(pattern, ".") + 1
+			if n <= len(relativePath) && matchRelationPattern(pattern, relativePath[len(relativePath)-n:]) {
+				return true
+			}
+		}
+		if b.DenyBackReferences && len(p.relations) > 0 && isBackReference(p.relations[len(p.relations)-1], relation) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBackReference returns true if the given relation is the reverse side of the previous relation,
+// meaning it targets the model the previous relation belongs to using the same keys.
+func isBackReference(previous, relation *schema.Relationship) bool {
+	if relation.FieldSchema == nil || previous.Schema == nil || relation.FieldSchema.Table != previous.Schema.Table {
+		return false
+	}
+	if len(relation.References) != len(previous.References) {
+		return false
+	}
+	for _, ref := range previous.References {
+		matches := lo.ContainsBy(relation.References, func(r *schema.Reference) bool {
+			return sameField(r.PrimaryKey, ref.PrimaryKey) && sameField(r.ForeignKey, ref.ForeignKey) && r.PrimaryValue == ref.PrimaryValue
+		})
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+func sameField(a, b *schema.Field) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.DBName == b.DBName && a.Schema != nil && b.Schema != nil && a.Schema.Table == b.Schema.Table
+}
+
+// matchRelationPattern returns true if the given relation path matches the
+// pattern. The pattern is a dot-separated relation path in which "*" matches
+// any relation name.
+func matchRelationPattern(pattern string, path []string) bool {
+	segments := strings.Split(pattern, ".")
+	if len(segments) != len(path) {
+		return false
+	}
+	for i, s := range segments {
+		if s != "*" && s != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a deep copy of the blacklist, including the nested relation blacklists.
+func (b *Blacklist) Clone() *Blacklist {
+	if b == nil {
+		return nil
+	}
+	clone := *b
+	clone.FieldsBlacklist = slices.Clone(b.FieldsBlacklist)
+	clone.RelationsBlacklist = slices.Clone(b.RelationsBlacklist)
+	clone.DeniedRelations = slices.Clone(b.DeniedRelations)
+	if b.Relations != nil {
+		clone.Relations = make(map[string]*Blacklist, len(b.Relations))
+		for name, relation := range b.Relations {
+			clone.Relations[name] = relation.Clone()
+		}
+	}
+	return &clone
+}
+
+// Merge returns a new blacklist combining this blacklist with the given one, so common
+// blacklists (tenant or audit columns for example) can be combined with the blacklists
+// specific to an endpoint. The lists are concatenated without duplicates and the relation
+// blacklists are merged recursively. A merge never grants more than either blacklist: the
+// restrictive flags (`IsFinal`, `DenyBackReferences`) are enabled if enabled in either
+// blacklist, but the permissive `AllowTrashed` flag is only enabled if enabled in both.
+// A relation blacklist missing from one side is merged with an empty blacklist, so it
+// doesn't allow trashed records either. Neither blacklist is modified.
+func (b Blacklist) Merge(other Blacklist) Blacklist {
+	merged := Blacklist{
+		FieldsBlacklist:    mergeLists(b.FieldsBlacklist, other.FieldsBlacklist),
+		RelationsBlacklist: mergeLists(b.RelationsBlacklist, other.RelationsBlacklist),
+		DeniedRelations:    mergeLists(b.DeniedRelations, other.DeniedRelations),
+		DenyBackReferences: b.DenyBackReferences || other.DenyBackReferences,
+		IsFinal:            b.IsFinal || other.IsFinal,
+		AllowTrashed:       b.AllowTrashed && other.AllowTrashed,
+	}
+	if b.Relations == nil && other.Relations == nil {
+		return merged
+	}
+
+	merged.Relations = make(map[string]*Blacklist, max(len(b.Relations), len(other.Relations)))
+	for _, name := range mergeLists(lo.Keys(b.Relations), lo.Keys(other.Relations)) {
+		relation, otherRelation := b.Relations[name], other.Relations[name]
+		if relation == nil && otherRelation == nil {
+			merged.Relations[name] = nil
+			continue
+		}
+		m := lo.FromPtr(relation).Merge(lo.FromPtr(otherRelation))
+		merged.Relations[name] = &m
+	}
+	return merged
+}
+
+// mergeLists returns a new slice containing the elements of a followed by
+// the elements of b that are not in a. Returns nil if both slices are nil.
+func mergeLists(a, b []string) []string {
+	if a == nil && b == nil {
+		return nil
+	}
+	return lo.Uniq(append(slices.Clone(a), b...))
+}
@@ -0,0 +1,379 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
+)
+
+func rel(name string) *schema.Relationship {
+	return &schema.Relationship{Name: name}
+}
+
+func TestMatchRelationPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    []string
+		want    bool
+	}{
+		{pattern: "Relation", path: []string{"Relation"}, want: true},
+		{pattern: "Relation", path: []string{"Other"}, want: false},
+		{pattern: "Relation", path: []string{"Relation", "Parent"}, want: false},
+		{pattern: "*", path: []string{"Relation"}, want: true},
+		{pattern: "*", path: []string{"Relation", "Parent"}, want: false},
+		{pattern: "*.AuditLogs", path: []string{"Relation", "AuditLogs"}, want: true},
+		{pattern: "*.AuditLogs", path: []string{"AuditLogs"}, want: false},
+		{pattern: "*.AuditLogs", path: []string{"Relation", "Parent"}, want: false},
+		{pattern: "User.*", path: []string{"User", "Articles"}, want: true},
+		{pattern: "User.*", path: []string{"User"}, want: false},
+		{pattern: "User.*", path: []string{"Author", "Articles"}, want: false},
+		{pattern: "User.*.Comments", path: []string{"User", "Articles", "Comments"}, want: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.pattern, func(t *testing.T) {
+			assert.Equal(t, c.want, matchRelationPattern(c.pattern, c.path))
+		})
+	}
+}
+
+func TestBlacklistPath(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		path := newBlacklistPath(nil)
+		assert.Nil(t, path.current())
+		assert.False(t, path.isDenied(rel("Relation")))
+		path = path.next(rel("Relation"))
+		assert.Nil(t, path.current())
+		assert.Equal(t, []*schema.Relationship{rel("Relation")}, path.relations)
+		assert.False(t, path.isDenied(rel("Parent")))
+	})
+
+	t.Run("next", func(t *testing.T) {
+		relationBlacklist := &Blacklist{FieldsBlacklist: []string{"a"}}
+		blacklist := &Blacklist{
+			Relations: map[string]*Blacklist{"Relation": relationBlacklist},
+		}
+		path := newBlacklistPath(blacklist)
+		assert.Same(t, blacklist, path.current())
+
+		next := path.next(rel("Relation"))
+		assert.Same(t, relationBlacklist, next.current())
+		assert.Equal(t, []*Blacklist{blacklist, relationBlacklist}, next.blacklists)
+		assert.Equal(t, []*schema.Relationship{rel("Relation")}, next.relations)
+
+		// The parent path is not modified
+		assert.Equal(t, []*Blacklist{blacklist}, path.blacklists)
+		assert.Empty(t, path.relations)
+
+		next = next.next(rel("Parent"))
+		assert.Nil(t, next.current())
+		assert.Equal(t, []*schema.Relationship{rel("Relation"), rel("Parent")}, next.relations)
+	})
+
+	t.Run("relations_blacklist", func(t *testing.T) {
+		path := newBlacklistPath(&Blacklist{
+			RelationsBlacklist: []string{"Relation"},
+			Relations: map[string]*Blacklist{
+				"Other": {RelationsBlacklist: []string{"Parent"}},
+			},
+		})
+		assert.True(t, path.isDenied(rel("Relation")))
+		assert.False(t, path.isDenied(rel("Other")))
+		assert.True(t, path.next(rel("Other")).isDenied(rel("Parent")))
+		assert.False(t, path.next(rel("Other")).isDenied(rel("Relation")))
+		assert.False(t, path.next(rel("Other")).next(rel("Child")).isDenied(rel("Parent")))
+	})
+
+	t.Run("wildcards", func(t *testing.T) {
+		path := newBlacklistPath(&Blacklist{
+			RelationsBlacklist: []string{"*.AuditLogs", "User.*"},
+		})
+		assert.False(t, path.isDenied(rel("AuditLogs")))
+		assert.False(t, path.isDenied(rel("User")))
+		assert.True(t, path.next(rel("Article")).isDenied(rel("AuditLogs")))
+		assert.False(t, path.next(rel("Article")).next(rel("Comment")).isDenied(rel("AuditLogs")))
+		assert.True(t, path.next(rel("User")).isDenied(rel("Articles")))
+		assert.False(t, path.next(rel("Author")).isDenied(rel("Articles")))
+	})
+
+	t.Run("wildcards_nested", func(t *testing.T) {
+		path := newBlacklistPath(&Blacklist{
+			Relations: map[string]*Blacklist{
+				"Article": {RelationsBlacklist: []string{"*.AuditLogs"}},
+			},
+		})
+		assert.False(t, path.next(rel("Article")).isDenied(rel("AuditLogs")))
+		assert.True(t, path.next(rel("Article")).next(rel("Comment")).isDenied(rel("AuditLogs")))
+		assert.False(t, path.next(rel("Comment")).isDenied(rel("AuditLogs")))
+	})
+
+	t.Run("denied_relations", func(t *testing.T) {
+		path := newBlacklistPath(&Blacklist{
+			DeniedRelations: []string{"AuditLogs", "User.Articles"},
+		})
+		assert.True(t, path.isDenied(rel("AuditLogs")))
+		assert.True(t, path.next(rel("Article")).isDenied(rel("AuditLogs")))
+		assert.True(t, path.next(rel("Article")).next(rel("Comment")).isDenied(rel("AuditLogs")))
+		assert.False(t, path.isDenied(rel("Articles")))
+		assert.True(t, path.next(rel("User")).isDenied(rel("Articles")))
+		assert.True(t, path.next(rel("Comment")).next(rel("User")).isDenied(rel("Articles")))
+		assert.False(t, path.next(rel("Author")).isDenied(rel("Articles")))
+	})
+
+	t.Run("denied_relations_nested", func(t *testing.T) {
+		path := newBlacklistPath(&Blacklist{
+			Relations: map[string]*Blacklist{
+				"Article": {DeniedRelations: []string{"AuditLogs"}},
+			},
+		})
+		assert.False(t, path.isDenied(rel("AuditLogs")))
+		assert.True(t, path.next(rel("Article")).isDenied(rel("AuditLogs")))
+		assert.True(t, path.next(rel("Article")).next(rel("Comment")).isDenied(rel("AuditLogs")))
+		assert.False(t, path.next(rel("Comment")).isDenied(rel("AuditLogs")))
+	})
+
+	t.Run("final", func(t *testing.T) {
+		path := newBlacklistPath(&Blacklist{
+			Relations: map[string]*Blacklist{
+				"Article": {IsFinal: true},
+			},
+		})
+		assert.False(t, path.isDenied(rel("Article")))
+		assert.True(t, path.next(rel("Article")).isDenied(rel("Comment")))
+	})
+}
+
+func TestBlacklistWildcardJoin(t *testing.T) {
+	db := openDryRunDB(t)
+	schema, err := parseModel(db, &JoinHopTestModel{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	newJoin := func(relation string) *Join {
+		return &Join{Relation: relation, selectCache: map[string][]string{}}
+	}
+
+	blacklist := Blacklist{RelationsBlacklist: []string{"Relation.*"}}
+	assert.Len(t, newJoin("Relation").Scopes(blacklist, schema), 1)
+	assert.Nil(t, newJoin("Relation.Parent").Scopes(blacklist, schema))
+
+	blacklist = Blacklist{DeniedRelations: []string{"Parent"}}
+	assert.Len(t, newJoin("Relation").Scopes(blacklist, schema), 1)
+	assert.Nil(t, newJoin("Relation.Parent").Scopes(blacklist, schema))
+
+	blacklist = Blacklist{DeniedRelations: []string{"Parent.Relation"}}
+	assert.Len(t, newJoin("Relation.Parent").Scopes(blacklist, schema), 2)
+	assert.Nil(t, newJoin("Relation.Parent.Relation").Scopes(blacklist, schema))
+}
+
+func TestBlacklistWildcardGetField(t *testing.T) {
+	db := openDryRunDB(t)
+	schema, err := parseModel(db, &JoinHopTestModel{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	blacklist := &Blacklist{RelationsBlacklist: []string{"*.Parent"}}
+	field, _, joinName := getField("Relation.b", schema, blacklist)
+	assert.NotNil(t, field)
+	assert.Equal(t, "Relation", joinName)
+	field, sch, joinName := getField("Relation.Parent.name", schema, blacklist)
+	assert.Nil(t, field)
+	assert.Nil(t, sch)
+	assert.Empty(t, joinName)
+
+	blacklist = &Blacklist{
+		DeniedRelations: []string{"Relation"},
+	}
+	field, sch, joinName = getField("Relation.b", schema, blacklist)
+	assert.Nil(t, field)
+	assert.Nil(t, sch)
+	assert.Empty(t, joinName)
+
+	// Field blacklist of the nested relation is still applied
+	blacklist = &Blacklist{
+		DeniedRelations: []string{"Parent.Relation"},
+		Relations: map[string]*Blacklist{
+			"Relation": {
+				Relations: map[string]*Blacklist{
+					"Parent": {FieldsBlacklist: []string{"name"}},
+				},
+			},
+		},
+	}
+	field, _, _ = getField("Relation.Parent.id", schema, blacklist)
+	assert.NotNil(t, field)
+	field, _, _ = getField("Relation.Parent.name", schema, blacklist)
+	assert.Nil(t, field)
+	field, _, _ = getField("Relation.Parent.Relation.b", schema, blacklist)
+	assert.Nil(t, field)
+}
+
+type BlacklistTestUser struct {
+	Name     string
+	Articles []*BlacklistTestArticle `gorm:"foreignKey:UserID"`
+	ID       int                     `gorm:"primaryKey"`
+}
+
+type BlacklistTestArticle struct {
+	User       *BlacklistTestUser
+	Reviewer   *BlacklistTestUser
+	Title      string
+	ID         int `gorm:"primaryKey"`
+	UserID     int
+	ReviewerID int
+}
+
+func TestBlacklistDenyBackReferences(t *testing.T) {
+	db := openDryRunDB(t)
+	userSchema, err := parseModel(db, &BlacklistTestUser{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	articleSchema, err := parseModel(db, &BlacklistTestArticle{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	articles := userSchema.Relationships.Relations["Articles"]
+	assert.True(t, isBackReference(articles, articleSchema.Relationships.Relations["User"]))
+	assert.True(t, isBackReference(articleSchema.Relationships.Relations["User"], articles))
+	assert.False(t, isBackReference(articles, articleSchema.Relationships.Relations["Reviewer"]))
+
+	newJoin := func(relation string) *Join {
+		return &Join{Relation: relation, selectCache: map[string][]string{}}
+	}
+
+	blacklist := Blacklist{}
+	assert.Len(t, newJoin("Articles.User").Scopes(blacklist, userSchema), 2)
+
+	blacklist = Blacklist{DenyBackReferences: true}
+	assert.Len(t, newJoin("Articles").Scopes(blacklist, userSchema), 1)
+	assert.Nil(t, newJoin("Articles.User").Scopes(blacklist, userSchema))
+	assert.Len(t, newJoin("Articles.Reviewer").Scopes(blacklist, userSchema), 2)
+	assert.Len(t, newJoin("Articles.Reviewer.Articles").Scopes(blacklist, userSchema), 3)
+	assert.Nil(t, newJoin("Articles.Reviewer.Articles.User").Scopes(blacklist, userSchema))
+
+	// Only applies to the sub-tree of the blacklist defining it
+	blacklist = Blacklist{
+		Relations: map[string]*Blacklist{
+			"Reviewer": {DenyBackReferences: true},
+		},
+	}
+	assert.Len(t, newJoin("User.Articles").Scopes(blacklist, articleSchema), 2)
+	assert.Len(t, newJoin("Reviewer.Articles.Reviewer").Scopes(blacklist, articleSchema), 3)
+	assert.Nil(t, newJoin("Reviewer.Articles.User").Scopes(blacklist, articleSchema))
+}
+
+func TestBlacklistClone(t *testing.T) {
+	var nilBlacklist *Blacklist
+	assert.Nil(t, nilBlacklist.Clone())
+
+	blacklist := &Blacklist{
+		FieldsBlacklist:    []string{"a"},
+		RelationsBlacklist: []string{"B"},
+		DeniedRelations:    []string{"C"},
+		DenyBackReferences: true,
+		IsFinal:            true,
+		Relations: map[string]*Blacklist{
+			"D": {FieldsBlacklist: []string{"e"}, Relations: map[string]*Blacklist{"F": nil}},
+		},
+	}
+	clone := blacklist.Clone()
+	assert.Equal(t, blacklist, clone)
+
+	clone.FieldsBlacklist[0] = "z"
+	clone.RelationsBlacklist[0] = "z"
+	clone.DeniedRelations[0] = "z"
+	clone.Relations["D"].FieldsBlacklist[0] = "z"
+	clone.Relations["D"].Relations["F"] = &Blacklist{}
+	assert.Equal(t, []string{"a"}, blacklist.FieldsBlacklist)
+	assert.Equal(t, []string{"B"}, blacklist.RelationsBlacklist)
+	assert.Equal(t, []string{"C"}, blacklist.DeniedRelations)
+	assert.Equal(t, []string{"e"}, blacklist.Relations["D"].FieldsBlacklist)
+	assert.Nil(t, blacklist.Relations["D"].Relations["F"])
+}
+
+func TestBlacklistMerge(t *testing.T) {
+	base := Blacklist{
+		FieldsBlacklist:    []string{"tenant_id", "deleted_at"},
+		RelationsBlacklist: []string{"AuditLogs"},
+		Relations: map[string]*Blacklist{
+			"Owner":   {FieldsBlacklist: []string{"password"}},
+			"Company": {FieldsBlacklist: []string{"tenant_id"}},
+			"Empty":   nil,
+		},
+	}
+	endpoint := Blacklist{
+		FieldsBlacklist:    []string{"deleted_at", "secret"},
+		DeniedRelations:    []string{"Tokens"},
+		DenyBackReferences: true,
+		Relations: map[string]*Blacklist{
+			"Owner":    {FieldsBlacklist: []string{"email", "password"}, IsFinal: true},
+			"Articles": {RelationsBlacklist: []string{"Comments"}},
+			"Company":  nil,
+		},
+	}
+
+	expected := Blacklist{
+		FieldsBlacklist:    []string{"tenant_id", "deleted_at", "secret"},
+		RelationsBlacklist: []string{"AuditLogs"},
+		DeniedRelations:    []string{"Tokens"},
+		DenyBackReferences: true,
+		Relations: map[string]*Blacklist{
+			"Owner":    {FieldsBlacklist: []string{"password", "email"}, IsFinal: true},
+			"Company":  {FieldsBlacklist: []string{"tenant_id"}},
+			"Articles": {RelationsBlacklist: []string{"Comments"}},
+			"Empty":    nil,
+		},
+	}
+	merged := base.Merge(endpoint)
+	assert.Equal(t, expected, merged)
+
+	// The merged blacklist doesn't share memory with the original ones
+	merged.FieldsBlacklist[0] = "z"
+	merged.Relations["Owner"].FieldsBlacklist[0] = "z"
+	merged.Relations["Company"].FieldsBlacklist[0] = "z"
+	merged.Relations["Articles"].RelationsBlacklist[0] = "z"
+	assert.Equal(t, []string{"tenant_id", "deleted_at"}, base.FieldsBlacklist)
+	assert.Equal(t, []string{"password"}, base.Relations["Owner"].FieldsBlacklist)
+	assert.Equal(t, []string{"tenant_id"}, base.Relations["Company"].FieldsBlacklist)
+	assert.Equal(t, []string{"Comments"}, endpoint.Relations["Articles"].RelationsBlacklist)
+
+	assert.Equal(t, Blacklist{}, Blacklist{}.Merge(Blacklist{}))
+	assert.Equal(t, Blacklist{IsFinal: true}, Blacklist{IsFinal: true}.Merge(Blacklist{}))
+	assert.Equal(t, Blacklist{DenyBackReferences: true}, Blacklist{}.Merge(Blacklist{DenyBackReferences: true}))
+}
+
+func TestBlacklistMergeAllowTrashed(t *testing.T) {
+	// A merge never grants a permission that one of the blacklists doesn't grant
+	assert.Equal(t, Blacklist{}, Blacklist{AllowTrashed: true}.Merge(Blacklist{}))
+	assert.Equal(t, Blacklist{}, Blacklist{}.Merge(Blacklist{AllowTrashed: true}))
+	assert.Equal(t, Blacklist{AllowTrashed: true}, Blacklist{AllowTrashed: true}.Merge(Blacklist{AllowTrashed: true}))
+
+	base := Blacklist{
+		Relations: map[string]*Blacklist{
+			"Comments": {AllowTrashed: true},
+			"Tags":     {AllowTrashed: true, Relations: map[string]*Blacklist{"Author": {AllowTrashed: true}}},
+			"Owner":    {AllowTrashed: true},
+		},
+	}
+	endpoint := Blacklist{
+		Relations: map[string]*Blacklist{
+			"Comments": {AllowTrashed: true},
+			"Owner":    {FieldsBlacklist: []string{"password"}},
+		},
+	}
+
+	expected := Blacklist{
+		Relations: map[string]*Blacklist{
+			"Comments": {AllowTrashed: true},
+			"Tags":     {Relations: map[string]*Blacklist{"Author": {}}},
+			"Owner":    {FieldsBlacklist: []string{"password"}},
+		},
+	}
+	assert.Equal(t, expected, base.Merge(endpoint))
+	assert.Equal(t, expected, endpoint.Merge(base))
+}
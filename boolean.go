@@ -0,0 +1,60 @@
+package filter
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm/schema"
+)
+
+// BoolLiteralsProvider provides the literals accepted as arguments of the filters on boolean
+// fields. The context of the query is given so the literals can depend on the request
+// (e.g. on the language of the client).
+type BoolLiteralsProvider interface {
+	BoolLiterals(ctx context.Context) *BoolLiterals
+}
+
+// BoolLiterals a `BoolLiteralsProvider` accepting the same literals for all the requests.
+// The arguments matching one of the `True` literals are considered true and the ones
+// matching one of the `False` literals are considered false. Other arguments cannot be
+// converted, as any invalid argument. The comparison is case-sensitive.
+type BoolLiterals struct {
+	True  []string
+	False []string
+}
+
+// StrictBoolLiterals only accepts "true" and "false".
+var StrictBoolLiterals = &BoolLiterals{True: []string{"true"}, False: []string{"false"}}
+
+// BoolLiterals returns the literals themselves.
+func (b *BoolLiterals) BoolLiterals(_ context.Context) *BoolLiterals {
+	return b
+}
+
+// normalize returns a copy of the given filter with its arguments replaced by "true" or "false"
+// if the filtered field is a boolean. The arguments not matching any literal are replaced by an
+// empty string so they cannot be converted. Returns the filter as is if the field is not a boolean.
+func (b *BoolLiterals) normalize(f *Filter, sch *schema.Schema, blacklist *Blacklist) *Filter {
+	field, _, _ := getField(f.Field, sch, blacklist)
+	if field == nil {
+		return f
+	}
+	if dataType := getDataType(field); dataType != DataTypeBool && dataType != DataTypeBoolArray {
+		return f
+	}
+	args := lo.Map(f.Args, func(arg string, _ int) string {
+		switch {
+		case lo.Contains(b.True, arg):
+			return "true"
+		case lo.Contains(b.False, arg):
+			return "false"
+		}
+		return ""
+	})
+	return &Filter{
+		Field:    f.Field,
+		Operator: f.Operator,
+		Args:     args,
+		Or:       f.Or,
+	}
+}
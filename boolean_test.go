@@ -0,0 +1,97 @@
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type BoolTestModel struct {
+	Name   string
+	Active bool
+	ID     uint
+}
+
+type boolTestLangKey struct{}
+
+type boolTestLocalizedLiterals map[string]*BoolLiterals
+
+func (l boolTestLocalizedLiterals) BoolLiterals(ctx context.Context) *BoolLiterals {
+	lang, _ := ctx.Value(boolTestLangKey{}).(string)
+	return l[lang]
+}
+
+func TestScopeBoolLiterals(t *testing.T) {
+	localized := boolTestLocalizedLiterals{
+		"fr": {True: []string{"oui", "vrai"}, False: []string{"non", "faux"}},
+	}
+	selectFrom := "SELECT `bool_test_models`.`name`,`bool_test_models`.`active`,`bool_test_models`.`id` FROM `bool_test_models` "
+
+	cases := []struct {
+		literals BoolLiteralsProvider
+		desc     string
+		lang     string
+		filter   *Filter
+		want     string
+		wantVars []any
+	}{
+		{
+			desc:     "default",
+			filter:   &Filter{Field: "active", Operator: Operators["$eq"], Args: []string{"yes"}},
+			want:     selectFrom + "WHERE `bool_test_models`.`active` = ?",
+			wantVars: []any{true},
+		},
+		{
+			desc:     "strict_valid",
+			literals: StrictBoolLiterals,
+			filter:   &Filter{Field: "active", Operator: Operators["$eq"], Args: []string{"false"}},
+			want:     selectFrom + "WHERE `bool_test_models`.`active` = ?",
+			wantVars: []any{false},
+		},
+		{
+			desc:     "strict_invalid",
+			literals: StrictBoolLiterals,
+			filter:   &Filter{Field: "active", Operator: Operators["$eq"], Args: []string{"yes"}},
+			want:     selectFrom + "WHERE FALSE",
+		},
+		{
+			desc:     "localized",
+			literals: localized,
+			lang:     "fr",
+			filter:   &Filter{Field: "active", Operator: Operators["$in"], Args: []string{"oui", "non"}},
+			want:     selectFrom + "WHERE `bool_test_models`.`active` IN (?,?)",
+			wantVars: []any{true, false},
+		},
+		{
+			desc:     "localized_fallback",
+			literals: localized,
+			lang:     "en",
+			filter:   &Filter{Field: "active", Operator: Operators["$eq"], Args: []string{"on"}},
+			want:     selectFrom + "WHERE `bool_test_models`.`active` = ?",
+			wantVars: []any{true},
+		},
+		{
+			desc:     "not_bool",
+			literals: localized,
+			lang:     "fr",
+			filter:   &Filter{Field: "name", Operator: Operators["$eq"], Args: []string{"oui"}},
+			want:     selectFrom + "WHERE `bool_test_models`.`name` = ?",
+			wantVars: []any{"oui"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), boolTestLangKey{}, c.lang)
+			request := &Request{Filter: typeutil.NewUndefined([]*Filter{c.filter})}
+			results := []*BoolTestModel{}
+			db := (&Settings[*BoolTestModel]{BoolLiterals: c.literals}).ScopeUnpaginated(openDryRunDB(t).WithContext(ctx), request, &results)
+			require.NoError(t, db.Error)
+			assert.Equal(t, c.want, db.Statement.SQL.String())
+			assert.Equal(t, c.wantVars, db.Statement.Vars)
+		})
+	}
+}
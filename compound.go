@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/errors"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+// ScopeUnion using the default FilterSettings. See `FilterSettings.ScopeUnion()` for more details.
+func ScopeUnion[T any](db *gorm.DB, first, second *Request, dest *[]T) *gorm.DB {
+	return (&Settings[T]{}).ScopeUnion(db, first, second, dest)
+}
+
+// ScopeIntersect using the default FilterSettings. See `FilterSettings.ScopeIntersect()` for more details.
+func ScopeIntersect[T any](db *gorm.DB, first, second *Request, dest *[]T) *gorm.DB {
+	return (&Settings[T]{}).ScopeIntersect(db, first, second, dest)
+}
+
+// ScopeUnion finds the records matching the filters and search of at least one of the
+// two given requests (e.g. to combine saved searches), without duplicates and without pagination.
+// Each request is applied to a subquery of the given `*gorm.DB` and the subqueries are combined using `UNION`:
+//
+//	SELECT ... FROM (SELECT ... UNION SELECT ...) AS table ORDER BY ...
+//
+// Both subqueries select the same columns: the fields of the first request, or the default selectable
+// fields. The sorts of the first request are applied to the combined records. The joins and pagination
+// options of both requests are ignored.
+//
+// Returns the `*gorm.DB` result, which can be used to check for database errors.
+// The records will be added in the given `dest` slice.
+// The given requests are expected to be validated using `ApplyValidation`.
+func (s *Settings[T]) ScopeUnion(db *gorm.DB, first, second *Request, dest *[]T) *gorm.DB {
+	return s.scopeCompound(db, "UNION", first, second, dest)
+}
+
+// ScopeIntersect finds the records matching the filters and search of both given requests,
+// without duplicates and without pagination. It works like `ScopeUnion()` but the subqueries
+// are combined using `INTERSECT`. Not supported by MySQL before 8.0.31.
+func (s *Settings[T]) ScopeIntersect(db *gorm.DB, first, second *Request, dest *[]T) *gorm.DB {
+	return s.scopeCompound(db, "INTERSECT", first, second, dest)
+}
+
+func (s *Settings[T]) scopeCompound(db *gorm.DB, operator string, first, second *Request, dest *[]T) *gorm.DB {
+	sch, err := parseModel(db, dest)
+	if err != nil {
+		db = db.Session(&gorm.Session{})
+		db.AddError(errors.Errorf("%w: %w", ErrUnsupportedModel, err))
+		return db
+	}
+	sch, err = withVirtualRelations(db, sch, s.VirtualRelations)
+	if err != nil {
+		db = db.Session(&gorm.Session{})
+		db.AddError(err)
+		return db
+	}
+
+	subqueries := make([]any, 0, 2)
+	for _, request := range []*Request{first, second} {
+		// Preloading relations in a subquery is not possible, and both
+		// subqueries must select the same columns.
+		r := *request
+		r.Join = typeutil.Undefined[[]*Join]{}
+		r.Fields = first.Fields
+		subquery, subquerySchema, _ := s.scopeCommon(db.Session(&gorm.Session{}), &r, dest)
+		if subquerySchema == nil {
+			return subquery
+		}
+		fieldsDB := s.scopeFields(subquery, &r, subquerySchema, false)
+		if fieldsDB == nil {
+			return subquery
+		}
+		subqueries = append(subqueries, fieldsDB)
+	}
+
+	// The combined records are aliased with the name of the model's table
+	// so the selected columns and the sorts can be qualified as usual.
+	tx := s.applyQueryLogger(db.Session(&gorm.Session{NewDB: true}))
+	tx = tx.Model(dest).Table("(? "+operator+" ?) AS "+tx.Statement.Quote(sch.Table), subqueries...)
+	tx = s.scopeSort(tx, first, sch)
+	if fieldsDB := s.scopeFields(tx, first, sch, false); fieldsDB != nil {
+		tx = fieldsDB
+	} else {
+		return tx
+	}
+	return tx.Find(dest)
+}
@@ -0,0 +1,61 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+func TestScopeUnion(t *testing.T) {
+	first := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}}}),
+		Sort:   typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortDescending}}),
+		Join:   typeutil.NewUndefined([]*Join{{Relation: "Relation"}}),
+		Page:   typeutil.NewUndefined(2),
+	}
+	second := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "Relation.name", Operator: Operators["$eq"], Args: []string{"b"}}}),
+		Fields: typeutil.NewUndefined([]string{"name"}),
+	}
+
+	results := []*FilterTestModel{}
+	db := ScopeUnion(openDryRunDB(t).Where("id > ?", 1), first, second, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM "+
+			"(SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` WHERE id > ? AND `filter_test_models`.`name` = ? "+
+			"UNION "+
+			"SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` LEFT JOIN `filter_test_relations` `Relation` ON `filter_test_models`.`id` = `Relation`.`parent_id` WHERE id > ? AND `Relation`.`name` = ?) AS `filter_test_models` "+
+			"ORDER BY `filter_test_models`.`name` DESC",
+		db.Statement.SQL.String(),
+	)
+	assert.Equal(t, []any{1, "a", 1, "b"}, db.Statement.Vars)
+	assert.Empty(t, db.Statement.Preloads)
+}
+
+func TestScopeIntersect(t *testing.T) {
+	first := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}}}),
+		Fields: typeutil.NewUndefined([]string{"id"}),
+	}
+	second := &Request{Search: typeutil.NewUndefined("b")}
+
+	results := []*FilterTestModel{}
+	db := (&Settings[*FilterTestModel]{FieldsSearch: []string{"name"}}).ScopeIntersect(openDryRunDB(t), first, second, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT `filter_test_models`.`id` FROM "+
+			"(SELECT `filter_test_models`.`id` FROM `filter_test_models` WHERE `filter_test_models`.`name` = ? "+
+			"INTERSECT "+
+			"SELECT `filter_test_models`.`id` FROM `filter_test_models` WHERE `filter_test_models`.`name` LIKE ?) AS `filter_test_models`",
+		db.Statement.SQL.String(),
+	)
+
+	t.Run("invalid_model", func(t *testing.T) {
+		model := []string{}
+		db := ScopeIntersect(openDryRunDB(t), first, second, &model)
+		require.ErrorIs(t, db.Error, ErrUnsupportedModel)
+	})
+}
@@ -0,0 +1,91 @@
+package filter
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// ComputedFilter a field that doesn't exist on the model, computed by a SQL expression
+// defined at runtime. Unlike computed columns, it can only be used in filters: it is never
+// selected and cannot be sorted on. This is useful for values depending on parameters
+// that are only known when the request is handled, such as the distance to a location.
+type ComputedFilter struct {
+	// Params returns the values of the `?` placeholders of the SQL expression, in order.
+	// It is called with the context of the `*gorm.DB` given to the scope (e.g. to read
+	// coordinates stored in the request context). The values must be numbers: they are
+	// rendered as SQL literals in the expression. Can be nil if there are no placeholders.
+	Params func(ctx context.Context) ([]any, error)
+
+	// SQL the expression computing the value of the field, using the same syntax as
+	// the `computed` struct tag: `~~~ct~~~` is replaced by the quoted table of the model.
+	SQL string
+
+	// Type the data type of the result of the expression, used to convert the filter arguments.
+	Type DataType
+}
+
+// scope returns the scope applying the given filter on the computed field,
+// using the given expression (see `expression()`).
+func (c *ComputedFilter) scope(expr string, f *Filter, table string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return f.Operator.Function(tx, f.withTable(table), expr, c.Type)
+	}
+}
+
+// expression returns the SQL expression of the computed field, with its placeholders
+// replaced by the parameters.
+func (c *ComputedFilter) expression(stmt *gorm.Statement, table string) (string, error) {
+	var params []any
+	if c.Params != nil {
+		var err error
+		params, err = c.Params(stmt.Context)
+		if err != nil {
+			return "", err
+		}
+	}
+	if count := strings.Count(c.SQL, "?"); count != len(params) {
+		return "", errors.Errorf("the expression has %d placeholder(s) but %d parameter(s) were given", count, len(params))
+	}
+
+	sql := strings.ReplaceAll(c.SQL, clause.CurrentTable, stmt.Quote(table))
+	builder := strings.Builder{}
+	i := 0
+	for _, r := range sql {
+		if r != '?' {
+			builder.WriteRune(r)
+			continue
+		}
+		literal, err := numberLiteral(params[i])
+		if err != nil {
+			return "", errors.Errorf("parameter %d: %w", i, err)
+		}
+		builder.WriteString(literal)
+		i++
+	}
+	return "(" + builder.String() + ")", nil
+}
+
+// numberLiteral returns the SQL literal representing the given number.
+func numberLiteral(value any) (string, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", errors.Errorf("%v is not a finite number", f)
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	}
+	return "", errors.Errorf("expected a number, got %T", value)
+}
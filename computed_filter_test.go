@@ -0,0 +1,99 @@
+package filter
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/errors"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type ComputedFilterTestStore struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+	ID        uint
+}
+
+type computedFilterTestLocation struct{}
+
+func TestScopeComputedFilter(t *testing.T) {
+	settings := &Settings[*ComputedFilterTestStore]{
+		ComputedFilters: map[string]*ComputedFilter{
+			"distance": {
+				SQL:  "ABS(~~~ct~~~.latitude - ?) + ABS(~~~ct~~~.longitude - ?)",
+				Type: DataTypeFloat64,
+				Params: func(ctx context.Context) ([]any, error) {
+					location, ok := ctx.Value(computedFilterTestLocation{}).([]float64)
+					if !ok {
+						return nil, errors.New("missing location")
+					}
+					return []any{location[0], location[1]}, nil
+				},
+			},
+		},
+	}
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "distance", Operator: Operators["$lt"], Args: []string{"0.5"}},
+			{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}},
+		}),
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), computedFilterTestLocation{}, []float64{48.85, 2.35})
+		results := []*ComputedFilterTestStore{}
+		db := settings.ScopeUnpaginated(openDryRunDB(t).WithContext(ctx), request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t,
+			"SELECT `computed_filter_test_stores`.`name`,`computed_filter_test_stores`.`latitude`,`computed_filter_test_stores`.`longitude`,`computed_filter_test_stores`.`id` FROM `computed_filter_test_stores` WHERE ((ABS(`computed_filter_test_stores`.latitude - 48.85) + ABS(`computed_filter_test_stores`.longitude - 2.35)) < 0.5 AND `computed_filter_test_stores`.`name` = \"a\")",
+			db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...),
+		)
+		issues, err := settings.Check(openDryRunDB(t), request)
+		require.NoError(t, err)
+		assert.Nil(t, issues)
+	})
+
+	t.Run("params_error", func(t *testing.T) {
+		results := []*ComputedFilterTestStore{}
+		db := settings.ScopeUnpaginated(openDryRunDB(t), request, &results)
+		require.Error(t, db.Error)
+		assert.Contains(t, db.Error.Error(), `computed filter "distance": missing location`)
+	})
+}
+
+func TestComputedFilterExpression(t *testing.T) {
+	stmt := openDryRunDB(t).Statement
+
+	cases := []struct {
+		params  []any
+		desc    string
+		want    string
+		wantErr string
+	}{
+		{desc: "numbers", params: []any{1, uint8(2), float32(0.5)}, want: "(`stores`.a + 1 * 2 - 0.5)"},
+		{desc: "not_a_number", params: []any{1, "2; DROP TABLE stores", 3}, wantErr: "parameter 1: expected a number, got string"},
+		{desc: "infinite", params: []any{1, 2, math.Inf(1)}, wantErr: "parameter 2: +Inf is not a finite number"},
+		{desc: "count_mismatch", params: []any{1}, wantErr: "the expression has 3 placeholder(s) but 1 parameter(s) were given"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			computed := &ComputedFilter{
+				SQL:    "~~~ct~~~.a + ? * ? - ?",
+				Params: func(_ context.Context) ([]any, error) { return c.params, nil },
+			}
+			expr, err := computed.expression(stmt, "stores")
+			if c.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, c.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.want, expr)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package filter
+
+import (
+	"fmt"
+	"io"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Condition a boolean condition built programmatically, for example by report generators
+// constructing complex criteria in code. Conditions are attached to a `Request` using
+// the `Request.Condition` field, and are compiled by the scopes in the same way as
+// filters: the blacklist and the `DisableFilter` setting apply to them too.
+//
+//	condition := filter.Or(
+//		filter.Cond("status", filter.Operators["$eq"], "active"),
+//		filter.Cond("created_at", filter.Operators["$gt"], "2024-01-01").And(
+//			filter.Not(filter.Cond("Owner.name", filter.Operators["$cont"], "test")),
+//		),
+//	)
+type Condition interface {
+	// And returns a condition matching if this condition and all the given conditions match.
+	And(conditions ...Condition) Condition
+	// Or returns a condition matching if this condition or any of the given conditions match.
+	Or(conditions ...Condition) Condition
+	// Not returns a condition matching if this condition doesn't match.
+	Not() Condition
+	// Node returns the tree representation of the condition.
+	Node() *ConditionNode
+}
+
+// Cond returns a condition applying the given operator to a field. The field can reference
+// a "has one" or "belongs to" relation in the same way as filters (e.g. "Relation.name").
+func Cond(field string, operator *Operator, args ...string) Condition {
+	return &ConditionNode{
+		Type:   ConditionFilter,
+		Filter: &Filter{Field: field, Operator: operator, Args: args},
+	}
+}
+
+// And returns a condition matching if all the given conditions match.
+// The nil conditions are ignored.
+func And(conditions ...Condition) Condition {
+	return combine(ConditionAnd, conditions)
+}
+
+// Or returns a condition matching if any of the given conditions match.
+// The nil conditions are ignored.
+func Or(conditions ...Condition) Condition {
+	return combine(ConditionOr, conditions)
+}
+
+// Not returns a condition matching if the given condition doesn't match.
+// If the given condition is nil, the returned condition is empty and is ignored.
+func Not(condition Condition) Condition {
+	return combine(ConditionNot, []Condition{condition})
+}
+
+func combine(conditionType ConditionType, conditions []Condition) *ConditionNode {
+	node := &ConditionNode{Type: conditionType, Children: make([]*ConditionNode, 0, len(conditions))}
+	for _, c := range conditions {
+		if c != nil {
+			node.add(c.Node())
+		}
+	}
+	return node
+}
+
+// And returns a condition matching if this condition and all the given conditions match.
+func (n *ConditionNode) And(conditions ...Condition) Condition {
+	return And(append([]Condition{n}, conditions...)...)
+}
+
+// Or returns a condition matching if this condition or any of the given conditions match.
+func (n *ConditionNode) Or(conditions ...Condition) Condition {
+	return Or(append([]Condition{n}, conditions...)...)
+}
+
+// Not returns a condition matching if this condition doesn't match.
+func (n *ConditionNode) Not() Condition {
+	return Not(n)
+}
+
+// Node returns the node itself.
+func (n *ConditionNode) Node() *ConditionNode {
+	return n
+}
+
+// conditionScope returns the scope adding the condition represented by the given node
+// to the `WHERE` clause, or nil if the condition is empty. The filters are turned into
+// scopes using the given function, which returns nil if the filter cannot be applied.
+// Search nodes are ignored.
+func conditionScope(node *ConditionNode, filterScope func(*Filter) func(*gorm.DB) *gorm.DB) func(*gorm.DB) *gorm.DB {
+	if node.Type == ConditionFilter {
+		f := node.Filter
+		if f.Or {
+			f = &Filter{Field: f.Field, Operator: f.Operator, Args: f.Args}
+		}
+		return filterScope(f)
+	}
+
+	children := make([]func(*gorm.DB) *gorm.DB, 0, len(node.Children))
+	for _, child := range node.Children {
+		if scope := conditionScope(child, filterScope); scope != nil {
+			children = append(children, scope)
+		}
+	}
+	if len(children) == 0 {
+		return nil
+	}
+
+	switch node.Type {
+	case ConditionAnd:
+		return func(tx *gorm.DB) *gorm.DB {
+			group := tx.Session(&gorm.Session{NewDB: true})
+			for _, c := range children {
+				group = c(group)
+			}
+			return tx.Where(group)
+		}
+	case ConditionOr:
+		return func(tx *gorm.DB) *gorm.DB {
+			group := tx.Session(&gorm.Session{NewDB: true})
+			for _, c := range children {
+				group = group.Or(c(tx.Session(&gorm.Session{NewDB: true})))
+			}
+			return tx.Where(group)
+		}
+	case ConditionNot:
+		return func(tx *gorm.DB) *gorm.DB {
+			group := tx.Session(&gorm.Session{NewDB: true})
+			for _, c := range children {
+				group = c(group)
+			}
+			if where, ok := group.Statement.Clauses["WHERE"].Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+				return tx.Where(notExpression{exprs: where.Exprs})
+			}
+			return tx
+		}
+	}
+	return nil
+}
+
+// notExpression negates a group of `WHERE` expressions: `NOT (<exprs>)`.
+type notExpression struct {
+	exprs []clause.Expression
+}
+
+func (n notExpression) Build(builder clause.Builder) {
+	builder.WriteString("NOT (")
+	clause.Where{Exprs: n.exprs}.Build(builder)
+	builder.WriteByte(')')
+}
+
+// writeConditionShape writes the shape of the condition tree (node types, fields and operators)
+// without the filter arguments. Used by `Request.NormalizedHash()`.
+func writeConditionShape(w io.Writer, node *ConditionNode) {
+	if node.Type == ConditionFilter {
+		opName, _ := operatorName(node.Filter.Operator)
+		fmt.Fprintf(w, "%q%s", node.Filter.Field, opName)
+		return
+	}
+	fmt.Fprintf(w, "%s(", node.Type)
+	for _, child := range node.Children {
+		writeConditionShape(w, child)
+		fmt.Fprint(w, ",")
+	}
+	fmt.Fprint(w, ")")
+}
@@ -0,0 +1,118 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+func TestConditionCombinators(t *testing.T) {
+	a := Cond("a", Operators["$eq"], "1")
+	b := Cond("b", Operators["$isnull"])
+	c := Cond("c", Operators["$in"], "1", "2")
+
+	assert.Equal(t, &ConditionNode{Type: ConditionFilter, Filter: &Filter{Field: "a", Operator: Operators["$eq"], Args: []string{"1"}}}, a)
+	assert.Equal(t, "a||$eq||1 AND b||$isnull", a.And(b).Node().String())
+	assert.Equal(t, "a||$eq||1 OR b||$isnull OR c||$in||1,2", a.Or(b, c).Node().String())
+	assert.Equal(t, "NOT (a||$eq||1)", a.Not().Node().String())
+	assert.Equal(t, "(a||$eq||1 OR b||$isnull) AND NOT (c||$in||1,2)", And(Or(a, b), Not(c)).Node().String())
+	assert.Equal(t, "a||$eq||1", And(nil, a).Node().String())
+	assert.Empty(t, Or().Node().Children)
+	assert.Empty(t, Not(nil).Node().Children)
+	assert.Empty(t, Not((*ConditionNode)(nil)).Node().Children)
+}
+
+func TestConditionScopeNotNil(t *testing.T) {
+	request := &Request{
+		Condition: And(Cond("name", Operators["$eq"], "a"), Not(nil)),
+	}
+
+	db := openDryRunDB(t)
+	results := []*FilterTestModel{}
+	db = (&Settings[*FilterTestModel]{}).ScopeUnpaginated(db, request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` WHERE `filter_test_models`.`name` = ?",
+		db.Statement.SQL.String(),
+	)
+
+	db = openDryRunDB(t)
+	db = (&Settings[*FilterTestModel]{}).ScopeUnpaginated(db, &Request{Condition: Not(nil)}, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models`", db.Statement.SQL.String())
+}
+
+func TestConditionScope(t *testing.T) {
+	condition := Or(
+		Cond("name", Operators["$eq"], "a"),
+		And(
+			Cond("id", Operators["$gt"], "1"),
+			Not(Or(Cond("Relation.name", Operators["$cont"], "b"), Cond("id", Operators["$between"], "5", "10"))),
+		),
+	)
+	request := &Request{
+		Filter:    typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$ne"], Args: []string{"c"}}}),
+		Condition: condition,
+	}
+
+	db := openDryRunDB(t)
+	results := []*FilterTestModel{}
+	db = (&Settings[*FilterTestModel]{DisableFields: true}).ScopeUnpaginated(db, request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` LEFT JOIN `filter_test_relations` `Relation` ON `filter_test_models`.`id` = `Relation`.`parent_id` WHERE `filter_test_models`.`name` <> ? AND (`filter_test_models`.`name` = ? OR (`filter_test_models`.`id` > ? AND NOT (`Relation`.`name` LIKE ? OR (`filter_test_models`.`id` BETWEEN ? AND ?))))",
+		db.Statement.SQL.String(),
+	)
+	assert.Equal(t, []any{"c", "a", uint64(1), "%b%", uint64(5), uint64(10)}, db.Statement.Vars)
+	assert.Equal(t, "name||$ne||c AND (name||$eq||a OR (id||$gt||1 AND NOT (Relation.name||$cont||b OR id||$between||5,10)))", request.Tree().String())
+}
+
+func TestConditionScopeSingleExpression(t *testing.T) {
+	request := &Request{
+		Condition: Not(Cond("id", Operators["$between"], "5", "10")),
+	}
+
+	db := openDryRunDB(t)
+	results := []*FilterTestModel{}
+	db = (&Settings[*FilterTestModel]{}).ScopeUnpaginated(db, request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` WHERE NOT (`filter_test_models`.`id` BETWEEN ? AND ?)",
+		db.Statement.SQL.String(),
+	)
+}
+
+func TestConditionScopeBlacklist(t *testing.T) {
+	request := &Request{
+		Condition: And(
+			Cond("name", Operators["$eq"], "a"),
+			Not(Cond("Relation.name", Operators["$eq"], "b")),
+			Or(Cond("id", Operators["$eq"], "1"), Cond("Relation.name", Operators["$eq"], "c")),
+		),
+	}
+
+	db := openDryRunDB(t)
+	results := []*FilterTestModel{}
+	settings := &Settings[*FilterTestModel]{Blacklist: Blacklist{RelationsBlacklist: []string{"Relation"}}}
+	db = settings.ScopeUnpaginated(db, request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` WHERE `filter_test_models`.`name` = ? AND `filter_test_models`.`id` = ?",
+		db.Statement.SQL.String(),
+	)
+
+	db = openDryRunDB(t)
+	db = (&Settings[*FilterTestModel]{DisableFilter: true}).ScopeUnpaginated(db, request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models`", db.Statement.SQL.String())
+}
+
+func TestConditionNormalizedHash(t *testing.T) {
+	request := &Request{Condition: Cond("name", Operators["$eq"], "a")}
+	hash := request.NormalizedHash()
+	assert.NotEqual(t, (&Request{}).NormalizedHash(), hash)
+	assert.Equal(t, hash, (&Request{Condition: Cond("name", Operators["$eq"], "b")}).NormalizedHash())
+	assert.NotEqual(t, hash, (&Request{Condition: Not(Cond("name", Operators["$eq"], "a"))}).NormalizedHash())
+}
@@ -0,0 +1,121 @@
+package filter
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// CostWeights the weights used by `EstimateCost()` to compute the cost of a request.
+type CostWeights struct {
+	// Base the cost of any request.
+	Base int
+	// Filter the cost of each filter.
+	Filter int
+	// PatternFilter the additional cost of each filter using an operator that usually
+	// cannot use an index (see `PatternOperators`).
+	PatternFilter int
+	// Relation the additional cost of each filter or sort on a relation field,
+	// which requires a join.
+	Relation int
+	// Join the cost of each joined (preloaded) relation. Nested relations are counted once each.
+	Join int
+	// Search the cost of the search.
+	Search int
+	// Sort the cost of each sort.
+	Sort int
+	// PageSize the cost of each started block of 100 records in the requested page.
+	PageSize int
+	// All the cost of fetching all the records with `per_page=all`, replacing `PageSize`.
+	All int
+}
+
+var (
+	// DefaultCostWeights the weights used by `EstimateCost()`.
+	DefaultCostWeights = CostWeights{
+		Base:          1,
+		Filter:        1,
+		PatternFilter: 2,
+		Relation:      3,
+		Join:          5,
+		Search:        5,
+		Sort:          1,
+		PageSize:      1,
+		All:           20,
+	}
+
+	// PatternOperators the names of the operators considered expensive by `EstimateCost()`
+	// because they usually cannot use an index.
+	PatternOperators = []string{"$cont", "$excl", "$ends", "$search"}
+)
+
+// EstimateCost returns a rough estimate of the cost of the given request when applied with
+// the given settings, computed with `DefaultCostWeights`. It doesn't access the database nor
+// resolve the fields against the model, so it can be used by middleware before the request is
+// applied, for example to charge more rate-limit quota for requests with joins and searches
+// than for simple lookups. The parts of the request disabled by the settings are not counted.
+// If settings is nil, the default settings are used.
+func EstimateCost[T any](request *Request, settings *Settings[T]) int {
+	if settings == nil {
+		settings = &Settings[T]{}
+	}
+	w := DefaultCostWeights
+	cost := w.Base
+
+	if !settings.DisableFilter {
+		filters := slices.Concat(request.Filter.Val, request.Or.Val, lo.Flatten(request.FilterGroups.Val))
+		if request.Condition != nil {
+			filters = append(filters, conditionFilters(request.Condition.Node())...)
+		}
+		for _, f := range filters {
+			cost += w.Filter
+			if name, ok := operatorName(f.Operator); ok && lo.Contains(PatternOperators, name) {
+				cost += w.PatternFilter
+			}
+			if strings.Contains(f.Field, ".") {
+				cost += w.Relation
+			}
+		}
+	}
+
+	if !settings.DisableSort {
+		for _, s := range request.Sort.Val {
+			cost += w.Sort
+			if strings.Contains(s.Field, ".") {
+				cost += w.Relation
+			}
+		}
+	}
+
+	if !settings.DisableJoin && request.Join.Present {
+		cost += countJoins(request.Join.Val) * w.Join
+	}
+
+	if !settings.DisableSearch && request.Search.Present && request.Search.Val != "" {
+		cost += w.Search
+	}
+
+	pageSize := request.PerPage.Default(DefaultPageSize)
+	switch {
+	case pageSize == PerPageAll && settings.AllowAll:
+		cost += w.All
+	case pageSize == PerPageAll:
+		// Rejected by the scopes (`ErrAllNotAllowed`), no record is fetched
+	default:
+		cost += w.PageSize * ((pageSize + 99) / 100)
+	}
+	return cost
+}
+
+// conditionFilters returns the filters of the given condition tree.
+func conditionFilters(node *ConditionNode) []*Filter {
+	if node.Type == ConditionFilter {
+		return []*Filter{node.Filter}
+	}
+	var filters []*Filter
+	for _, child := range node.Children {
+		filters = append(filters, conditionFilters(child)...)
+	}
+	return filters
+}
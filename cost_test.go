@@ -0,0 +1,60 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+func TestEstimateCost(t *testing.T) {
+	cases := []struct {
+		request  *Request
+		settings *Settings[*FilterTestModel]
+		desc     string
+		want     int
+	}{
+		{desc: "empty", request: &Request{}, want: 2},
+		{
+			desc: "lookup",
+			request: &Request{
+				Filter: typeutil.NewUndefined([]*Filter{{Field: "id", Operator: Operators["$eq"], Args: []string{"1"}}}),
+			},
+			want: 3,
+		},
+		{
+			desc: "expensive",
+			request: &Request{
+				Filter:       typeutil.NewUndefined([]*Filter{{Field: "Relation.name", Operator: Operators["$cont"], Args: []string{"a"}}}),
+				Or:           typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}}}),
+				FilterGroups: typeutil.NewUndefined([][]*Filter{{{Field: "name", Operator: Operators["$ends"], Args: []string{"a"}}}}),
+				Condition:    Cond("name", Operators["$eq"], "b"),
+				Sort:         typeutil.NewUndefined([]*Sort{{Field: "Relation.name"}, {Field: "id"}}),
+				Join:         typeutil.NewUndefined([]*Join{{Relation: "Relation.NestedRelation"}}),
+				Search:       typeutil.NewUndefined("search"),
+				PerPage:      typeutil.NewUndefined(250),
+			},
+			// base + filters (1+2+3, 1, 1+2, 1) + sorts (1+3, 1) + joins (2*5) + search + 3 page blocks
+			want: 1 + 11 + 5 + 10 + 5 + 3,
+		},
+		{
+			desc: "disabled",
+			request: &Request{
+				Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$cont"], Args: []string{"a"}}}),
+				Sort:   typeutil.NewUndefined([]*Sort{{Field: "id"}}),
+				Join:   typeutil.NewUndefined([]*Join{{Relation: "Relation"}}),
+				Search: typeutil.NewUndefined("search"),
+			},
+			settings: &Settings[*FilterTestModel]{DisableFilter: true, DisableSort: true, DisableJoin: true, DisableSearch: true},
+			want:     2,
+		},
+		{desc: "all", request: &Request{PerPage: typeutil.NewUndefined(PerPageAll)}, settings: &Settings[*FilterTestModel]{AllowAll: true}, want: 21},
+		{desc: "all_not_allowed", request: &Request{PerPage: typeutil.NewUndefined(PerPageAll)}, want: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.want, EstimateCost(c.request, c.settings))
+		})
+	}
+}
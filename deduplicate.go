@@ -0,0 +1,247 @@
+package filter
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/schema"
+)
+
+// bindVarPrefixes the prefix of the numbered bind variables of the supported dialects,
+// identified by their name.
+var bindVarPrefixes = map[string]string{
+	"postgres":  "$",
+	"sqlserver": "@p",
+}
+
+// ArgsDeduplicator GORM plugin making the queries reuse the same bind variable
+// for identical values, reducing the number of parameters sent to the database.
+// This is useful with databases having a low parameter count limit (2100 for SQL Server),
+// especially when searching a value in many columns.
+//
+// The database deduces the type of a bind variable from the column it is compared with,
+// so identical values are only merged if they are compared with columns of the model or of
+// its relations having the same database type (e.g. `"users"."name" = $1`). The values used
+// in other contexts (functions, subqueries, raw SQL) keep their own bind variable.
+//
+// Only dialects using numbered bind variables are supported ("postgres" and "sqlserver").
+// The queries of other dialects are not modified.
+//
+//	db.Use(&filter.ArgsDeduplicator{})
+type ArgsDeduplicator struct{}
+
+// Name returns the name of the plugin.
+func (d *ArgsDeduplicator) Name() string {
+	return "goyave-filter:args-deduplicator"
+}
+
+// Initialize registers the callback deduplicating the query arguments.
+func (d *ArgsDeduplicator) Initialize(db *gorm.DB) error {
+	return db.Callback().Query().Before("gorm:query").Register("goyave-filter:deduplicate_args", deduplicateArgsCallback)
+}
+
+func deduplicateArgsCallback(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	prefix, ok := bindVarPrefixes[db.Dialector.Name()]
+	if !ok {
+		return
+	}
+
+	// Build the SQL now so it can be rewritten. The "gorm:query" callback
+	// won't build it again.
+	callbacks.BuildQuerySQL(db)
+	if db.Error != nil {
+		return
+	}
+
+	sql, vars := deduplicateArgs(db.Statement.SQL.String(), db.Statement.Vars, prefix, columnTypeResolver(db))
+	db.Statement.SQL.Reset()
+	db.Statement.SQL.WriteString(sql)
+	db.Statement.Vars = vars
+}
+
+// bindKey identifies the bind variables that can be merged: identical values compared
+// with columns of the same database type.
+type bindKey struct {
+	value      any
+	columnType string
+}
+
+// deduplicateArgs rewrites the given SQL so identical values compared with columns of the same
+// type share the same numbered bind variable. columnType returns the database type of the given
+// column (e.g. "users.name"), or an empty string if it is unknown. The bind variables that are not
+// directly compared with a column of known type (see `comparedColumn()`) are not merged.
+// The bind variables inside quotes are ignored.
+// If the bind variables don't match the given vars exactly (unexpected order or count),
+// the SQL and vars are returned unchanged.
+func deduplicateArgs(sql string, vars []any, prefix string, columnType func(column string) string) (string, []any) {
+	newVars := make([]any, 0, len(vars))
+	indexes := make(map[bindKey]int, len(vars))
+	builder := strings.Builder{}
+	builder.Grow(len(sql))
+
+	var quote byte
+	count := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			builder.WriteByte(c)
+			continue
+		}
+		if c == '\'' || c == '"' || c == '`' {
+			quote = c
+			builder.WriteByte(c)
+			continue
+		}
+
+		end := i + len(prefix)
+		if !strings.HasPrefix(sql[i:], prefix) || end >= len(sql) || sql[end] < '0' || sql[end] > '9' {
+			builder.WriteByte(c)
+			continue
+		}
+		for end < len(sql) && sql[end] >= '0' && sql[end] <= '9' {
+			end++
+		}
+		n, err := strconv.Atoi(sql[i+len(prefix) : end])
+		count++
+		if err != nil || n != count || n > len(vars) {
+			return sql, vars
+		}
+
+		value := vars[n-1]
+		index := len(newVars) + 1
+		key := bindKey{value: value}
+		if isDeduplicable(value) {
+			if column := comparedColumn(sql[:i], prefix); column != "" {
+				key.columnType = columnType(column)
+			}
+		}
+		if key.columnType != "" {
+			if existing, ok := indexes[key]; ok {
+				index = existing
+			} else {
+				indexes[key] = index
+				newVars = append(newVars, value)
+			}
+		} else {
+			newVars = append(newVars, value)
+		}
+
+		builder.WriteString(prefix)
+		builder.WriteString(strconv.Itoa(index))
+		i = end - 1
+	}
+
+	if count != len(vars) {
+		return sql, vars
+	}
+	return builder.String(), newVars
+}
+
+func isDeduplicable(value any) bool {
+	if _, ok := value.(time.Time); ok {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// comparisonKeywords the keywords that can separate a column from the bind variable
+// it is compared with.
+var comparisonKeywords = []string{"LIKE", "ILIKE", "NOT", "IN"}
+
+// comparedColumn returns the column (e.g. "users.name") directly compared with the bind
+// variable following the given SQL, such as in `"users"."name" = $1`, `"users"."name" ILIKE $1`
+// or `"users"."id" IN ($1,$2)`. Returns an empty string if the bind variable is used in another
+// context, for example as a function argument.
+func comparedColumn(sql, prefix string) string {
+	for {
+		trimmed := strings.TrimRight(sql, " \t\n=<>!(,")
+		if i := strings.LastIndexFunc(trimmed, func(r rune) bool { return r < '0' || r > '9' }); i < len(trimmed)-1 && strings.HasSuffix(trimmed[:i+1], prefix) {
+			// Preceding bind variable of an "IN" list
+			trimmed = trimmed[:i+1-len(prefix)]
+		} else {
+			for _, keyword := range comparisonKeywords {
+				if len(trimmed) > len(keyword) && strings.EqualFold(trimmed[len(trimmed)-len(keyword):], keyword) && trimmed[len(trimmed)-len(keyword)-1] == ' ' {
+					trimmed = trimmed[:len(trimmed)-len(keyword)]
+					break
+				}
+			}
+		}
+		if trimmed == sql {
+			break
+		}
+		sql = trimmed
+	}
+
+	var parts []string
+	for len(sql) > 0 {
+		quote := sql[len(sql)-1]
+		if quote != '"' && quote != '`' {
+			break
+		}
+		start := strings.LastIndexByte(sql[:len(sql)-1], quote)
+		if start == -1 {
+			return ""
+		}
+		parts = append([]string{sql[start+1 : len(sql)-1]}, parts...)
+		sql = sql[:start]
+		if !strings.HasSuffix(sql, ".") {
+			break
+		}
+		sql = sql[:len(sql)-1]
+	}
+	return strings.Join(parts, ".")
+}
+
+// columnTypeResolver returns a function returning the database type of the given column of the
+// statement's model or of one of its relations, identified by its alias (see `tableFromJoinName()`).
+// Falls back to the GORM data type of the field if the dialector doesn't provide it.
+// The function returns an empty string if the column cannot be found.
+func columnTypeResolver(db *gorm.DB) func(column string) string {
+	stmt := db.Statement
+	return func(column string) string {
+		if stmt.Schema == nil {
+			return ""
+		}
+		parts := strings.Split(column, ".")
+		sch := stmt.Schema
+		if len(parts) > 1 {
+			table := parts[len(parts)-2]
+			if table != stmt.Schema.Table && table != stmt.Table {
+				rel := findRelation(stmt.Schema, table, map[*schema.Schema]bool{})
+				if rel == nil {
+					return ""
+				}
+				sch = rel.FieldSchema
+			}
+		}
+		field, ok := sch.FieldsByDBName[parts[len(parts)-1]]
+		if !ok {
+			return ""
+		}
+		if dataType := db.Dialector.DataTypeOf(field); dataType != "" {
+			return dataType
+		}
+		return string(field.DataType)
+	}
+}
@@ -0,0 +1,174 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type numberedBindVarDialector struct {
+	gorm.Dialector
+	name   string
+	prefix string
+}
+
+func (d numberedBindVarDialector) Name() string {
+	return d.name
+}
+
+func (d numberedBindVarDialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, _ any) {
+	_, _ = writer.WriteString(fmt.Sprintf("%s%d", d.prefix, len(stmt.Vars)))
+}
+
+func TestDeduplicateArgs(t *testing.T) {
+	date := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	columnTypes := map[string]string{
+		"id":         "bigint",
+		"name":       "text",
+		"email":      "text",
+		"role":       "text",
+		"uuid":       "uuid",
+		"created_at": "timestamptz",
+		"updated_at": "timestamptz",
+	}
+	cases := []struct {
+		desc     string
+		sql      string
+		prefix   string
+		wantSQL  string
+		vars     []any
+		wantVars []any
+	}{
+		{
+			desc:     "postgres",
+			sql:      `SELECT * FROM "users" WHERE ("name" LIKE $1 OR "email" LIKE $2) AND "id" > $3 AND "role" = $4 LIMIT $5`,
+			prefix:   "$",
+			vars:     []any{"%val%", "%val%", 1, "admin", 1},
+			wantSQL:  `SELECT * FROM "users" WHERE ("name" LIKE $1 OR "email" LIKE $1) AND "id" > $2 AND "role" = $3 LIMIT $4`,
+			wantVars: []any{"%val%", 1, "admin", 1},
+		},
+		{
+			desc:     "sqlserver",
+			sql:      `SELECT * FROM "users" WHERE "name" = @p1 OR "email" = @p2 OR "created_at" = @p3 OR "updated_at" = @p4`,
+			prefix:   "@p",
+			vars:     []any{"val", "val", date, date},
+			wantSQL:  `SELECT * FROM "users" WHERE "name" = @p1 OR "email" = @p1 OR "created_at" = @p2 OR "updated_at" = @p2`,
+			wantVars: []any{"val", date},
+		},
+		{
+			desc:     "more_than_9",
+			sql:      `"id" IN ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`,
+			prefix:   "$",
+			vars:     []any{1, 2, 3, 4, 5, 6, 7, 8, 9, 1, 10},
+			wantSQL:  `"id" IN ($1,$2,$3,$4,$5,$6,$7,$8,$9,$1,$10)`,
+			wantVars: []any{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+		{
+			desc:     "quoted",
+			sql:      `SELECT 'cost: $1', "$2" FROM "users" WHERE "name" = $1 OR "email" = $2`,
+			prefix:   "$",
+			vars:     []any{"val", "val"},
+			wantSQL:  `SELECT 'cost: $1', "$2" FROM "users" WHERE "name" = $1 OR "email" = $1`,
+			wantVars: []any{"val"},
+		},
+		{
+			desc:     "not_deduplicable",
+			sql:      `"name" = $1 OR "name" = $2 OR "name" = $3 OR "name" = $4`,
+			prefix:   "$",
+			vars:     []any{nil, nil, []byte("a"), []byte("a")},
+			wantSQL:  `"name" = $1 OR "name" = $2 OR "name" = $3 OR "name" = $4`,
+			wantVars: []any{nil, nil, []byte("a"), []byte("a")},
+		},
+		{
+			desc:     "different_types",
+			sql:      `"id" = $1 OR "id" = $2`,
+			prefix:   "$",
+			vars:     []any{1, int64(1)},
+			wantSQL:  `"id" = $1 OR "id" = $2`,
+			wantVars: []any{1, int64(1)},
+		},
+		{
+			desc:     "different_column_types",
+			sql:      `SELECT * FROM "users" WHERE "users"."name" = $1 OR "users"."uuid" = $2 OR "users"."email" = $3`,
+			prefix:   "$",
+			vars:     []any{"val", "val", "val"},
+			wantSQL:  `SELECT * FROM "users" WHERE "users"."name" = $1 OR "users"."uuid" = $2 OR "users"."email" = $1`,
+			wantVars: []any{"val", "val"},
+		},
+		{
+			desc:     "not_compared_with_column",
+			sql:      `SELECT * FROM "users" WHERE LENGTH("name") > $1 OR "id" > $2 OR "id" BETWEEN $3 AND $4 OR $5 = ANY("tags") OR "unknown" = $6`,
+			prefix:   "$",
+			vars:     []any{1, 1, 1, 1, 1, 1},
+			wantSQL:  `SELECT * FROM "users" WHERE LENGTH("name") > $1 OR "id" > $2 OR "id" BETWEEN $3 AND $4 OR $5 = ANY("tags") OR "unknown" = $6`,
+			wantVars: []any{1, 1, 1, 1, 1, 1},
+		},
+		{
+			desc:     "unexpected_order",
+			sql:      "$2 $1 $2",
+			prefix:   "$",
+			vars:     []any{"a", "a"},
+			wantSQL:  "$2 $1 $2",
+			wantVars: []any{"a", "a"},
+		},
+		{
+			desc:     "unexpected_count",
+			sql:      "$1 $2",
+			prefix:   "$",
+			vars:     []any{"a", "a", "a"},
+			wantSQL:  "$1 $2",
+			wantVars: []any{"a", "a", "a"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			sql, vars := deduplicateArgs(c.sql, c.vars, c.prefix, func(column string) string {
+				return columnTypes[strings.TrimPrefix(column, "users.")]
+			})
+			assert.Equal(t, c.wantSQL, sql)
+			assert.Equal(t, c.wantVars, vars)
+		})
+	}
+}
+
+func TestArgsDeduplicator(t *testing.T) {
+	openDB := func(t *testing.T, name, prefix string) *gorm.DB {
+		dryRunDB := openDryRunDB(t)
+		db, err := gorm.Open(numberedBindVarDialector{Dialector: dryRunDB.Dialector, name: name, prefix: prefix}, &gorm.Config{DryRun: true})
+		require.NoError(t, err)
+		require.NoError(t, db.Use(&ArgsDeduplicator{}))
+		return db
+	}
+
+	request := &Request{
+		Search:  typeutil.NewUndefined("val"),
+		Filter:  typeutil.NewUndefined([]*Filter{{Field: "id", Args: []string{"1"}, Operator: Operators["$gt"]}}),
+		PerPage: typeutil.NewUndefined(1),
+	}
+	settings := &Settings[*FilterTestModel]{FieldsSearch: []string{"name", "Relation.name"}}
+
+	t.Run("postgres", func(t *testing.T) {
+		db := openDB(t, "postgres", "$")
+		results := []*FilterTestModel{}
+		db = settings.ScopeUnpaginated(db, request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` LEFT JOIN `filter_test_relations` `Relation` ON `filter_test_models`.`id` = `Relation`.`parent_id` WHERE `filter_test_models`.`id` > $1 AND (`filter_test_models`.`name` LIKE $2 OR `Relation`.`name` LIKE $2)", db.Statement.SQL.String())
+		assert.Equal(t, []any{uint64(1), "%val%"}, db.Statement.Vars)
+	})
+
+	t.Run("unsupported_dialect", func(t *testing.T) {
+		db := openDB(t, "mysql", "$")
+		results := []*FilterTestModel{}
+		db = settings.ScopeUnpaginated(db, request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, []any{uint64(1), "%val%", "%val%"}, db.Statement.Vars)
+	})
+}
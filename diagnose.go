@@ -0,0 +1,48 @@
+package filter
+
+import (
+	"slices"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+// FilterDiagnostic the number of records matching a single filter of a request that
+// didn't return any record. See `Settings.DiagnoseEmpty`.
+type FilterDiagnostic struct {
+	Filter *Filter `json:"filter"`
+	// Total the number of records matching this filter alone. If 0, this filter
+	// eliminates all the records by itself.
+	Total int64 `json:"total"`
+}
+
+// diagnoseEmpty counts the records matching each filter of the given request alone, in addition
+// to the conditions already present on the given DB (e.g. tenant scopes). The search and
+// the other options of the request are ignored.
+func (s *Settings[T]) diagnoseEmpty(db *gorm.DB, request *Request, dest any) ([]*FilterDiagnostic, error) {
+	if s.DisableFilter {
+		return nil, nil
+	}
+	filters := slices.Concat(request.Filter.Val, request.Or.Val, lo.Flatten(request.FilterGroups.Val))
+	diagnostics := make([]*FilterDiagnostic, 0, len(filters))
+	for _, f := range filters {
+		single := &Request{
+			Filter: typeutil.NewUndefined([]*Filter{{Field: f.Field, Operator: f.Operator, Args: f.Args}}),
+		}
+		tx, sch, _ := s.scopeCommon(db.Session(&gorm.Session{}), single, dest)
+		if sch == nil {
+			return nil, tx.Error
+		}
+		diagnostic := &FilterDiagnostic{Filter: f}
+		err := markCountQuery(tx).Scopes(func(tx *gorm.DB) *gorm.DB {
+			tx.Statement.Preloads = nil
+			return tx
+		}).Count(&diagnostic.Total).Error
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,99 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+func TestScopeDiagnoseEmpty(t *testing.T) {
+	db := openDryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Query().After("gorm:query").Register("test:count", func(tx *gorm.DB) {
+		if count, ok := tx.Statement.Dest.(*int64); ok {
+			sql := tx.Statement.SQL.String()
+			queries = append(queries, sql)
+			switch {
+			case strings.Contains(sql, "`name` LIKE ? AND `Relation`.`a` LIKE ?"):
+				*count = 0
+			case strings.Contains(sql, "`Relation`.`a` LIKE ?"):
+				*count = 4
+			case strings.Contains(sql, "`name` LIKE ?"):
+				*count = 12
+			}
+			tx.RowsAffected = 1
+		}
+	})
+	require.NoError(t, err)
+
+	nameFilter := &Filter{Field: "name", Args: []string{"val"}, Operator: Operators["$cont"]}
+	relationFilter := &Filter{Field: "Relation.a", Args: []string{"val"}, Operator: Operators["$cont"]}
+	emailFilter := &Filter{Field: "email", Args: []string{"val"}, Operator: Operators["$eq"], Or: true}
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{nameFilter, relationFilter}),
+		FilterGroups: typeutil.NewUndefined([][]*Filter{
+			{emailFilter},
+		}),
+		Search: typeutil.NewUndefined("search"),
+	}
+
+	results := []*TestScopeModel{}
+	settings := &Settings[*TestScopeModel]{DiagnoseEmpty: true, FieldsSearch: []string{"email"}}
+	paginator, err := settings.Scope(db.Where("relation_id = ?", 1), request, &results)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"SELECT count(*) FROM `test_scope_models` LEFT JOIN `test_scope_relations` `Relation` ON `test_scope_models`.`relation_id` = `Relation`.`id` WHERE relation_id = ? AND (`test_scope_models`.`name` LIKE ? AND `Relation`.`a` LIKE ?) AND `test_scope_models`.`email` = ? AND `test_scope_models`.`email` LIKE ?",
+		"SELECT count(*) FROM `test_scope_models` WHERE relation_id = ? AND `test_scope_models`.`name` LIKE ?",
+		"SELECT count(*) FROM `test_scope_models` LEFT JOIN `test_scope_relations` `Relation` ON `test_scope_models`.`relation_id` = `Relation`.`id` WHERE relation_id = ? AND `Relation`.`a` LIKE ?",
+		"SELECT count(*) FROM `test_scope_models` WHERE relation_id = ? AND `test_scope_models`.`email` = ?",
+	}, queries)
+	diagnostics, ok := paginator.DB.Get(DiagnosticsSetting)
+	require.True(t, ok)
+	assert.Equal(t, []*FilterDiagnostic{
+		{Filter: nameFilter, Total: 12},
+		{Filter: relationFilter, Total: 4},
+		{Filter: emailFilter, Total: 0},
+	}, diagnostics)
+
+	t.Run("disabled", func(t *testing.T) {
+		queries = queries[:0]
+		paginator, err := (&Settings[*TestScopeModel]{}).Scope(db, request, &results)
+		require.NoError(t, err)
+		assert.Len(t, queries, 1)
+		_, ok := paginator.DB.Get(DiagnosticsSetting)
+		assert.False(t, ok)
+	})
+
+	t.Run("not_empty", func(t *testing.T) {
+		queries = queries[:0]
+		request := &Request{Filter: typeutil.NewUndefined([]*Filter{nameFilter})}
+		paginator, err := settings.Scope(db, request, &results)
+		require.NoError(t, err)
+		assert.Len(t, queries, 1)
+		_, ok := paginator.DB.Get(DiagnosticsSetting)
+		assert.False(t, ok)
+	})
+
+	t.Run("no_filter", func(t *testing.T) {
+		queries = queries[:0]
+		paginator, err := settings.Scope(db, &Request{}, &results)
+		require.NoError(t, err)
+		assert.Len(t, queries, 1)
+		_, ok := paginator.DB.Get(DiagnosticsSetting)
+		assert.False(t, ok)
+	})
+
+	t.Run("disable_filter", func(t *testing.T) {
+		queries = queries[:0]
+		settings := &Settings[*TestScopeModel]{DiagnoseEmpty: true, DisableFilter: true}
+		paginator, err := settings.Scope(db, request, &results)
+		require.NoError(t, err)
+		assert.Len(t, queries, 1)
+		_, ok := paginator.DB.Get(DiagnosticsSetting)
+		assert.False(t, ok)
+	})
+}
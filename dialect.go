@@ -0,0 +1,178 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/sqlutil"
+)
+
+// Dialect SQL fragments generated by the operators that differ depending on
+// the database engine. Pagination is not part of the dialect: the `LIMIT` and `OFFSET`
+// clauses are rendered by the GORM dialector (e.g. `OFFSET ... FETCH` for SQL Server).
+type Dialect struct {
+	// False a condition that is always false. Used when a filter cannot be applied
+	// to a column.
+	False string
+
+	// IsTrue format of the condition checking a boolean column is true.
+	// The column is the only formatting argument.
+	IsTrue string
+
+	// IsFalse format of the condition checking a boolean column is false.
+	// The column is the only formatting argument.
+	IsFalse string
+
+	// TextType the type enums are cast to when using text operators such as "$cont".
+	TextType string
+
+	// LikeEscape appended to the `LIKE` conditions. Required for databases
+	// not using backslash as the default escape character for `LIKE` patterns.
+	LikeEscape string
+
+	// LikeWildcards the characters other than `%` and `_` that have a special meaning
+	// in `LIKE` patterns and must be escaped with a backslash (e.g. `[` for SQL Server).
+	LikeWildcards string
+
+	// ILike if true, the database supports the native `ILIKE` operator, used by
+	// case-insensitive filters. Otherwise, both sides are wrapped in `LOWER()`.
+	ILike bool
+
+	// Mod format of the remainder of the division of a column by a `?` placeholder,
+	// used by "$mod". The column is the only formatting argument. Defaults to `%s % ?`.
+	Mod string
+
+	// BitAnd format of the condition checking that at least one of the bits of a `?`
+	// placeholder mask is set in a column, used by "$bitand". The column is the only
+	// formatting argument. Defaults to `(%s & ?) <> 0`.
+	BitAnd string
+
+	// Length format of the expression returning the number of characters of a text column,
+	// used by the length operators ("$lengt", "$lenlt", "$leneq"). The column is the only
+	// formatting argument. Defaults to `LENGTH(%s)`.
+	Length string
+}
+
+var (
+	// DefaultDialect the dialect used if the GORM dialector's name is not in `Dialects`.
+	// Compatible with PostgreSQL and SQLite.
+	DefaultDialect = &Dialect{
+		False:    "FALSE",
+		IsTrue:   "%s IS TRUE",
+		IsFalse:  "%s IS FALSE",
+		TextType: "TEXT",
+	}
+
+	// Dialects the dialects identified by the name of the GORM dialector.
+	Dialects = map[string]*Dialect{
+		"postgres": {
+			False:    "FALSE",
+			IsTrue:   "%s IS TRUE",
+			IsFalse:  "%s IS FALSE",
+			TextType: "TEXT",
+			ILike:    true,
+		},
+		"mysql": {
+			False:    "FALSE",
+			IsTrue:   "%s IS TRUE",
+			IsFalse:  "%s IS FALSE",
+			TextType: "TEXT",
+			Length:   "CHAR_LENGTH(%s)",
+		},
+		"sqlserver": {
+			False:         "1 = 0",
+			IsTrue:        "%s = 1",
+			IsFalse:       "%s = 0",
+			TextType:      "NVARCHAR(MAX)",
+			LikeEscape:    ` ESCAPE '\'`,
+			LikeWildcards: "[",
+			Length:        "LEN(%s)",
+		},
+		"oracle": {
+			False:      "1 = 0",
+			IsTrue:     "%s = 1",
+			IsFalse:    "%s = 0",
+			TextType:   "VARCHAR2(4000)",
+			LikeEscape: ` ESCAPE '\'`,
+			Mod:        "MOD(%s, ?)",
+			BitAnd:     "BITAND(%s, ?) <> 0",
+		},
+	}
+)
+
+// getDialect returns the dialect matching the dialector of the given DB.
+func getDialect(tx *gorm.DB) *Dialect {
+	if tx.Dialector != nil {
+		if dialect, ok := Dialects[tx.Dialector.Name()]; ok {
+			return dialect
+		}
+	}
+	return DefaultDialect
+}
+
+// like returns the `LIKE` (or `NOT LIKE` if not is true) condition for the given column.
+func (d *Dialect) like(column string, dataType DataType, not bool) string {
+	op := " LIKE ?"
+	if not {
+		op = " NOT LIKE ?"
+	}
+	return d.castEnumAsText(column, dataType) + op + d.LikeEscape
+}
+
+// escapeLike escapes the given value so it matches literally in a `LIKE` pattern.
+func (d *Dialect) escapeLike(value string) string {
+	value = sqlutil.EscapeLike(value)
+	for _, c := range d.LikeWildcards {
+		value = strings.ReplaceAll(value, string(c), `\`+string(c))
+	}
+	return value
+}
+
+// caseInsensitive returns the case-insensitive condition comparing the given column
+// to a value using the given operator ("=" or "LIKE"). Uses `ILIKE` if supported,
+// in which case the value must be escaped as a `LIKE` pattern.
+func (d *Dialect) caseInsensitive(column string, dataType DataType, op string) string {
+	column = d.castEnumAsText(column, dataType)
+	if d.ILike {
+		return column + " ILIKE ?" + d.LikeEscape
+	}
+	if op == "LIKE" {
+		return fmt.Sprintf("LOWER(%s) LIKE LOWER(?)%s", column, d.LikeEscape)
+	}
+	return fmt.Sprintf("LOWER(%s) %s LOWER(?)", column, op)
+}
+
+func (d *Dialect) castEnumAsText(column string, dataType DataType) string {
+	if dataType == DataTypeEnum || dataType == DataTypeEnumArray {
+		return fmt.Sprintf("CAST(%s AS %s)", column, d.TextType)
+	}
+	return column
+}
+
+// mod returns the expression computing the remainder of the division of the
+// given column by a `?` placeholder.
+func (d *Dialect) mod(column string) string {
+	if d.Mod == "" {
+		return column + " % ?"
+	}
+	return fmt.Sprintf(d.Mod, column)
+}
+
+// bitAnd returns the condition checking that at least one of the bits
+// of a `?` placeholder mask is set in the given column.
+func (d *Dialect) bitAnd(column string) string {
+	if d.BitAnd == "" {
+		return "(" + column + " & ?) <> 0"
+	}
+	return fmt.Sprintf(d.BitAnd, column)
+}
+
+// length returns the expression computing the number of characters of the given column.
+func (d *Dialect) length(column string, dataType DataType) string {
+	column = d.castEnumAsText(column, dataType)
+	if d.Length == "" {
+		return "LENGTH(" + column + ")"
+	}
+	return fmt.Sprintf(d.Length, column)
+}
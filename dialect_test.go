@@ -0,0 +1,57 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestGetDialect(t *testing.T) {
+	db := openDryRunDB(t)
+	assert.Same(t, DefaultDialect, getDialect(db))
+
+	sqlServerDB, err := gorm.Open(numberedBindVarDialector{Dialector: db.Dialector, name: "sqlserver", prefix: "@p"}, &gorm.Config{DryRun: true})
+	require.NoError(t, err)
+	assert.Same(t, Dialects["sqlserver"], getDialect(sqlServerDB))
+}
+
+func TestDialectOperators(t *testing.T) {
+	cases := []struct {
+		filter   *Filter
+		desc     string
+		dialect  string
+		want     clause.Expr
+		dataType DataType
+	}{
+		{desc: "sqlserver_false", dialect: "sqlserver", filter: &Filter{Operator: Operators["$cont"], Args: []string{"a"}}, dataType: DataTypeInt64, want: clause.Expr{SQL: "1 = 0"}},
+		{desc: "sqlserver_istrue", dialect: "sqlserver", filter: &Filter{Operator: Operators["$istrue"]}, dataType: DataTypeBool, want: clause.Expr{SQL: "`name` = 1"}},
+		{desc: "sqlserver_isfalse", dialect: "sqlserver", filter: &Filter{Operator: Operators["$isfalse"]}, dataType: DataTypeBool, want: clause.Expr{SQL: "`name` = 0"}},
+		{desc: "sqlserver_cont", dialect: "sqlserver", filter: &Filter{Operator: Operators["$cont"], Args: []string{"a_"}}, dataType: DataTypeText, want: clause.Expr{SQL: "`name` LIKE ? ESCAPE '\\'", Vars: []any{"%a\\_%"}}},
+		{desc: "sqlserver_cont_bracket", dialect: "sqlserver", filter: &Filter{Operator: Operators["$cont"], Args: []string{"[a-z]%"}}, dataType: DataTypeText, want: clause.Expr{SQL: "`name` LIKE ? ESCAPE '\\'", Vars: []any{"%\\[a-z]\\%%"}}},
+		{desc: "sqlserver_starts_bracket", dialect: "sqlserver", filter: &Filter{Operator: Operators["$starts"], Args: []string{"[a]"}}, dataType: DataTypeText, want: clause.Expr{SQL: "`name` LIKE ? ESCAPE '\\'", Vars: []any{"\\[a]%"}}},
+		{desc: "sqlserver_excl_enum", dialect: "sqlserver", filter: &Filter{Operator: Operators["$excl"], Args: []string{"a"}}, dataType: DataTypeEnum, want: clause.Expr{SQL: "CAST(`name` AS NVARCHAR(MAX)) NOT LIKE ? ESCAPE '\\'", Vars: []any{"%a%"}}},
+		{desc: "oracle_starts_enum", dialect: "oracle", filter: &Filter{Operator: Operators["$starts"], Args: []string{"a"}}, dataType: DataTypeEnum, want: clause.Expr{SQL: "CAST(`name` AS VARCHAR2(4000)) LIKE ? ESCAPE '\\'", Vars: []any{"a%"}}},
+		{desc: "oracle_eq_enum", dialect: "oracle", filter: &Filter{Operator: Operators["$eq"], Args: []string{"a"}}, dataType: DataTypeEnum, want: clause.Expr{SQL: "CAST(`name` AS VARCHAR2(4000)) = ?", Vars: []any{"a"}}},
+		{desc: "oracle_mod", dialect: "oracle", filter: &Filter{Operator: Operators["$mod"], Args: []string{"10", "1"}}, dataType: DataTypeInt64, want: clause.Expr{SQL: "MOD(`name`, ?) = ?", Vars: []any{int64(10), int64(1)}}},
+		{desc: "oracle_bitand", dialect: "oracle", filter: &Filter{Operator: Operators["$bitand"], Args: []string{"4"}}, dataType: DataTypeInt64, want: clause.Expr{SQL: "BITAND(`name`, ?) <> 0", Vars: []any{int64(4)}}},
+		{desc: "sqlserver_lengt", dialect: "sqlserver", filter: &Filter{Operator: Operators["$lengt"], Args: []string{"3"}}, dataType: DataTypeText, want: clause.Expr{SQL: "LEN(`name`) > ?", Vars: []any{uint64(3)}}},
+		{desc: "mysql_leneq", dialect: "mysql", filter: &Filter{Operator: Operators["$leneq"], Args: []string{"3"}}, dataType: DataTypeText, want: clause.Expr{SQL: "CHAR_LENGTH(`name`) = ?", Vars: []any{uint64(3)}}},
+		{desc: "default_istrue", dialect: "sqlite", filter: &Filter{Operator: Operators["$istrue"]}, dataType: DataTypeBool, want: clause.Expr{SQL: "`name` IS TRUE"}},
+		{desc: "default_cont", dialect: "sqlite", filter: &Filter{Operator: Operators["$cont"], Args: []string{"a"}}, dataType: DataTypeText, want: clause.Expr{SQL: "`name` LIKE ?", Vars: []any{"%a%"}}},
+		{desc: "default_cont_bracket", dialect: "sqlite", filter: &Filter{Operator: Operators["$cont"], Args: []string{"[a]"}}, dataType: DataTypeText, want: clause.Expr{SQL: "`name` LIKE ?", Vars: []any{"%[a]%"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			dryRunDB := openDryRunDB(t)
+			db, err := gorm.Open(numberedBindVarDialector{Dialector: dryRunDB.Dialector, name: c.dialect, prefix: "@p"}, &gorm.Config{DryRun: true})
+			require.NoError(t, err)
+
+			db = c.filter.Operator.Function(db, c.filter, "`name`", c.dataType)
+			assert.Equal(t, clause.Where{Exprs: []clause.Expression{c.want}}, db.Statement.Clauses["WHERE"].Expression)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package filter
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// distinctOnFields returns the fields of the "distinct_on" query allowed by `DistinctOn`,
+// in the order of the query. Unknown, blacklisted, relation and duplicate fields are ignored.
+func (s *Settings[T]) distinctOnFields(request *Request, sch *schema.Schema) []*schema.Field {
+	if !request.DistinctOn.Present || len(s.DistinctOn) == 0 {
+		return nil
+	}
+	fields := make([]*schema.Field, 0, len(request.DistinctOn.Val))
+	for _, name := range request.DistinctOn.Val {
+		if !lo.Contains(s.DistinctOn, name) || lo.Contains(s.FieldsBlacklist, name) {
+			continue
+		}
+		field, ok := sch.FieldsByDBName[name]
+		if !ok || columnsContain(fields, field) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// distinctOnSorts splits the given sorts so the `ORDER BY` clause starts with the `DISTINCT ON`
+// columns, as required by PostgreSQL. The first returned slice contains a sort for each of
+// the given fields, using the order of the matching sort if there is one, or ascending order otherwise.
+// The second returned slice contains the other sorts.
+func (s *Settings[T]) distinctOnSorts(fields []*schema.Field, sorts []*Sort, sch *schema.Schema) ([]*Sort, []*Sort) {
+	leading := make([]*Sort, 0, len(fields))
+	rest := slices.Clone(sorts)
+	for _, field := range fields {
+		sort := &Sort{Field: field.DBName, Order: SortAscending}
+		i := slices.IndexFunc(rest, func(r *Sort) bool {
+			f, _, joinName := getField(r.Field, sch, &s.Blacklist)
+			return f != nil && joinName == "" && f.DBName == field.DBName
+		})
+		if i != -1 {
+			sort.Order = rest[i].Order
+			rest = slices.Delete(rest, i, i+1)
+		}
+		leading = append(leading, sort)
+	}
+	return leading, rest
+}
+
+// distinctOnScope returns the scope adding `DISTINCT ON (columns)` to the `SELECT` clause.
+// The columns are added right after the `SELECT` keyword so they are kept when the selected
+// columns are replaced.
+func distinctOnScope(table string, fields []*schema.Field) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		columns := lo.Map(fields, func(f *schema.Field, _ int) string {
+			return columnExpression(tx.Statement, table, f)
+		})
+		c := tx.Statement.Clauses["SELECT"]
+		c.Name = "SELECT"
+		c.AfterNameExpression = clause.Expr{SQL: "DISTINCT ON (" + strings.Join(columns, ",") + ")"}
+		tx.Statement.Clauses["SELECT"] = c
+		return tx
+	}
+}
+
+// distinctOnGroupScope returns the scope grouping the query by the `DISTINCT ON` columns,
+// used to count the number of distinct records instead of the number of rows.
+func distinctOnGroupScope(table string, fields []*schema.Field) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		columns := lo.Map(fields, func(f *schema.Field, _ int) clause.Column {
+			return clause.Column{Raw: true, Name: columnExpression(tx.Statement, table, f)}
+		})
+		tx.Statement.AddClause(clause.GroupBy{Columns: columns})
+		return tx
+	}
+}
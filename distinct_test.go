@@ -0,0 +1,101 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type DistinctTestOrder struct {
+	Status     string
+	CustomerID uint
+	CreatedAt  int64
+	ID         uint
+}
+
+func TestScopeDistinctOn(t *testing.T) {
+	selectDistinct := "SELECT DISTINCT ON (`distinct_test_orders`.`customer_id`) `distinct_test_orders`.`status`,`distinct_test_orders`.`customer_id`,`distinct_test_orders`.`created_at`,`distinct_test_orders`.`id` FROM `distinct_test_orders` "
+
+	cases := []struct {
+		request  *Request
+		settings *Settings[*DistinctTestOrder]
+		desc     string
+		want     string
+	}{
+		{
+			desc:     "default_order",
+			request:  &Request{DistinctOn: typeutil.NewUndefined([]string{"customer_id"}), Sort: typeutil.NewUndefined([]*Sort{{Field: "created_at", Order: SortDescending}})},
+			settings: &Settings[*DistinctTestOrder]{DistinctOn: []string{"customer_id"}},
+			want:     selectDistinct + "ORDER BY `distinct_test_orders`.`customer_id`,`distinct_test_orders`.`created_at` DESC",
+		},
+		{
+			desc: "sorted",
+			request: &Request{
+				DistinctOn: typeutil.NewUndefined([]string{"customer_id"}),
+				Sort:       typeutil.NewUndefined([]*Sort{{Field: "created_at", Order: SortDescending}, {Field: "customer_id", Order: SortDescending}}),
+			},
+			settings: &Settings[*DistinctTestOrder]{DistinctOn: []string{"customer_id"}},
+			want:     selectDistinct + "ORDER BY `distinct_test_orders`.`customer_id` DESC,`distinct_test_orders`.`created_at` DESC",
+		},
+		{
+			desc:     "sort_disabled",
+			request:  &Request{DistinctOn: typeutil.NewUndefined([]string{"customer_id"}), Sort: typeutil.NewUndefined([]*Sort{{Field: "created_at", Order: SortDescending}})},
+			settings: &Settings[*DistinctTestOrder]{DistinctOn: []string{"customer_id"}, DisableSort: true},
+			want:     selectDistinct + "ORDER BY `distinct_test_orders`.`customer_id`",
+		},
+		{
+			desc:     "case_insensitive",
+			request:  &Request{DistinctOn: typeutil.NewUndefined([]string{"status"}), Sort: typeutil.NewUndefined([]*Sort{{Field: "status", Order: SortAscending}})},
+			settings: &Settings[*DistinctTestOrder]{DistinctOn: []string{"status"}, CaseInsensitiveSort: true},
+			want:     "SELECT DISTINCT ON (`distinct_test_orders`.`status`) `distinct_test_orders`.`status`,`distinct_test_orders`.`customer_id`,`distinct_test_orders`.`created_at`,`distinct_test_orders`.`id` FROM `distinct_test_orders` ORDER BY `distinct_test_orders`.`status`",
+		},
+		{
+			desc:     "not_allowed",
+			request:  &Request{DistinctOn: typeutil.NewUndefined([]string{"customer_id", "status"})},
+			settings: &Settings[*DistinctTestOrder]{DistinctOn: []string{"status"}, Blacklist: Blacklist{FieldsBlacklist: []string{"status"}}},
+			want:     "SELECT `distinct_test_orders`.`customer_id`,`distinct_test_orders`.`created_at`,`distinct_test_orders`.`id` FROM `distinct_test_orders`",
+		},
+		{
+			desc:     "disabled",
+			request:  &Request{DistinctOn: typeutil.NewUndefined([]string{"customer_id"})},
+			settings: &Settings[*DistinctTestOrder]{},
+			want:     "SELECT `distinct_test_orders`.`status`,`distinct_test_orders`.`customer_id`,`distinct_test_orders`.`created_at`,`distinct_test_orders`.`id` FROM `distinct_test_orders`",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			results := []*DistinctTestOrder{}
+			db := c.settings.ScopeUnpaginated(openDryRunDB(t), c.request, &results)
+			require.NoError(t, db.Error)
+			assert.Equal(t, c.want, db.Statement.SQL.String())
+		})
+	}
+}
+
+func TestScopeDistinctOnPaginated(t *testing.T) {
+	db := openDryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Query().After("gorm:query").Register("test:queries", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	})
+	require.NoError(t, err)
+
+	request := &Request{DistinctOn: typeutil.NewUndefined([]string{"customer_id"})}
+	results := []*DistinctTestOrder{}
+	_, err = (&Settings[*DistinctTestOrder]{DistinctOn: []string{"customer_id"}}).Scope(db, request, &results)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"SELECT count(*) FROM `distinct_test_orders` GROUP BY `distinct_test_orders`.`customer_id`",
+		"SELECT DISTINCT ON (`distinct_test_orders`.`customer_id`) `distinct_test_orders`.`status`,`distinct_test_orders`.`customer_id`,`distinct_test_orders`.`created_at`,`distinct_test_orders`.`id` FROM `distinct_test_orders` ORDER BY `distinct_test_orders`.`customer_id` LIMIT ?",
+	}, queries)
+}
+
+func TestNewRequestDistinctOn(t *testing.T) {
+	request := NewRequest(map[string]any{"distinct_on": []string{"customer_id"}})
+	assert.Equal(t, typeutil.NewUndefined([]string{"customer_id"}), request.DistinctOn)
+	assert.NotEqual(t, (&Request{}).NormalizedHash(), request.NormalizedHash())
+}
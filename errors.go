@@ -0,0 +1,65 @@
+package filter
+
+import "errors"
+
+var (
+	// ErrNoPrimaryKey returned by the scopes if the model doesn't have a primary key
+	// but one is required (e.g. when selecting fields and joining relations).
+	ErrNoPrimaryKey = errors.New("could not find primary key. Add `gorm:\"primaryKey\"` to your model")
+
+	// ErrKeyFieldsExcluded returned by the scopes if the "fields" query excludes the primary
+	// key or foreign keys of the model while joining relations and `KeyFields` is `KeyFieldsReject`.
+	ErrKeyFieldsExcluded = errors.New("the selected fields must include the primary and foreign keys when joining relations")
+
+	// ErrArgTooLong returned by the scopes if a filter argument or the search query
+	// is longer than allowed by `MaxArgLength` or `FieldMaxArgLength`.
+	ErrArgTooLong = errors.New("argument too long")
+
+	// ErrAllNotAllowed returned by the scopes if the request uses `per_page=all`
+	// but `Settings.AllowAll` is disabled.
+	ErrAllNotAllowed = errors.New("fetching all records is not allowed")
+
+	// ErrTooManyJoins returned by the scopes if the request would join more
+	// relations than allowed by `MaxJoins`.
+	ErrTooManyJoins = errors.New("too many joins")
+
+	// ErrTooManyPreloadRows returned by the scopes if a "has many" or "many to many"
+	// preload would load more records than allowed by `MaxPreloadRows`.
+	ErrTooManyPreloadRows = errors.New("too many preloaded records")
+
+	// ErrUnsupportedPreload returned by `ScopeInto()` if the request joins "has many" or
+	// "many to many" relations, which are preloaded, while the destination type is not the model.
+	ErrUnsupportedPreload = errors.New("relations cannot be preloaded into a type other than the model")
+
+	// ErrUnsupportedBatchSetting returned by `Batch.Scope()` in the result of the queries
+	// whose settings use features that are not supported in batches.
+	ErrUnsupportedBatchSetting = errors.New("setting not supported in batches")
+
+	// ErrInvalidSort returned by the scopes if a requested sort cannot be applied because its
+	// field doesn't exist or is blacklisted, and `InvalidSort` is `InvalidSortReject`.
+	ErrInvalidSort = errors.New("invalid sort")
+
+	// ErrSnapshotExpired returned by `Scope()` if the snapshot token of the request doesn't
+	// identify a snapshot kept by `Settings.Snapshots`, for example because it expired.
+	ErrSnapshotExpired = errors.New("snapshot expired")
+
+	// ErrTooManySnapshots returned by `Scope()` if the request asks for a new snapshot
+	// but `Snapshots.MaxSnapshots` snapshots are already kept.
+	ErrTooManySnapshots = errors.New("too many snapshots")
+
+	// ErrAnonymousRelation returned by the scopes when joining a relation whose
+	// table name cannot be determined.
+	ErrAnonymousRelation = errors.New("relation is anonymous, could not get table name")
+
+	// ErrUnsupportedModel returned by the scopes when the model cannot be parsed
+	// by GORM. The error is wrapped with the original GORM error.
+	ErrUnsupportedModel = errors.New("unsupported model")
+
+	// ErrInvalidVirtualRelation returned by the scopes and `Settings.Validate()` if a virtual
+	// relation cannot be resolved (e.g. its model cannot be parsed or a column doesn't exist).
+	ErrInvalidVirtualRelation = errors.New("invalid virtual relation")
+
+	// ErrInvalidComputedColumn returned by `WarmUp()` if a field has a `computed`
+	// tag but is not a read-only column.
+	ErrInvalidComputedColumn = errors.New("invalid computed column")
+)
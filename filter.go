@@ -1,8 +1,10 @@
 package filter
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
-	"strings"
+	"slices"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -14,8 +16,12 @@ import (
 type Filter struct {
 	Field    string
 	Operator *Operator
-	Args     []string
-	Or       bool
+	// table the table or relation alias of the filtered field, set when the filter is applied.
+	table string
+	// queryOperator the alias of the operator used in the query, if any, set when the filter is parsed.
+	queryOperator string
+	Args          []string
+	Or            bool
 }
 
 // Scope returns the GORM scope to use in order to apply this filter.
@@ -38,31 +44,176 @@ func (f *Filter) Scope(blacklist Blacklist, sch *schema.Schema) (func(*gorm.DB)
 			}
 			tx = join(tx, joinName, sch)
 		}
+		if operatorScope := f.operatorScope(field, s, joinName, dataType); operatorScope != nil {
+			tx = operatorScope(tx)
+		}
 
 		return tx
 	}
 
-	computed := field.StructField.Tag.Get("computed")
-
 	conditionScope := func(tx *gorm.DB) *gorm.DB {
 		if dataType == DataTypeUnsupported {
 			return tx
 		}
 
-		table := tx.Statement.Quote(tableFromJoinName(s.Table, joinName))
-		var fieldExpr string
-		if computed != "" {
-			fieldExpr = fmt.Sprintf("(%s)", strings.ReplaceAll(computed, clause.CurrentTable, table))
-		} else {
-			fieldExpr = table + "." + tx.Statement.Quote(field.DBName)
-		}
-
-		return f.Operator.Function(tx, f, fieldExpr, dataType)
+		table := tableFromJoinName(s.Table, joinName)
+		fieldExpr := columnExpression(tx.Statement, table, field)
+		return f.Operator.Function(tx, f.withTable(table), fieldExpr, dataType)
 	}
 
 	return joinScope, conditionScope
 }
 
+// operatorScope returns the scope applying the operator's `Scope` function to the main query,
+// or nil if the operator doesn't define one.
+func (f *Filter) operatorScope(field *schema.Field, sch *schema.Schema, joinName string, dataType DataType) func(*gorm.DB) *gorm.DB {
+	if f.Operator.Scope == nil {
+		return nil
+	}
+	return func(tx *gorm.DB) *gorm.DB {
+		table := tableFromJoinName(sch.Table, joinName)
+		fieldExpr := columnExpression(tx.Statement, table, field)
+		return f.Operator.Scope(tx, f.withTable(table), fieldExpr, dataType)
+	}
+}
+
+// mainQuery returns the name of the relation the filter's field belongs to (or an empty string
+// if the field belongs to the model) and the operator's main query scope (see `operatorScope`).
+// Both are empty if the field cannot be filtered.
+func (f *Filter) mainQuery(blacklist Blacklist, sch *schema.Schema) (string, func(*gorm.DB) *gorm.DB) {
+	field, s, joinName := getField(f.Field, sch, &blacklist)
+	if field == nil {
+		return "", nil
+	}
+	dataType := getDataType(field)
+	if dataType == DataTypeUnsupported {
+		return "", nil
+	}
+	return joinName, f.operatorScope(field, s, joinName, dataType)
+}
+
+// subqueryScope returns the scope applying this filter on a relation field using a subquery
+// instead of a join: `pk IN (SELECT pk FROM table LEFT JOIN relation ... WHERE condition)`.
+// Returns nil if the field doesn't belong to a relation, cannot be filtered, if the operator
+// has a main query scope or if the model doesn't have exactly one primary key.
+func (f *Filter) subqueryScope(blacklist Blacklist, sch *schema.Schema) func(*gorm.DB) *gorm.DB {
+	field, s, joinName := getField(f.Field, sch, &blacklist)
+	if field == nil || joinName == "" || f.Operator.Scope != nil || len(sch.PrimaryFields) != 1 {
+		return nil
+	}
+	dataType := getDataType(field)
+	if dataType == DataTypeUnsupported {
+		return nil
+	}
+
+	return func(tx *gorm.DB) *gorm.DB {
+		pk := clause.Column{Table: sch.Table, Name: sch.PrimaryFields[0].DBName}
+		subquery := tx.Session(&gorm.Session{NewDB: true}).Table(sch.Table).Select("?", pk)
+		subquery = addJoins(subquery, relationJoins(joinName, sch))
+		table := tableFromJoinName(s.Table, joinName)
+		fieldExpr := columnExpression(subquery.Statement, table, field)
+		subquery = f.Operator.Function(subquery, &Filter{Field: f.Field, Operator: f.Operator, Args: f.Args, table: table}, fieldExpr, dataType)
+		return f.Where(tx, "? IN (?)", pk, subquery)
+	}
+}
+
+// SelectivityHinter provides the estimated selectivity of filters, used to apply
+// the most selective filters first in `AND` conditions. The selectivity is the
+// estimated fraction of rows matching the filter, between 0 and 1.
+type SelectivityHinter interface {
+	Selectivity(filter *Filter) float64
+}
+
+// SelectivityHints a `SelectivityHinter` using a fixed selectivity per field.
+// Fields not present in the map are considered the least selective (1).
+type SelectivityHints map[string]float64
+
+// Selectivity returns the selectivity of the filter's field.
+func (h SelectivityHints) Selectivity(filter *Filter) float64 {
+	if selectivity, ok := h[filter.Field]; ok {
+		return selectivity
+	}
+	return 1
+}
+
+// sortBySelectivity returns a copy of the given filters sorted by ascending selectivity.
+// The filters having the same selectivity keep their order.
+func sortBySelectivity(filters []*Filter, hinter SelectivityHinter) []*Filter {
+	selectivities := make(map[*Filter]float64, len(filters))
+	for _, f := range filters {
+		selectivities[f] = hinter.Selectivity(f)
+	}
+	sorted := slices.Clone(filters)
+	slices.SortStableFunc(sorted, func(a, b *Filter) int {
+		return cmp.Compare(selectivities[a], selectivities[b])
+	})
+	return sorted
+}
+
+type filterJSON struct {
+	Field    string   `json:"field"`
+	Operator string   `json:"operator"`
+	Args     []string `json:"args"`
+	Or       bool     `json:"or"`
+}
+
+// MarshalJSON encodes the filter as a JSON object. The operator is encoded
+// using its name in the `Operators` map. Returns an error if the operator is not registered.
+func (f *Filter) MarshalJSON() ([]byte, error) {
+	name, ok := operatorName(f.Operator)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal filter on field %q: operator is not registered", f.Field)
+	}
+	return json.Marshal(filterJSON{
+		Field:    f.Field,
+		Operator: name,
+		Args:     f.Args,
+		Or:       f.Or,
+	})
+}
+
+// UnmarshalJSON decodes a filter encoded with `Filter.MarshalJSON()`. The operator is
+// looked up by name in the `Operators` map, or in the `OperatorAliases` map. Like
+// `ParseFilter()`, returns an error if the operator doesn't exist or if the filter doesn't
+// satisfy the operator's "RequiredArguments".
+func (f *Filter) UnmarshalJSON(data []byte) error {
+	var raw filterJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	operator, ok := lookupOperator(raw.Operator)
+	if !ok {
+		return fmt.Errorf("unknown operator: %q", raw.Operator)
+	}
+	if len(raw.Args) < int(operator.RequiredArguments) {
+		return fmt.Errorf("operator %q requires at least %d argument(s)", raw.Operator, operator.RequiredArguments)
+	}
+	*f = Filter{
+		Field:    raw.Field,
+		Operator: operator,
+		Args:     raw.Args,
+		Or:       raw.Or,
+	}
+	if _, ok := Operators[raw.Operator]; !ok {
+		f.queryOperator = raw.Operator
+	}
+	return nil
+}
+
+// Table returns the unquoted name of the table the filtered field belongs to, or the alias of
+// the relation if the field belongs to a joined relation (e.g. "Author" for "Author.name").
+// Operators can use it with `QuoteColumn()` to reference other columns of the same table
+// without ambiguity when joins are present. Returns an empty string if the filter is not being applied.
+func (f *Filter) Table() string {
+	return f.table
+}
+
+func (f *Filter) withTable(table string) *Filter {
+	filter := *f
+	filter.table = table
+	return &filter
+}
+
 // Where applies a condition to given transaction, automatically taking the "Or"
 // filter value into account.
 func (f *Filter) Where(tx *gorm.DB, query string, args ...any) *gorm.DB {
@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,6 +9,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
+	"goyave.dev/goyave/v5/util/typeutil"
 )
 
 func TestFilterWhere(t *testing.T) {
@@ -580,6 +582,29 @@ func TestFilterScopeComputed(t *testing.T) {
 	assert.Equal(t, expected, db.Statement.Clauses)
 }
 
+type FilterTestModelComputedType struct {
+	Length string `gorm:"->;-:migration" computed:"LENGTH(~~~ct~~~.name)" computedType:"int64"`
+	Name   string
+	ID     uint
+}
+
+func TestFilterScopeComputedType(t *testing.T) {
+	db := openDryRunDB(t)
+	results := []*FilterTestModelComputedType{}
+	schema, err := parseModel(db, &results)
+	require.NoError(t, err)
+
+	filter := &Filter{Field: "length", Args: []string{"5"}, Operator: Operators["$gt"]}
+	tx := db.Model(&results).Scopes(filter.Scope(Blacklist{}, schema)).Find(&results)
+	assert.Equal(t, "SELECT * FROM `filter_test_model_computed_types` WHERE (LENGTH(`filter_test_model_computed_types`.name)) > ?", tx.Statement.SQL.String())
+	assert.Equal(t, []any{int64(5)}, tx.Statement.Vars)
+
+	// The argument doesn't match the type of the expression
+	filter = &Filter{Field: "length", Args: []string{"abc"}, Operator: Operators["$gt"]}
+	tx = db.Model(&results).Scopes(filter.Scope(Blacklist{}, schema)).Find(&results)
+	assert.Equal(t, "SELECT * FROM `filter_test_model_computed_types` WHERE FALSE", tx.Statement.SQL.String())
+}
+
 func TestFilterScopeComputedRelation(t *testing.T) {
 	db := openDryRunDB(t)
 	filter := &Filter{Field: "Relation.computed", Args: []string{"val1"}, Operator: Operators["$eq"]}
@@ -684,3 +709,171 @@ func TestFilterScopeWithJoinedUnsupportedDataType(t *testing.T) {
 	assert.Equal(t, expected, db.Statement.Clauses)
 	assert.Nil(t, db.Error)
 }
+
+type FilterTestModelReservedKeyword struct {
+	Order string
+	Group string
+	User  string
+	ID    uint
+}
+
+func TestFilterScopeReservedKeyword(t *testing.T) {
+	db := openDryRunDB(t)
+	results := []*FilterTestModelReservedKeyword{}
+	schema, err := parseModel(db, &results)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	filters := []*Filter{
+		{Field: "order", Args: []string{"val1"}, Operator: Operators["$eq"]},
+		{Field: "group", Args: []string{"val2"}, Operator: Operators["$cont"]},
+		{Field: "user", Operator: Operators["$isnull"]},
+	}
+	for _, f := range filters {
+		_, conditionScope := f.Scope(Blacklist{}, schema)
+		db = db.Scopes(conditionScope)
+	}
+	db = db.Model(&results).Find(&results)
+	expected := clause.Where{
+		Exprs: []clause.Expression{
+			clause.Expr{SQL: "`filter_test_model_reserved_keywords`.`order` = ?", Vars: []any{"val1"}},
+			clause.Expr{SQL: "`filter_test_model_reserved_keywords`.`group` LIKE ?", Vars: []any{"%val2%"}},
+			clause.Expr{SQL: "`filter_test_model_reserved_keywords`.`user` IS NULL", Vars: nil},
+		},
+	}
+	assert.Equal(t, expected, db.Statement.Clauses["WHERE"].Expression)
+}
+
+func TestFilterJSON(t *testing.T) {
+	filter := &Filter{Field: "name", Args: []string{"val1", "val2"}, Operator: Operators["$in"], Or: true}
+	data, err := json.Marshal(filter)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"field":"name","operator":"$in","args":["val1","val2"],"or":true}`, string(data))
+
+	result := &Filter{}
+	require.NoError(t, json.Unmarshal(data, result))
+	assert.Equal(t, filter, result)
+
+	t.Run("unregistered_operator", func(t *testing.T) {
+		filter := &Filter{Field: "name", Operator: &Operator{}}
+		_, err := json.Marshal(filter)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot marshal filter on field \"name\": operator is not registered")
+	})
+
+	t.Run("unknown_operator", func(t *testing.T) {
+		err := json.Unmarshal([]byte(`{"field":"name","operator":"$notanoperator","args":["val1"]}`), &Filter{})
+		require.Error(t, err)
+		assert.Equal(t, "unknown operator: \"$notanoperator\"", err.Error())
+	})
+
+	t.Run("missing_arguments", func(t *testing.T) {
+		err := json.Unmarshal([]byte(`{"field":"name","operator":"$eq"}`), &Filter{})
+		require.Error(t, err)
+		assert.Equal(t, "operator \"$eq\" requires at least 1 argument(s)", err.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		require.Error(t, json.Unmarshal([]byte(`"name||$eq||val1"`), &Filter{}))
+	})
+}
+
+func TestSortBySelectivity(t *testing.T) {
+	name := &Filter{Field: "name"}
+	id := &Filter{Field: "id"}
+	email := &Filter{Field: "email"}
+	role := &Filter{Field: "role"}
+	filters := []*Filter{name, id, email, role}
+
+	hints := SelectivityHints{"id": 0.01, "email": 0.1}
+	assert.InDelta(t, 0.01, hints.Selectivity(id), 0)
+	assert.InDelta(t, 1, hints.Selectivity(name), 0)
+
+	sorted := sortBySelectivity(filters, hints)
+	assert.Equal(t, []*Filter{id, email, name, role}, sorted)
+
+	// The original slice is not modified
+	assert.Equal(t, []*Filter{name, id, email, role}, filters)
+}
+
+func TestFilterTable(t *testing.T) {
+	var tables []string
+	operator := &Operator{
+		Function: func(tx *gorm.DB, filter *Filter, column string, _ DataType) *gorm.DB {
+			tables = append(tables, filter.Table())
+			return filter.Where(tx, column+" <> "+QuoteColumn(tx, filter.Table(), "id"))
+		},
+		RequiredArguments: 0,
+	}
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: operator}, {Field: "Relation.name", Operator: operator}}),
+	}
+
+	results := []*FilterTestModel{}
+	db := (&Settings[*FilterTestModel]{}).ScopeUnpaginated(openDryRunDB(t), request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` LEFT JOIN `filter_test_relations` `Relation` ON `filter_test_models`.`id` = `Relation`.`parent_id` WHERE (`filter_test_models`.`name` <> `filter_test_models`.`id` AND `Relation`.`name` <> `Relation`.`id`)",
+		db.Statement.SQL.String(),
+	)
+	assert.Equal(t, []string{"filter_test_models", "Relation"}, tables)
+	assert.Empty(t, request.Filter.Val[0].Table())
+}
+
+func TestScopeRelationSubqueries(t *testing.T) {
+	selectModel := "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` "
+	subquery := "`filter_test_models`.`id` IN (SELECT `filter_test_models`.`id` FROM `filter_test_models` LEFT JOIN `filter_test_relations` `Relation` ON `filter_test_models`.`id` = `Relation`.`parent_id` WHERE `Relation`.`name` = \"val1\")"
+	filters := []*Filter{
+		{Field: "Relation.name", Args: []string{"val1"}, Operator: Operators["$eq"]},
+		{Field: "name", Args: []string{"val2"}, Operator: Operators["$eq"]},
+	}
+
+	cases := []struct {
+		settings *Settings[*FilterTestModel]
+		desc     string
+		want     string
+	}{
+		{
+			desc:     "disabled",
+			settings: &Settings[*FilterTestModel]{},
+			want:     selectModel + "LEFT JOIN `filter_test_relations` `Relation` ON `filter_test_models`.`id` = `Relation`.`parent_id` WHERE (`Relation`.`name` = \"val1\" AND `filter_test_models`.`name` = \"val2\")",
+		},
+		{
+			desc:     "enabled",
+			settings: &Settings[*FilterTestModel]{RelationSubqueries: true},
+			want:     selectModel + "WHERE (" + subquery + " AND `filter_test_models`.`name` = \"val2\")",
+		},
+		{
+			desc:     "per_relation",
+			settings: &Settings[*FilterTestModel]{SubqueryRelations: map[string]bool{"Relation": true}},
+			want:     selectModel + "WHERE (" + subquery + " AND `filter_test_models`.`name` = \"val2\")",
+		},
+		{
+			desc:     "per_relation_disabled",
+			settings: &Settings[*FilterTestModel]{RelationSubqueries: true, SubqueryRelations: map[string]bool{"Relation": false}},
+			want:     selectModel + "LEFT JOIN `filter_test_relations` `Relation` ON `filter_test_models`.`id` = `Relation`.`parent_id` WHERE (`Relation`.`name` = \"val1\" AND `filter_test_models`.`name` = \"val2\")",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			request := &Request{Filter: typeutil.NewUndefined(filters)}
+			results := []*FilterTestModel{}
+			db := c.settings.ScopeUnpaginated(openDryRunDB(t), request, &results)
+			require.NoError(t, db.Error)
+			assert.Equal(t, c.want, db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...))
+		})
+	}
+
+	t.Run("or", func(t *testing.T) {
+		request := &Request{Or: typeutil.NewUndefined([]*Filter{
+			{Field: "Relation.name", Args: []string{"val1"}, Operator: Operators["$eq"], Or: true},
+			{Field: "name", Args: []string{"val2"}, Operator: Operators["$eq"], Or: true},
+		})}
+		results := []*FilterTestModel{}
+		db := (&Settings[*FilterTestModel]{RelationSubqueries: true}).ScopeUnpaginated(openDryRunDB(t), request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, selectModel+"WHERE ("+subquery+" OR `filter_test_models`.`name` = \"val2\")", db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...))
+	})
+}
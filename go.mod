@@ -1,10 +1,11 @@
 module goyave.dev/filter
 
-go 1.22
+go 1.23
 
 require (
 	github.com/samber/lo v1.47.0
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/text v0.16.0
 	gorm.io/driver/sqlite v1.5.6
 	gorm.io/gorm v1.25.12
 	goyave.dev/goyave/v5 v5.0.0
@@ -18,7 +19,6 @@ require (
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/mattn/go-sqlite3 v1.14.22 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/text v0.16.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	goyave.dev/copier v0.4.3 // indirect
 )
@@ -0,0 +1,298 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// Issue a problem found when resolving a request against a model. The parts of the
+// request having an issue are silently ignored (or result in an error) by the scopes.
+type Issue struct {
+	// Param the name of the query parameter containing the invalid value
+	// (e.g. "filter", "or", "filter[0]", "sort", "join", "fields", "search"),
+	// as defined by `Settings.ParamNames`.
+	Param string
+	// Value the field or relation having an issue. Empty if the whole parameter is concerned.
+	Value string
+	// Message describes the issue.
+	Message string
+}
+
+// Error returns a string representation of the issue.
+func (i *Issue) Error() string {
+	if i.Value == "" {
+		return fmt.Sprintf("%s: %s", i.Param, i.Message)
+	}
+	return fmt.Sprintf("%s: %q: %s", i.Param, i.Value, i.Message)
+}
+
+// NewValidatedRequest creates a filter request from an HTTP request's query using the
+// `ParamNames` of the settings (see `NewRequest()`) and resolves its filters, sorts, joins
+// and fields against the model using the given settings.
+// The returned issues let handlers return precise errors or warnings before the request is
+// actually used in a scope. If settings is nil, the default settings are used.
+// Returns an error if the request cannot be checked (see `Settings.Check()`).
+func NewValidatedRequest[T any](db *gorm.DB, query map[string]any, settings *Settings[T]) (*Request, []*Issue, error) {
+	if settings == nil {
+		settings = &Settings[T]{}
+	}
+	request := settings.ParamNames.NewRequest(query)
+	issues, err := settings.Check(db, request)
+	return request, issues, err
+}
+
+// Check resolves the filters, sorts, joins and fields of the given request against the model
+// and returns the list of issues found. Returns nil if the request can be applied entirely.
+// The filter arguments that cannot be converted to the type of the field are reported
+// without their value for the `SensitiveFields`. The `Param` of the issues uses the
+// `ParamNames` of the settings.
+//
+// Returns an error wrapping `ErrUnsupportedModel` if the model cannot be parsed, or
+// `ErrInvalidVirtualRelation` if a virtual relation cannot be resolved.
+func (s *Settings[T]) Check(db *gorm.DB, request *Request) ([]*Issue, error) {
+	modelSchema, err := parseModel(db, new(T))
+	if err != nil {
+		return nil, errors.Errorf("%w: %w", ErrUnsupportedModel, err)
+	}
+	sch, err := withVirtualRelations(db, modelSchema, s.VirtualRelations)
+	if err != nil {
+		return nil, errors.New(err)
+	}
+
+	names := s.ParamNames.withDefaults()
+	var issues []*Issue
+	checkFilters := func(param string, filters []*Filter) {
+		if s.DisableFilter {
+			issues = append(issues, &Issue{Param: param, Message: "filters are disabled"})
+			return
+		}
+		for _, f := range filters {
+			if _, ok := s.ComputedFilters[f.Field]; !ok {
+				message := checkField(f.Field, sch, &s.Blacklist, true)
+				if message == "" {
+					message = s.checkArgs(db, f, sch)
+				}
+				if message != "" {
+					issues = append(issues, &Issue{Param: param, Value: f.Field, Message: message})
+				}
+			}
+			if length, limit, ok := s.filterArgTooLong(f); ok {
+				issues = append(issues, &Issue{
+					Param:   param,
+					Value:   f.Field,
+					Message: fmt.Sprintf("an argument is %d characters long, the maximum is %d", length, limit),
+				})
+			}
+			if message, ok := deprecationMessage(f); ok {
+				issues = append(issues, &Issue{Param: param, Value: f.Field, Message: message})
+			}
+		}
+	}
+	if request.Filter.Present {
+		checkFilters(names.Filter, request.Filter.Val)
+	}
+	if request.Or.Present {
+		checkFilters(names.Or, request.Or.Val)
+	}
+	if request.FilterGroups.Present {
+		for i, group := range request.FilterGroups.Val {
+			checkFilters(fmt.Sprintf("%s[%d]", names.Filter, i), group)
+		}
+	}
+
+	if request.Sort.Present {
+		if s.DisableSort {
+			issues = append(issues, &Issue{Param: names.Sort, Message: "sorts are disabled"})
+		} else {
+			for _, sort := range request.Sort.Val {
+				if message := checkField(sort.Field, sch, &s.Blacklist, false); message != "" {
+					issues = append(issues, &Issue{Param: names.Sort, Value: sort.Field, Message: message})
+				}
+			}
+		}
+	}
+
+	if request.Join.Present {
+		if s.DisableJoin {
+			issues = append(issues, &Issue{Param: names.Join, Message: "joins are disabled"})
+		} else {
+			if count := countJoins(request.Join.Val); s.MaxJoins > 0 && count > s.MaxJoins {
+				issues = append(issues, &Issue{
+					Param:   names.Join,
+					Message: fmt.Sprintf("%s: the request would join %d relations, the maximum is %d", ErrTooManyJoins, count, s.MaxJoins),
+				})
+			}
+			for _, j := range request.Join.Val {
+				issues = append(issues, checkJoin(names.Join, j, modelSchema, &s.Blacklist)...)
+			}
+		}
+	}
+
+	if request.Fields.Present {
+		if s.DisableFields {
+			issues = append(issues, &Issue{Param: names.Fields, Message: "fields selection is disabled"})
+		} else {
+			for _, f := range request.Fields.Val {
+				if message := checkColumn(f, sch, s.FieldsBlacklist); message != "" {
+					issues = append(issues, &Issue{Param: names.Fields, Value: f, Message: message})
+				}
+			}
+		}
+	}
+
+	if request.DistinctOn.Present {
+		for _, f := range request.DistinctOn.Val {
+			message := checkColumn(f, sch, s.FieldsBlacklist)
+			if message == "" && !lo.Contains(s.DistinctOn, f) {
+				message = "distinct on this field is not allowed"
+			}
+			if message != "" {
+				issues = append(issues, &Issue{Param: names.DistinctOn, Value: f, Message: message})
+			}
+		}
+	}
+
+	if request.Search.Present && s.DisableSearch {
+		issues = append(issues, &Issue{Param: names.Search, Message: "search is disabled"})
+	} else if length, ok := s.searchTooLong(request); ok {
+		issues = append(issues, &Issue{
+			Param:   names.Search,
+			Message: fmt.Sprintf("the search query is %d characters long, the maximum is %d", length, s.MaxArgLength),
+		})
+	}
+
+	if request.PerPage.Present && request.PerPage.Val == PerPageAll && !s.AllowAll {
+		issues = append(issues, &Issue{Param: names.PerPage, Value: "all", Message: "fetching all records is not allowed"})
+	}
+
+	return issues, nil
+}
+
+// checkField returns a message describing why the given field cannot be used in a
+// filter or sort in the same way as `getField()`. Returns an empty string if the field is valid.
+// If filter is true, fields having an unsupported data type are reported as well.
+func checkField(field string, sch *schema.Schema, blacklist *Blacklist, filter bool) string {
+	s := sch
+	if i := strings.LastIndex(field, "."); i != -1 && i+1 < len(field) {
+		rel := field[:i]
+		field = field[i+1:]
+		path := newBlacklistPath(blacklist)
+		for _, v := range strings.Split(rel, ".") {
+			relation, ok := s.Relationships.Relations[v]
+			if !ok {
+				return fmt.Sprintf("unknown relation %q", v)
+			}
+			if relation.Type != schema.HasOne && relation.Type != schema.BelongsTo {
+				return fmt.Sprintf("relation %q is not a \"has one\" or \"belongs to\" relation", v)
+			}
+			if path.isDenied(relation) {
+				return fmt.Sprintf("relation %q is blacklisted", v)
+			}
+			s = relation.FieldSchema
+			path = path.next(relation)
+		}
+		blacklist = path.current()
+	}
+	if blacklist != nil && lo.Contains(blacklist.FieldsBlacklist, field) {
+		return "field is blacklisted"
+	}
+	col := s.LookUpField(field)
+	if col == nil {
+		return "unknown field"
+	}
+	if filter && getDataType(col) == DataTypeUnsupported {
+		return "field has an unsupported data type"
+	}
+	return ""
+}
+
+// comparisonOperators the operators comparing the field with arguments of the same data type.
+var comparisonOperators = []string{"$eq", "$ne", "$gt", "$lt", "$gte", "$lte", "$in", "$notin"}
+
+// checkArgs returns a message describing the first argument of the given filter that cannot be
+// converted to the data type of the field, for the operators comparing the field with their
+// arguments. Such filters are replaced by an always-false condition by the scopes. The argument
+// is not included in the message if the field is one of the `SensitiveFields`.
+// Returns an empty string if the arguments are valid.
+func (s *Settings[T]) checkArgs(db *gorm.DB, f *Filter, sch *schema.Schema) string {
+	name, ok := operatorName(f.Operator)
+	if !ok || !lo.Contains(comparisonOperators, name) {
+		return ""
+	}
+	field, _, _ := getField(f.Field, sch, &s.Blacklist)
+	if field == nil {
+		return ""
+	}
+	dataType := getDataType(field)
+	if dataType == DataTypeUnsupported || dataType.IsArray() {
+		return ""
+	}
+	normalized := f
+	if s.BoolLiterals != nil {
+		normalized = s.BoolLiterals.BoolLiterals(db.Statement.Context).normalize(f, sch, &s.Blacklist)
+	}
+	args := normalized.Args
+	if name != "$in" && name != "$notin" {
+		args = args[:min(len(args), 1)]
+	}
+	for i, arg := range args {
+		if _, ok := ConvertToSafeType(arg, dataType); ok {
+			continue
+		}
+		if lo.Contains(s.SensitiveFields, f.Field) {
+			return fmt.Sprintf("an argument cannot be converted to %s", dataType)
+		}
+		return fmt.Sprintf("argument %q cannot be converted to %s", f.Args[i], dataType)
+	}
+	return ""
+}
+
+// checkColumn returns a message describing why the given column cannot be selected
+// in the same way as `cleanColumns()`. Returns an empty string if the column is valid.
+func checkColumn(column string, sch *schema.Schema, blacklist []string) string {
+	if _, ok := sch.FieldsByDBName[column]; !ok {
+		return "unknown field"
+	}
+	if lo.Contains(blacklist, column) {
+		return "field is blacklisted"
+	}
+	return ""
+}
+
+// checkJoin returns the issues preventing the given join from being applied
+// in the same way as `Join.Scopes()`. The issues are reported for the given query parameter.
+func checkJoin(param string, j *Join, sch *schema.Schema, blacklist *Blacklist) []*Issue {
+	path := newBlacklistPath(blacklist)
+	for _, name := range strings.Split(j.Relation, ".") {
+		relation, ok := sch.Relationships.Relations[name]
+		if !ok {
+			return []*Issue{{Param: param, Value: j.Relation, Message: fmt.Sprintf("unknown relation %q", name)}}
+		}
+		if path.isDenied(relation) {
+			return []*Issue{{Param: param, Value: j.Relation, Message: fmt.Sprintf("relation %q is blacklisted", name)}}
+		}
+		sch = relation.FieldSchema
+		path = path.next(relation)
+	}
+
+	var issues []*Issue
+	var fieldsBlacklist []string
+	b := path.current()
+	if b != nil {
+		fieldsBlacklist = b.FieldsBlacklist
+	}
+	if j.WithTrashed && (b == nil || !b.AllowTrashed) {
+		issues = append(issues, &Issue{Param: param, Value: j.Relation, Message: "soft-deleted records are not allowed"})
+	}
+	for _, f := range j.Fields {
+		if message := checkColumn(f, sch, fieldsBlacklist); message != "" {
+			issues = append(issues, &Issue{Param: param, Value: j.Relation + "." + f, Message: message})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,281 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type IssueTestProfile struct {
+	Bio    string
+	Secret string
+	ID     uint
+	UserID uint
+}
+
+type IssueTestArticle struct {
+	Title  string
+	ID     uint
+	UserID uint
+}
+
+type IssueTestUser struct {
+	Profile  *IssueTestProfile   `gorm:"foreignKey:UserID"`
+	Articles []*IssueTestArticle `gorm:"foreignKey:UserID"`
+	Data     []byte              `filterType:"-"`
+	Name     string
+	Password string
+	ID       uint
+}
+
+func TestIssueError(t *testing.T) {
+	assert.Equal(t, `filter: "name": unknown field`, (&Issue{Param: "filter", Value: "name", Message: "unknown field"}).Error())
+	assert.Equal(t, "sort: sorts are disabled", (&Issue{Param: "sort", Message: "sorts are disabled"}).Error())
+}
+
+func TestSettingsCheck(t *testing.T) {
+	db := openDryRunDB(t)
+	settings := &Settings[*IssueTestUser]{
+		Blacklist: Blacklist{
+			FieldsBlacklist: []string{"password"},
+			Relations: map[string]*Blacklist{
+				"Profile": {FieldsBlacklist: []string{"secret"}},
+			},
+		},
+	}
+
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}},
+			{Field: "password", Operator: Operators["$eq"], Args: []string{"a"}},
+			{Field: "Profile.bio", Operator: Operators["$eq"], Args: []string{"a"}},
+			{Field: "Profile.secret", Operator: Operators["$eq"], Args: []string{"a"}},
+			{Field: "data", Operator: Operators["$eq"], Args: []string{"a"}},
+		}),
+		Or: typeutil.NewUndefined([]*Filter{
+			{Field: "Articles.title", Operator: Operators["$eq"], Args: []string{"a"}},
+			{Field: "Unknown.title", Operator: Operators["$eq"], Args: []string{"a"}},
+		}),
+		FilterGroups: typeutil.NewUndefined([][]*Filter{
+			{{Field: "unknown", Operator: Operators["$eq"], Args: []string{"a"}}},
+		}),
+		Sort: typeutil.NewUndefined([]*Sort{
+			{Field: "name", Order: SortAscending},
+			{Field: "data", Order: SortAscending},
+			{Field: "unknown", Order: SortAscending},
+		}),
+		Join: typeutil.NewUndefined([]*Join{
+			{Relation: "Articles", Fields: []string{"title", "unknown"}},
+			{Relation: "Profile", Fields: []string{"bio", "secret"}},
+			{Relation: "Profile.Unknown"},
+		}),
+		Fields: typeutil.NewUndefined([]string{"name", "password", "unknown"}),
+		Search: typeutil.NewUndefined("search"),
+	}
+
+	issues := checkRequest(t, settings, db, request)
+	expected := []*Issue{
+		{Param: "filter", Value: "password", Message: "field is blacklisted"},
+		{Param: "filter", Value: "Profile.secret", Message: "field is blacklisted"},
+		{Param: "filter", Value: "data", Message: "field has an unsupported data type"},
+		{Param: "or", Value: "Articles.title", Message: `relation "Articles" is not a "has one" or "belongs to" relation`},
+		{Param: "or", Value: "Unknown.title", Message: `unknown relation "Unknown"`},
+		{Param: "filter[0]", Value: "unknown", Message: "unknown field"},
+		{Param: "sort", Value: "unknown", Message: "unknown field"},
+		{Param: "join", Value: "Articles.unknown", Message: "unknown field"},
+		{Param: "join", Value: "Profile.secret", Message: "field is blacklisted"},
+		{Param: "join", Value: "Profile.Unknown", Message: `unknown relation "Unknown"`},
+		{Param: "fields", Value: "password", Message: "field is blacklisted"},
+		{Param: "fields", Value: "unknown", Message: "unknown field"},
+	}
+	assert.Equal(t, expected, issues)
+
+	settings = &Settings[*IssueTestUser]{
+		Blacklist: Blacklist{RelationsBlacklist: []string{"Profile"}},
+		MaxJoins:  1,
+	}
+	request = &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "Profile.bio", Operator: Operators["$eq"], Args: []string{"a"}}}),
+		Join:   typeutil.NewUndefined([]*Join{{Relation: "Articles"}, {Relation: "Profile"}}),
+	}
+	expected = []*Issue{
+		{Param: "filter", Value: "Profile.bio", Message: `relation "Profile" is blacklisted`},
+		{Param: "join", Message: "too many joins: the request would join 2 relations, the maximum is 1"},
+		{Param: "join", Value: "Profile", Message: `relation "Profile" is blacklisted`},
+	}
+	assert.Equal(t, expected, checkRequest(t, settings, db, request))
+
+	settings = &Settings[*IssueTestUser]{
+		DisableFilter: true,
+		DisableSort:   true,
+		DisableJoin:   true,
+		DisableFields: true,
+		DisableSearch: true,
+	}
+	request = &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}}}),
+		Sort:   typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortAscending}}),
+		Join:   typeutil.NewUndefined([]*Join{{Relation: "Articles"}}),
+		Fields: typeutil.NewUndefined([]string{"name"}),
+		Search: typeutil.NewUndefined("search"),
+	}
+	expected = []*Issue{
+		{Param: "filter", Message: "filters are disabled"},
+		{Param: "sort", Message: "sorts are disabled"},
+		{Param: "join", Message: "joins are disabled"},
+		{Param: "fields", Message: "fields selection is disabled"},
+		{Param: "search", Message: "search is disabled"},
+	}
+	assert.Equal(t, expected, checkRequest(t, settings, db, request))
+
+	assert.Nil(t, checkRequest(t, settings, db, &Request{}))
+
+	settings = &Settings[*IssueTestUser]{
+		Blacklist: Blacklist{Relations: map[string]*Blacklist{"Articles": {AllowTrashed: true}}},
+	}
+	request = &Request{
+		Join: typeutil.NewUndefined([]*Join{{Relation: "Articles", WithTrashed: true}, {Relation: "Profile", WithTrashed: true}}),
+	}
+	expected = []*Issue{
+		{Param: "join", Value: "Profile", Message: "soft-deleted records are not allowed"},
+	}
+	assert.Equal(t, expected, checkRequest(t, settings, db, request))
+
+	request = &Request{PerPage: typeutil.NewUndefined(PerPageAll)}
+	expected = []*Issue{
+		{Param: "per_page", Value: "all", Message: "fetching all records is not allowed"},
+	}
+	assert.Equal(t, expected, checkRequest(t, &Settings[*IssueTestUser]{}, db, request))
+	assert.Nil(t, checkRequest(t, &Settings[*IssueTestUser]{AllowAll: true}, db, request))
+
+	request = &Request{DistinctOn: typeutil.NewUndefined([]string{"name", "id", "notacolumn"})}
+	expected = []*Issue{
+		{Param: "distinct_on", Value: "id", Message: "distinct on this field is not allowed"},
+		{Param: "distinct_on", Value: "notacolumn", Message: "unknown field"},
+	}
+	assert.Equal(t, expected, checkRequest(t, &Settings[*IssueTestUser]{DistinctOn: []string{"name"}}, db, request))
+}
+
+func TestNewValidatedRequest(t *testing.T) {
+	db := openDryRunDB(t)
+	query := map[string]any{
+		"filter": []*Filter{{Field: "unknown", Operator: Operators["$eq"], Args: []string{"a"}}},
+		"sort":   []*Sort{{Field: "name", Order: SortDescending}},
+	}
+
+	request, issues, err := NewValidatedRequest[*IssueTestUser](db, query, nil)
+	require.NoError(t, err)
+	assert.Equal(t, NewRequest(query), request)
+	assert.Equal(t, []*Issue{{Param: "filter", Value: "unknown", Message: "unknown field"}}, issues)
+
+	settings := &Settings[*IssueTestUser]{DisableSort: true}
+	_, issues, err = NewValidatedRequest(db, query, settings)
+	require.NoError(t, err)
+	assert.Equal(t, []*Issue{
+		{Param: "filter", Value: "unknown", Message: "unknown field"},
+		{Param: "sort", Message: "sorts are disabled"},
+	}, issues)
+}
+
+func TestNewValidatedRequestParamNames(t *testing.T) {
+	db := openDryRunDB(t)
+	settings := &Settings[*IssueTestUser]{
+		ParamNames:  ParamNames{Filter: "where", Sort: "order_by", Join: "with"},
+		DisableSort: true,
+	}
+	query := map[string]any{
+		"where":    []*Filter{{Field: "unknown", Operator: Operators["$eq"], Args: []string{"a"}}},
+		"where[0]": []*Filter{{Field: "Profile.unknown", Operator: Operators["$eq"], Args: []string{"a"}}},
+		"order_by": []*Sort{{Field: "name", Order: SortDescending}},
+		"with":     []*Join{{Relation: "Unknown"}},
+		"fields":   []string{"unknown"},
+	}
+
+	request, issues, err := NewValidatedRequest(db, query, settings)
+	require.NoError(t, err)
+	assert.Equal(t, settings.ParamNames.NewRequest(query), request)
+	assert.Equal(t, []*Issue{
+		{Param: "where", Value: "unknown", Message: "unknown field"},
+		{Param: "where[0]", Value: "Profile.unknown", Message: "unknown field"},
+		{Param: "order_by", Message: "sorts are disabled"},
+		{Param: "with", Value: "Unknown", Message: `unknown relation "Unknown"`},
+		{Param: "fields", Value: "unknown", Message: "unknown field"},
+	}, issues)
+}
+
+func TestSettingsCheckArgs(t *testing.T) {
+	db := openDryRunDB(t)
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "id", Operator: Operators["$eq"], Args: []string{"1"}},
+			{Field: "id", Operator: Operators["$gt"], Args: []string{"abc"}},
+			{Field: "id", Operator: Operators["$in"], Args: []string{"1", "2", "secret@example.org"}},
+			{Field: "Profile.user_id", Operator: Operators["$in"], Args: []string{"1", "secret@example.org"}},
+			{Field: "name", Operator: Operators["$eq"], Args: []string{"abc"}},
+			{Field: "id", Operator: Operators["$cont"], Args: []string{"abc"}},
+		}),
+	}
+
+	expected := []*Issue{
+		{Param: "filter", Value: "id", Message: `argument "abc" cannot be converted to uint64`},
+		{Param: "filter", Value: "id", Message: `argument "secret@example.org" cannot be converted to uint64`},
+		{Param: "filter", Value: "Profile.user_id", Message: `argument "secret@example.org" cannot be converted to uint64`},
+	}
+	assert.Equal(t, expected, checkRequest(t, &Settings[*IssueTestUser]{}, db, request))
+
+	expected = []*Issue{
+		{Param: "filter", Value: "id", Message: "an argument cannot be converted to uint64"},
+		{Param: "filter", Value: "id", Message: "an argument cannot be converted to uint64"},
+		{Param: "filter", Value: "Profile.user_id", Message: `argument "secret@example.org" cannot be converted to uint64`},
+	}
+	assert.Equal(t, expected, checkRequest(t, &Settings[*IssueTestUser]{SensitiveFields: []string{"id"}}, db, request))
+}
+
+func TestSettingsCheckArgLength(t *testing.T) {
+	db := openDryRunDB(t)
+	settings := &Settings[*IssueTestUser]{
+		MaxArgLength:      3,
+		FieldMaxArgLength: map[string]int{"Profile.bio": 5},
+	}
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Operator: Operators["$in"], Args: []string{"abc", "abcd"}},
+			{Field: "Profile.bio", Operator: Operators["$eq"], Args: []string{"abcde"}},
+		}),
+		Or:     typeutil.NewUndefined([]*Filter{{Field: "Profile.bio", Operator: Operators["$eq"], Args: []string{"abcdef"}}}),
+		Search: typeutil.NewUndefined("abcd"),
+	}
+
+	expected := []*Issue{
+		{Param: "filter", Value: "name", Message: "an argument is 4 characters long, the maximum is 3"},
+		{Param: "or", Value: "Profile.bio", Message: "an argument is 6 characters long, the maximum is 5"},
+		{Param: "search", Message: "the search query is 4 characters long, the maximum is 3"},
+	}
+	assert.Equal(t, expected, checkRequest(t, settings, db, request))
+}
+
+func TestCheckInvalidModel(t *testing.T) {
+	db := openDryRunDB(t)
+	issues, err := (&Settings[*struct{ A chan int }]{}).Check(db, &Request{})
+	require.ErrorIs(t, err, ErrUnsupportedModel)
+	assert.Nil(t, issues)
+
+	settings := &Settings[*IssueTestUser]{
+		VirtualRelations: map[string]*VirtualRelation{
+			"Stats": {Model: &IssueTestProfile{}, ForeignKey: "unknown"},
+		},
+	}
+	_, issues, err = NewValidatedRequest(db, map[string]any{}, settings)
+	require.ErrorIs(t, err, ErrInvalidVirtualRelation)
+	assert.Nil(t, issues)
+}
+
+func checkRequest[T any](t *testing.T, settings *Settings[T], db *gorm.DB, request *Request) []*Issue {
+	t.Helper()
+	issues, err := settings.Check(db, request)
+	require.NoError(t, err)
+	return issues
+}
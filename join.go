@@ -3,7 +3,6 @@ package filter
 import (
 	"bytes"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/samber/lo"
@@ -13,47 +12,48 @@ import (
 	"goyave.dev/goyave/v5/util/errors"
 )
 
-var (
-	joinRegex = regexp.MustCompile("(?i)((LEFT|RIGHT|FULL)\\s+)?((OUTER|INNER)\\s+)?JOIN\\s+[\"'`]?(?P<TableName>\\w+)[\"'`]?\\s+((AS\\s+)?[\"'`]?(?P<Alias>\\w+)[\"'`]?)?\\s*ON")
-)
+// JoinWithTrashed the join option including the soft-deleted records of the
+// relation: "relation||*||withTrashed".
+const JoinWithTrashed = "withTrashed"
 
 // Join structured representation of a join query.
 type Join struct {
-	selectCache map[string][]string
-	Relation    string
-	Fields      []string
+	selectCache  map[string][]string
+	trashedCache map[string]bool
+	// pathsCache the relations joined by all the joins of the request.
+	pathsCache []string
+	Relation   string   `json:"relation"`
+	Fields     []string `json:"fields"`
+	// WithTrashed if true, the soft-deleted records of the relation are included.
+	// Ignored if the blacklist of the relation doesn't allow it (see `Blacklist.AllowTrashed`).
+	WithTrashed bool `json:"with_trashed,omitempty"`
 }
 
 // Scopes returns the GORM scopes to use in order to apply this joint.
 func (j *Join) Scopes(blacklist Blacklist, schema *schema.Schema) []func(*gorm.DB) *gorm.DB {
-	scopes := j.applyRelation(schema, &blacklist, j.Relation, 0, make([]func(*gorm.DB) *gorm.DB, 0, strings.Count(j.Relation, ".")+1))
+	scopes := j.applyRelation(schema, newBlacklistPath(&blacklist), j.Relation, 0, make([]func(*gorm.DB) *gorm.DB, 0, strings.Count(j.Relation, ".")+1))
 	if scopes != nil {
 		return scopes
 	}
 	return nil
 }
 
-func (j *Join) applyRelation(schema *schema.Schema, blacklist *Blacklist, relationName string, startIndex int, scopes []func(*gorm.DB) *gorm.DB) []func(*gorm.DB) *gorm.DB {
-	if blacklist != nil && blacklist.IsFinal {
-		return nil
-	}
+func (j *Join) applyRelation(schema *schema.Schema, blacklist *blacklistPath, relationName string, startIndex int, scopes []func(*gorm.DB) *gorm.DB) []func(*gorm.DB) *gorm.DB {
 	trimmedRelationName := relationName[startIndex:]
 	i := strings.Index(trimmedRelationName, ".")
 	if i == -1 {
-		if blacklist != nil {
-			if lo.Contains(blacklist.RelationsBlacklist, trimmedRelationName) {
-				return nil
-			}
-			blacklist = blacklist.Relations[trimmedRelationName]
-		}
-
 		r, ok := schema.Relationships.Relations[trimmedRelationName]
-		if !ok {
+		if !ok || blacklist.isDenied(r) {
 			return nil
 		}
 
+		b := blacklist.next(r).current()
+		withTrashed := j.WithTrashed && b != nil && b.AllowTrashed
 		j.selectCache[relationName] = j.Fields
-		return append(scopes, joinScope(relationName, r, j.Fields, blacklist))
+		if j.trashedCache != nil {
+			j.trashedCache[relationName] = withTrashed
+		}
+		return append(scopes, joinScope(relationName, r, j.Fields, b, withTrashed, j.nestedRelations(relationName, r)))
 	}
 
 	if startIndex+i+1 >= len(relationName) {
@@ -61,28 +61,46 @@ func (j *Join) applyRelation(schema *schema.Schema, blacklist *Blacklist, relati
 	}
 
 	name := trimmedRelationName[:i]
-	var b *Blacklist
-	if blacklist != nil {
-		if lo.Contains(blacklist.RelationsBlacklist, name) {
-			return nil
-		}
-		b = blacklist.Relations[name]
-	}
 	r, ok := schema.Relationships.Relations[name]
-	if !ok {
+	if !ok || blacklist.isDenied(r) {
 		return nil
 	}
+	b := blacklist.next(r)
 	n := relationName[:startIndex+i]
 	fields := []string{}
 	if f, ok := j.selectCache[n]; ok {
 		fields = f
 	}
-	scopes = append(scopes, joinScope(n, r, fields, b))
+	scopes = append(scopes, joinScope(n, r, fields, b.current(), j.trashedCache[n], j.nestedRelations(n, r)))
 
 	return j.applyRelation(r.FieldSchema, b, relationName, startIndex+i+1, scopes)
 }
 
-func joinScope(relationName string, rel *schema.Relationship, fields []string, blacklist *Blacklist) func(*gorm.DB) *gorm.DB {
+// nestedRelations returns the relations of the given relation's model that are joined
+// through it by the joins of the request (e.g. "Nested" for "Relation" if "Relation.Nested" is joined).
+func (j *Join) nestedRelations(relationName string, rel *schema.Relationship) []*schema.Relationship {
+	paths := j.pathsCache
+	if paths == nil {
+		paths = []string{j.Relation}
+	}
+	var nested []*schema.Relationship
+	for _, path := range paths {
+		child, ok := strings.CutPrefix(path, relationName+".")
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(child, ".")
+		if r, ok := rel.FieldSchema.Relationships.Relations[name]; ok && !lo.Contains(nested, r) {
+			nested = append(nested, r)
+		}
+	}
+	return nested
+}
+
+// joinScope returns the scope preloading the given relation. If withTrashed is true,
+// the soft-deleted records of the relation are included. The keys needed to preload the
+// relation and the given nested relations are always selected.
+func joinScope(relationName string, rel *schema.Relationship, fields []string, blacklist *Blacklist, withTrashed bool, nested []*schema.Relationship) func(*gorm.DB) *gorm.DB {
 	var columns []*schema.Field
 	if fields == nil {
 		columns = getSelectableFields(blacklist, rel.FieldSchema)
@@ -96,7 +114,7 @@ func joinScope(relationName string, rel *schema.Relationship, fields []string, b
 
 	return func(tx *gorm.DB) *gorm.DB {
 		if rel.FieldSchema.Table == "" {
-			tx.AddError(errors.Errorf("relation %q is anonymous, could not get table name", relationName))
+			tx.AddError(errors.Errorf("%w: %q", ErrAnonymousRelation, relationName))
 			return tx
 		}
 		if columns != nil {
@@ -114,13 +132,35 @@ func joinScope(relationName string, rel *schema.Relationship, fields []string, b
 					}
 				}
 			}
+			// The keys of the relation's model used by the relation itself and by the nested relations
+			for _, r := range append([]*schema.Relationship{rel}, nested...) {
+				for _, ref := range r.References {
+					for _, key := range []*schema.Field{ref.ForeignKey, ref.PrimaryKey} {
+						if key != nil && key.Schema == rel.FieldSchema && !columnsContain(columns, key) && (blacklist == nil || !lo.Contains(blacklist.FieldsBlacklist, key.DBName)) {
+							columns = append(columns, key)
+						}
+					}
+				}
+			}
 		}
 
-		return tx.Preload(relationName, selectScope(rel.FieldSchema.Table, columns, true))
+		selectColumns := selectScope(rel.FieldSchema.Table, columns, true)
+		if withTrashed {
+			return tx.Preload(relationName, func(tx *gorm.DB) *gorm.DB {
+				return selectColumns(tx.Unscoped())
+			})
+		}
+		return tx.Preload(relationName, selectColumns)
 	}
 }
 
 func join(tx *gorm.DB, joinName string, sch *schema.Schema) *gorm.DB {
+	return addJoins(tx, relationJoins(joinName, sch))
+}
+
+// relationJoins returns the `LEFT JOIN` clauses needed to join the relation identified
+// by the given join name (e.g. "Relation.Parent"), one for each relation of the path.
+func relationJoins(joinName string, sch *schema.Schema) []clause.Join {
 	var lastTable string
 	var relation *schema.Relationship
 	joins := make([]clause.Join, 0, strings.Count(joinName, ".")+1)
@@ -152,15 +192,21 @@ func join(tx *gorm.DB, joinName string, sch *schema.Schema) *gorm.DB {
 				}
 			}
 		}
-		j := clause.Join{
+		joins = append(joins, clause.Join{
 			Type:  clause.LeftJoin,
 			Table: clause.Table{Name: sch.Table, Alias: relation.Name},
 			ON:    clause.Where{Exprs: exprs},
-		}
-		if !joinExists(tx.Statement, j) && !findStatementJoin(tx.Statement, &j) {
-			joins = append(joins, j)
-		}
+		})
 	}
+	return joins
+}
+
+// addJoins adds the given joins to the FROM clause of the statement, except
+// the ones already present in the statement.
+func addJoins(tx *gorm.DB, joins []clause.Join) *gorm.DB {
+	joins = lo.Filter(joins, func(j clause.Join, _ int) bool {
+		return !joinExists(tx.Statement, j) && !findStatementJoin(tx.Statement, &j)
+	})
 	if c, ok := tx.Statement.Clauses["FROM"]; ok {
 		from := c.Expression.(clause.From)
 		from.Joins = append(from.Joins, joins...)
@@ -171,6 +217,44 @@ func join(tx *gorm.DB, joinName string, sch *schema.Schema) *gorm.DB {
 	return tx.Clauses(clause.From{Joins: joins})
 }
 
+// joinPaths the relations joined by the filters, search and sorts of a request,
+// identified by their join name (e.g. "Relation.Parent"). The relations are collected
+// for the whole request so each of them is joined a single time, even if it is referenced
+// by several filters, the search and the sorts, or is an intermediate relation of another path.
+type joinPaths []string
+
+// add the given join names, ignoring the empty ones and the ones already present.
+func (p *joinPaths) add(joinNames ...string) {
+	for _, joinName := range joinNames {
+		if joinName != "" && !lo.Contains(*p, joinName) {
+			*p = append(*p, joinName)
+		}
+	}
+}
+
+// scope returns the scope joining all the collected relations. The paths are read when
+// the scope is executed, so the scope can be registered before the paths are collected.
+func (p *joinPaths) scope(sch *schema.Schema) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if len(*p) == 0 {
+			return tx
+		}
+		if err := tx.Statement.Parse(tx.Statement.Model); err != nil {
+			tx.AddError(err)
+			return tx
+		}
+		joins := make([]clause.Join, 0, len(*p))
+		for _, joinName := range *p {
+			for _, j := range relationJoins(joinName, sch) {
+				if !lo.ContainsBy(joins, func(other clause.Join) bool { return other.Table == j.Table }) {
+					joins = append(joins, j)
+				}
+			}
+		}
+		return addJoins(tx, joins)
+	}
+}
+
 func joinExists(stmt *gorm.Statement, join clause.Join) bool {
 	if c, ok := stmt.Clauses["FROM"]; ok {
 		from := c.Expression.(clause.From)
@@ -182,13 +266,8 @@ func joinExists(stmt *gorm.Statement, join clause.Join) bool {
 		}
 	}
 	for _, j := range stmt.Joins {
-		groups := joinRegex.FindStringSubmatch(j.Name)
-		if groups != nil {
-			tableName := groups[joinRegex.SubexpIndex("TableName")]
-			aliasName := groups[joinRegex.SubexpIndex("Alias")]
-			tableMatch := tableName == join.Table.Name
-			aliasMatch := aliasName == "" || aliasName == join.Table.Alias
-			if tableMatch && aliasMatch {
+		for _, raw := range parseRawJoins(j.Name) {
+			if raw.matches(join.Table.Name, join.Table.Alias) {
 				return true
 			}
 		}
@@ -243,14 +322,142 @@ func processJoinsComputedColumns(stmt *gorm.Statement, sch *schema.Schema) {
 		for _, s := range rel.FieldSchema.DBNames {
 			if v, ok := selectColumns[s]; (ok && v) || (!ok && !restricted) {
 				field := rel.FieldSchema.FieldsByDBName[s]
-				computed := field.StructField.Tag.Get("computed")
-				if computed != "" {
-					stmt.Selects = append(stmt.Selects, fmt.Sprintf("(%s) %s", strings.ReplaceAll(computed, clause.CurrentTable, quoteString(stmt, j.Name)), quoteString(stmt, j.Name+"__"+s)))
-					continue
-				}
-				stmt.Selects = append(stmt.Selects, fmt.Sprintf("%s.%s %s", quoteString(stmt, j.Name), quoteString(stmt, s), quoteString(stmt, j.Name+"__"+s)))
+				stmt.Selects = append(stmt.Selects, fmt.Sprintf("%s %s", columnExpression(stmt, j.Name, field), quoteString(stmt, j.Name+"__"+s)))
 			}
 		}
 		stmt.Joins[i] = j
 	}
 }
+
+// countQuerySetting the key of the GORM statement setting marking the queries counting
+// the records of a request (see `markCountQuery()`).
+const countQuerySetting = "goyave-filter:count_query"
+
+// markCountQuery returns a new session of the given DB marked as counting the records
+// of the request, so `duplicateJoinsScope()` counts the distinct primary keys instead of
+// selecting distinct rows.
+func markCountQuery(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{}).Set(countQuerySetting, true)
+}
+
+// isCountQuery returns true if the given DB was marked using `markCountQuery()`.
+func isCountQuery(tx *gorm.DB) bool {
+	count, _ := tx.Get(countQuerySetting)
+	return count == true
+}
+
+// duplicateJoinsScope returns the scope preventing the joins that may match several rows
+// for a single record (see `hasDuplicatingJoins()`) from duplicating the records: the
+// count query (see `markCountQuery()`) counts the distinct primary keys and the other
+// queries select distinct rows. The joins are kept in the queries, so the columns of the
+// joined tables can still be selected.
+// The scope does nothing if the query is already grouped or if the model doesn't have
+// exactly one primary key. The scope must be applied after the scopes adding the joins.
+func duplicateJoinsScope(sch *schema.Schema) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if len(sch.PrimaryFields) != 1 {
+			return tx
+		}
+		if _, ok := tx.Statement.Clauses["GROUP BY"]; ok || !hasDuplicatingJoins(tx.Statement, sch) {
+			return tx
+		}
+		if isCountQuery(tx) {
+			pk := clause.Column{Table: sch.Table, Name: sch.PrimaryFields[0].DBName}
+			tx.Statement.AddClause(clause.Select{Expression: clause.Expr{SQL: "COUNT(DISTINCT ?)", Vars: []any{pk}}})
+			return tx
+		}
+		tx.Statement.Distinct = true
+		return tx
+	}
+}
+
+// hasDuplicatingJoins returns true if the statement contains a join that may match several rows
+// for a single record: raw joins (e.g. `db.Joins("LEFT JOIN ...")`) that don't manually join a
+// relation using its name as alias, joins of "has many" and "many to many" relations, and joins
+// of "belongs to" relations referencing a column that is neither a primary key nor unique
+// (e.g. virtual relations with custom `References`).
+func hasDuplicatingJoins(stmt *gorm.Statement, sch *schema.Schema) bool {
+	for _, j := range stmt.Joins {
+		if rel := lookUpRelationPath(sch, j.Name); rel != nil {
+			if !isUniqueRelation(rel) {
+				return true
+			}
+			continue
+		}
+		// Raw joins are only considered safe if they join a relation manually
+		raws := parseRawJoins(j.Name)
+		if len(raws) == 0 {
+			return true
+		}
+		for _, raw := range raws {
+			rel := findRelation(sch, raw.Alias, map[*schema.Schema]bool{})
+			if raw.Alias == "" || rel == nil || !raw.matches(rel.FieldSchema.Table, rel.Name) || !isUniqueRelation(rel) {
+				return true
+			}
+		}
+	}
+	c, ok := stmt.Clauses["FROM"]
+	if !ok {
+		return false
+	}
+	from, ok := c.Expression.(clause.From)
+	if !ok {
+		return false
+	}
+	for _, j := range from.Joins {
+		if j.Expression != nil {
+			return true
+		}
+		rel := findRelation(sch, j.Table.Alias, map[*schema.Schema]bool{})
+		if rel == nil || !isUniqueRelation(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookUpRelationPath returns the last relation of the given path (e.g. "Relation.Nested"),
+// or nil if the path doesn't identify a relation of the given schema.
+func lookUpRelationPath(sch *schema.Schema, path string) *schema.Relationship {
+	var rel *schema.Relationship
+	for _, name := range strings.Split(path, ".") {
+		r, ok := sch.Relationships.Relations[name]
+		if !ok {
+			return nil
+		}
+		rel = r
+		sch = r.FieldSchema
+	}
+	return rel
+}
+
+// findRelation returns the first relation with the given name found in the given schema
+// or in the schemas of its relations, recursively. Returns nil if there is none.
+func findRelation(sch *schema.Schema, name string, visited map[*schema.Schema]bool) *schema.Relationship {
+	if visited[sch] {
+		return nil
+	}
+	visited[sch] = true
+	if rel, ok := sch.Relationships.Relations[name]; ok {
+		return rel
+	}
+	for _, r := range sch.Relationships.Relations {
+		if rel := findRelation(r.FieldSchema, name, visited); rel != nil {
+			return rel
+		}
+	}
+	return nil
+}
+
+// isUniqueRelation returns true if joining the given relation matches at most one row per record.
+func isUniqueRelation(rel *schema.Relationship) bool {
+	switch rel.Type {
+	case schema.HasOne:
+		return true
+	case schema.BelongsTo:
+		return lo.EveryBy(rel.References, func(ref *schema.Reference) bool {
+			return ref.PrimaryValue != "" || ref.PrimaryKey.PrimaryKey || ref.PrimaryKey.Unique
+		})
+	}
+	return false
+}
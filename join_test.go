@@ -1,10 +1,13 @@
 package filter
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/typeutil"
 )
 
 type JoinTestModel struct {
@@ -115,7 +118,8 @@ func TestJoinScopeAnonymousRelation(t *testing.T) {
 	db = db.Model(&JoinTestModel{}).Scopes(join.Scopes(Blacklist{}, schema)...).Find(nil)
 	assert.Empty(t, db.Statement.Preloads)
 	assert.Empty(t, db.Statement.Selects)
-	assert.Equal(t, "relation \"Relation\" is anonymous, could not get table name", db.Error.Error())
+	assert.Equal(t, "relation is anonymous, could not get table name: \"Relation\"", db.Error.Error())
+	assert.ErrorIs(t, db.Error, ErrAnonymousRelation)
 	assert.Equal(t, []string{"a", "b", "notacolumn"}, join.selectCache["Relation"])
 }
 
@@ -387,3 +391,289 @@ func TestJoinScopeComputedField(t *testing.T) {
 	}
 	assert.Equal(t, []string{"a", "b", "c"}, join.selectCache["Relation"])
 }
+
+func TestJoinJSON(t *testing.T) {
+	join := &Join{Relation: "Relation.Parent", Fields: []string{"a", "b"}}
+	data, err := json.Marshal(join)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"relation":"Relation.Parent","fields":["a","b"]}`, string(data))
+
+	result := &Join{}
+	if assert.NoError(t, json.Unmarshal(data, result)) {
+		assert.Equal(t, join, result)
+	}
+}
+
+type JoinTrashedAuthor struct {
+	DeletedAt gorm.DeletedAt
+	Name      string
+	ID        int
+	CommentID int
+}
+
+type JoinTrashedComment struct {
+	Author    *JoinTrashedAuthor `gorm:"foreignKey:CommentID"`
+	DeletedAt gorm.DeletedAt
+	Content   string
+	ID        int
+	ParentID  int
+}
+
+type JoinTrashedModel struct {
+	Comments []*JoinTrashedComment `gorm:"foreignKey:ParentID"`
+	ID       int
+}
+
+func TestJoinScopeWithTrashed(t *testing.T) {
+	preloadSQL := func(t *testing.T, db *gorm.DB, relation string, model any) string {
+		if !assert.Contains(t, db.Statement.Preloads, relation) {
+			return ""
+		}
+		tx := openDryRunDB(t).Model(model).Scopes(db.Statement.Preloads[relation][0].(func(*gorm.DB) *gorm.DB)).Find(nil)
+		return tx.Statement.SQL.String()
+	}
+
+	request := &Request{
+		Join: typeutil.NewUndefined([]*Join{{Relation: "Comments", Fields: []string{"content"}, WithTrashed: true}, {Relation: "Comments.Author", WithTrashed: true}}),
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		settings := &Settings[*JoinTrashedModel]{
+			Blacklist: Blacklist{Relations: map[string]*Blacklist{"Comments": {AllowTrashed: true}}},
+		}
+		results := []*JoinTrashedModel{}
+		db := settings.ScopeUnpaginated(openDryRunDB(t), request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, "SELECT `join_trashed_comments`.`content`,`join_trashed_comments`.`id`,`join_trashed_comments`.`parent_id` FROM `join_trashed_comments`", preloadSQL(t, db, "Comments", &JoinTrashedComment{}))
+		assert.Equal(t, "SELECT `join_trashed_authors`.`deleted_at`,`join_trashed_authors`.`name`,`join_trashed_authors`.`id`,`join_trashed_authors`.`comment_id` FROM `join_trashed_authors` WHERE `join_trashed_authors`.`deleted_at` IS NULL", preloadSQL(t, db, "Comments.Author", &JoinTrashedAuthor{}))
+	})
+
+	t.Run("not_allowed", func(t *testing.T) {
+		results := []*JoinTrashedModel{}
+		db := (&Settings[*JoinTrashedModel]{}).ScopeUnpaginated(openDryRunDB(t), request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, "SELECT `join_trashed_comments`.`content`,`join_trashed_comments`.`id`,`join_trashed_comments`.`parent_id` FROM `join_trashed_comments` WHERE `join_trashed_comments`.`deleted_at` IS NULL", preloadSQL(t, db, "Comments", &JoinTrashedComment{}))
+	})
+}
+
+func TestJoinPaths(t *testing.T) {
+	paths := &joinPaths{}
+	paths.add("Relation", "", "Relation.Parent", "Relation")
+	paths.add("Relation.Parent")
+	assert.Equal(t, &joinPaths{"Relation", "Relation.Parent"}, paths)
+}
+
+func TestScopeJoinsRelationOnce(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "Relation.name", Operator: Operators["$eq"], Args: []string{"a"}},
+			{Field: "Relation.id", Operator: Operators["$gt"], Args: []string{"1"}},
+		}),
+		Or:     typeutil.NewUndefined([]*Filter{{Field: "Relation.name", Operator: Operators["$eq"], Args: []string{"b"}, Or: true}}),
+		Search: typeutil.NewUndefined("c"),
+		Sort:   typeutil.NewUndefined([]*Sort{{Field: "Relation.name", Order: SortAscending}}),
+	}
+	settings := &Settings[*FilterTestModel]{FieldsSearch: []string{"Relation.name"}}
+
+	results := []*FilterTestModel{}
+	db := settings.ScopeUnpaginated(openDryRunDB(t), request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` LEFT JOIN `filter_test_relations` `Relation` ON `filter_test_models`.`id` = `Relation`.`parent_id` WHERE ((`Relation`.`name` = ? AND `Relation`.`id` > ?) OR `Relation`.`name` = ?) AND `Relation`.`name` LIKE ? ORDER BY `Relation`.`name`",
+		db.Statement.SQL.String(),
+	)
+}
+
+type JoinNestedKeyOwner struct {
+	Name string
+	ID   uint
+}
+
+type JoinNestedKeyRelation struct {
+	Owner    *JoinNestedKeyOwner
+	Name     string
+	ID       uint
+	ParentID uint
+	OwnerID  uint
+}
+
+type JoinNestedKeyModel struct {
+	Relation *JoinNestedKeyRelation `gorm:"foreignKey:ParentID"`
+	Name     string
+	ID       uint
+}
+
+func TestJoinScopeNestedForeignKeys(t *testing.T) {
+	request := &Request{
+		Join: typeutil.NewUndefined([]*Join{
+			{Relation: "Relation", Fields: []string{"name"}},
+			{Relation: "Relation.Owner", Fields: []string{"name"}},
+		}),
+		Fields: typeutil.NewUndefined([]string{"name"}),
+	}
+	results := []*JoinNestedKeyModel{}
+	db := (&Settings[*JoinNestedKeyModel]{}).ScopeUnpaginated(openDryRunDB(t), request, &results)
+	require.NoError(t, db.Error)
+
+	if assert.Contains(t, db.Statement.Preloads, "Relation") {
+		tx := openDryRunDB(t).Model(&JoinNestedKeyRelation{}).Scopes(db.Statement.Preloads["Relation"][0].(func(*gorm.DB) *gorm.DB)).Find(nil)
+		assert.Equal(t, []string{"`join_nested_key_relations`.`name`", "`join_nested_key_relations`.`id`", "`join_nested_key_relations`.`parent_id`", "`join_nested_key_relations`.`owner_id`"}, tx.Statement.Selects)
+	}
+
+	t.Run("standalone", func(t *testing.T) {
+		join := &Join{Relation: "Relation.Owner", Fields: []string{"name"}}
+		join.selectCache = map[string][]string{"Relation": {"name"}}
+		sch, err := parseModel(openDryRunDB(t), &JoinNestedKeyModel{})
+		require.NoError(t, err)
+		db := openDryRunDB(t).Model(&JoinNestedKeyModel{}).Scopes(join.Scopes(Blacklist{}, sch)...).Find(nil)
+		if assert.Contains(t, db.Statement.Preloads, "Relation") {
+			tx := openDryRunDB(t).Model(&JoinNestedKeyRelation{}).Scopes(db.Statement.Preloads["Relation"][0].(func(*gorm.DB) *gorm.DB)).Find(nil)
+			assert.Equal(t, []string{"`join_nested_key_relations`.`name`", "`join_nested_key_relations`.`id`", "`join_nested_key_relations`.`parent_id`", "`join_nested_key_relations`.`owner_id`"}, tx.Statement.Selects)
+		}
+	})
+}
+
+type DuplicateJoinTestModel struct {
+	Name  string
+	Email string
+	ID    uint
+}
+
+type DuplicateJoinTestLogin struct {
+	Email string
+	IP    string
+	ID    uint
+}
+
+func TestScopeDuplicateJoins(t *testing.T) {
+	virtualRelations := func(references string) map[string]*VirtualRelation {
+		return map[string]*VirtualRelation{
+			"Login": {Model: &DuplicateJoinTestLogin{}, ForeignKey: "email", References: references},
+		}
+	}
+	loginFilter := &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "Login.ip", Operator: Operators["$eq"], Args: []string{"127.0.0.1"}}})}
+	selectModel := "SELECT `duplicate_join_test_models`.`name`,`duplicate_join_test_models`.`email`,`duplicate_join_test_models`.`id` FROM `duplicate_join_test_models` "
+	loginJoin := "LEFT JOIN `duplicate_join_test_logins` `Login` ON `duplicate_join_test_models`.`email` = `Login`.`email` "
+
+	cases := []struct {
+		request  *Request
+		settings *Settings[*DuplicateJoinTestModel]
+		db       func(*gorm.DB) *gorm.DB
+		desc     string
+		want     []string
+	}{
+		{
+			desc:     "raw_join",
+			request:  &Request{},
+			settings: &Settings[*DuplicateJoinTestModel]{},
+			db: func(db *gorm.DB) *gorm.DB {
+				return db.Joins("LEFT JOIN logins ON logins.email = duplicate_join_test_models.email").Where("logins.ip = ?", "127.0.0.1")
+			},
+			want: []string{
+				"SELECT COUNT(DISTINCT `duplicate_join_test_models`.`id`) FROM `duplicate_join_test_models` LEFT JOIN logins ON logins.email = duplicate_join_test_models.email WHERE logins.ip = ?",
+				"SELECT DISTINCT " + selectModel[len("SELECT "):] + "LEFT JOIN logins ON logins.email = duplicate_join_test_models.email WHERE logins.ip = ? LIMIT ?",
+			},
+		},
+		{
+			desc:     "raw_join_sorted",
+			request:  &Request{Sort: typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortDescending}})},
+			settings: &Settings[*DuplicateJoinTestModel]{},
+			db: func(db *gorm.DB) *gorm.DB {
+				return db.Joins("LEFT JOIN logins ON logins.email = duplicate_join_test_models.email").Where("logins.ip = ?", "127.0.0.1")
+			},
+			want: []string{
+				"SELECT COUNT(DISTINCT `duplicate_join_test_models`.`id`) FROM `duplicate_join_test_models` LEFT JOIN logins ON logins.email = duplicate_join_test_models.email WHERE logins.ip = ?",
+				"SELECT DISTINCT " + selectModel[len("SELECT "):] + "LEFT JOIN logins ON logins.email = duplicate_join_test_models.email WHERE logins.ip = ? ORDER BY `duplicate_join_test_models`.`name` DESC LIMIT ?",
+			},
+		},
+		{
+			desc:     "raw_join_selected",
+			request:  &Request{},
+			settings: &Settings[*DuplicateJoinTestModel]{},
+			db: func(db *gorm.DB) *gorm.DB {
+				return db.Joins("LEFT JOIN logins ON logins.email = duplicate_join_test_models.email").
+					Where("logins.ip = ?", "127.0.0.1").
+					Select("duplicate_join_test_models.*, logins.ip").
+					Order("logins.ip")
+			},
+			want: []string{
+				"SELECT COUNT(DISTINCT `duplicate_join_test_models`.`id`) FROM `duplicate_join_test_models` LEFT JOIN logins ON logins.email = duplicate_join_test_models.email WHERE logins.ip = ?",
+				"SELECT DISTINCT duplicate_join_test_models.*, logins.ip," + selectModel[len("SELECT "):] + "LEFT JOIN logins ON logins.email = duplicate_join_test_models.email WHERE logins.ip = ? ORDER BY logins.ip LIMIT ?",
+			},
+		},
+		{
+			desc:     "raw_join_grouped",
+			request:  &Request{},
+			settings: &Settings[*DuplicateJoinTestModel]{},
+			db: func(db *gorm.DB) *gorm.DB {
+				return db.Joins("LEFT JOIN logins ON logins.email = duplicate_join_test_models.email").Group("duplicate_join_test_models.id").Having("COUNT(logins.id) > ?", 1)
+			},
+			want: []string{
+				"SELECT count(*) FROM `duplicate_join_test_models` LEFT JOIN logins ON logins.email = duplicate_join_test_models.email GROUP BY `duplicate_join_test_models`.`id` HAVING COUNT(logins.id) > ?",
+				selectModel + "LEFT JOIN logins ON logins.email = duplicate_join_test_models.email GROUP BY `duplicate_join_test_models`.`id` HAVING COUNT(logins.id) > ? LIMIT ?",
+			},
+		},
+		{
+			desc:     "virtual_relation_not_unique",
+			request:  loginFilter,
+			settings: &Settings[*DuplicateJoinTestModel]{VirtualRelations: virtualRelations("email")},
+			want: []string{
+				"SELECT COUNT(DISTINCT `duplicate_join_test_models`.`id`) FROM `duplicate_join_test_models` " + loginJoin + "WHERE `Login`.`ip` = ?",
+				"SELECT DISTINCT " + selectModel[len("SELECT "):] + loginJoin + "WHERE `Login`.`ip` = ? LIMIT ?",
+			},
+		},
+		{
+			desc:     "virtual_relation_primary_key",
+			request:  loginFilter,
+			settings: &Settings[*DuplicateJoinTestModel]{VirtualRelations: virtualRelations("")},
+			want: []string{
+				"SELECT count(*) FROM `duplicate_join_test_models` LEFT JOIN `duplicate_join_test_logins` `Login` ON `duplicate_join_test_models`.`email` = `Login`.`id` WHERE `Login`.`ip` = ?",
+				selectModel + "LEFT JOIN `duplicate_join_test_logins` `Login` ON `duplicate_join_test_models`.`email` = `Login`.`id` WHERE `Login`.`ip` = ? LIMIT ?",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			queries := []string{}
+			err := db.Callback().Query().After("gorm:query").Register("test:queries", func(tx *gorm.DB) {
+				queries = append(queries, tx.Statement.SQL.String())
+			})
+			require.NoError(t, err)
+			if c.db != nil {
+				db = c.db(db)
+			}
+
+			results := []*DuplicateJoinTestModel{}
+			_, err = c.settings.Scope(db, c.request, &results)
+			require.NoError(t, err)
+			assert.Equal(t, c.want, queries)
+		})
+	}
+}
+
+func TestScopeDuplicateJoinsScanInt64(t *testing.T) {
+	// Scanning the built query into an int64 must not be mistaken for the count query.
+	db := openDryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Row().After("gorm:row").Register("test:queries", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	})
+	require.NoError(t, err)
+	db = db.Joins("LEFT JOIN logins ON logins.email = duplicate_join_test_models.email").Where("logins.ip = ?", "127.0.0.1")
+
+	settings := &Settings[*DuplicateJoinTestModel]{}
+	results := []*DuplicateJoinTestModel{}
+	tx, err := settings.Build(db, &Request{}, &results)
+	require.NoError(t, err)
+
+	var n int64
+	tx.Scan(&n)
+	want := []string{
+		"SELECT DISTINCT `duplicate_join_test_models`.`name`,`duplicate_join_test_models`.`email`,`duplicate_join_test_models`.`id` FROM `duplicate_join_test_models` LEFT JOIN logins ON logins.email = duplicate_join_test_models.email WHERE logins.ip = ?",
+	}
+	assert.Equal(t, want, queries)
+}
@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"slices"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// keyset the ordered columns used to paginate a query using the keyset (seek) method
+// instead of `OFFSET`: each batch starts right after the last record of the previous batch,
+// so records inserted or deleted while iterating don't shift the following batches.
+//
+// Nullable columns are ordered by the tuple `(col IS NULL, col)` so the NULL values are
+// sorted last in ascending order and first in descending order on all database engines.
+// The primary key is always the last column of the keyset so the order is total.
+type keyset struct {
+	table string
+	keys  []keysetKey
+}
+
+type keysetKey struct {
+	field *schema.Field
+	desc  bool
+}
+
+// newKeyset returns the keyset matching the effective sorts of the request, completed by
+// the primary key. Returns nil if the keyset method cannot be used: the model doesn't have
+// a primary key, the request uses "distinct_on", a sort references a relation or is
+// case-insensitive, or a column of the keyset is not selected (including lazy columns
+// pruned by `AutoPruneSelects`).
+func (s *Settings[T]) newKeyset(request *Request, sch *schema.Schema) *keyset {
+	if len(sch.PrimaryFields) == 0 || len(s.distinctOnFields(request, sch)) > 0 {
+		return nil
+	}
+
+	var sorts []*Sort
+	if !s.DisableSort {
+		sorts = request.Sort.Default(s.DefaultSort)
+	}
+
+	k := &keyset{table: sch.Table, keys: make([]keysetKey, 0, len(sorts)+len(sch.PrimaryFields))}
+	for _, sort := range sorts {
+		field, _, joinName := getField(sort.Field, sch, &s.Blacklist)
+		if field == nil {
+			continue
+		}
+		if joinName != "" || (s.CaseInsensitiveSort && getDataType(field) == DataTypeText) {
+			return nil
+		}
+		if !k.contains(field) {
+			k.keys = append(k.keys, keysetKey{field: field, desc: sort.Order == SortDescending})
+		}
+	}
+	for _, pk := range sch.PrimaryFields {
+		if !k.contains(pk) {
+			k.keys = append(k.keys, keysetKey{field: pk})
+		}
+	}
+
+	for _, key := range k.keys {
+		fieldsPresent := !s.DisableFields && request.Fields.Present
+		if lo.Contains(s.FieldsBlacklist, key.field.DBName) ||
+			(fieldsPresent && !lo.Contains(request.Fields.Val, key.field.DBName)) ||
+			(!fieldsPresent && s.AutoPruneSelects && isLazy(key.field)) {
+			return nil
+		}
+	}
+	return k
+}
+
+func (k *keyset) contains(field *schema.Field) bool {
+	return lo.ContainsBy(k.keys, func(key keysetKey) bool { return key.field.DBName == field.DBName })
+}
+
+// orderScope returns the scope ordering the query by the columns of the keyset.
+func (k *keyset) orderScope() func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		for _, key := range k.keys {
+			expr := columnExpression(tx.Statement, k.table, key.field)
+			if key.nullable() {
+				tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Raw: true, Name: expr + " IS NULL"}, Desc: key.desc})
+			}
+			tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Raw: true, Name: expr}, Desc: key.desc})
+		}
+		return tx
+	}
+}
+
+// seekScope returns the scope restricting the query to the records located
+// after the given record in the order of the keyset.
+func (k *keyset) seekScope(ctx context.Context, record any) func(*gorm.DB) *gorm.DB {
+	rv := reflect.ValueOf(record)
+	values := make([]any, len(k.keys))
+	for i, key := range k.keys {
+		v, _ := key.field.ValueOf(ctx, rv)
+		values[i] = keysetValue(v)
+	}
+
+	return func(tx *gorm.DB) *gorm.DB {
+		alternatives := make([]clause.Expression, 0, len(k.keys))
+		equalities := make([]clause.Expression, 0, len(k.keys))
+		for i, key := range k.keys {
+			expr := columnExpression(tx.Statement, k.table, key.field)
+			if after := key.after(expr, values[i]); after != nil {
+				alternatives = append(alternatives, clause.And(append(slices.Clone(equalities), after)...))
+			}
+			if values[i] == nil {
+				equalities = append(equalities, clause.Expr{SQL: expr + " IS NULL"})
+			} else {
+				equalities = append(equalities, clause.Expr{SQL: expr + " = ?", Vars: []any{values[i]}})
+			}
+		}
+		return tx.Where(clause.Or(alternatives...))
+	}
+}
+
+// after returns the condition matching the values located strictly after the given value
+// for this key, or nil if no value can be located after it.
+func (key keysetKey) after(expr string, value any) clause.Expression {
+	switch {
+	case value == nil && key.desc:
+		return clause.Expr{SQL: expr + " IS NOT NULL"}
+	case value == nil:
+		return nil
+	case key.desc:
+		return clause.Expr{SQL: expr + " < ?", Vars: []any{value}}
+	case key.nullable():
+		return clause.Or(clause.Expr{SQL: expr + " > ?", Vars: []any{value}}, clause.Expr{SQL: expr + " IS NULL"})
+	default:
+		return clause.Expr{SQL: expr + " > ?", Vars: []any{value}}
+	}
+}
+
+// nullable returns false if the column of this key cannot contain NULL values:
+// primary keys and columns having the `not null` GORM tag.
+func (key keysetKey) nullable() bool {
+	return !key.field.PrimaryKey && !key.field.NotNull
+}
+
+// keysetValue returns nil if the given field value represents NULL: nil pointers and
+// `driver.Valuer` returning nil (e.g. `sql.NullString`). Otherwise the value is returned as is.
+func keysetValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil && value == nil {
+			return nil
+		}
+	}
+	return v
+}
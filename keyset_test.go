@@ -0,0 +1,186 @@
+package filter
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type KeysetTestRelation struct {
+	Name     string
+	ID       uint
+	ParentID uint
+}
+
+type KeysetTestModel struct {
+	Relation *KeysetTestRelation `gorm:"foreignKey:ParentID"`
+	Name     *string
+	Score    int `gorm:"not null"`
+	ID       uint
+}
+
+func TestScopeIteratorKeyset(t *testing.T) {
+	prevBatchSize := DefaultBatchSize
+	DefaultBatchSize = 2
+	t.Cleanup(func() {
+		DefaultBatchSize = prevBatchSize
+	})
+
+	records := []*KeysetTestModel{
+		{Name: lo.ToPtr("a"), Score: 5, ID: 3},
+		{Name: lo.ToPtr("b"), Score: 1, ID: 1},
+		{Name: nil, Score: 7, ID: 2},
+		{Name: nil, Score: 2, ID: 4},
+	}
+	db := openDryRunDB(t)
+	queries := []string{}
+	vars := [][]any{}
+	err := db.Callback().Query().After("gorm:query").Register("test:fill", func(tx *gorm.DB) {
+		dest, ok := tx.Statement.Dest.(*[]*KeysetTestModel)
+		if !ok {
+			return
+		}
+		queries = append(queries, tx.Statement.SQL.String())
+		vars = append(vars, tx.Statement.Vars)
+		start := min((len(queries)-1)*DefaultBatchSize, len(records))
+		*dest = append(*dest, records[start:min(start+DefaultBatchSize, len(records))]...)
+	})
+	require.NoError(t, err)
+
+	request := &Request{
+		Sort: typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortAscending}, {Field: "score", Order: SortDescending}}),
+	}
+	settings := &Settings[*KeysetTestModel]{KeysetIteration: true}
+
+	ids := []uint{}
+	for record, err := range settings.ScopeIterator(db, request) {
+		require.NoError(t, err)
+		ids = append(ids, record.ID)
+	}
+	assert.Equal(t, []uint{3, 1, 2, 4}, ids)
+
+	selectFrom := "SELECT `keyset_test_models`.`name`,`keyset_test_models`.`score`,`keyset_test_models`.`id` FROM `keyset_test_models` "
+	orderBy := " ORDER BY `keyset_test_models`.`name` IS NULL,`keyset_test_models`.`name`,`keyset_test_models`.`score` DESC,`keyset_test_models`.`id` LIMIT ?"
+	assert.Equal(t, []string{
+		selectFrom + orderBy[1:],
+		selectFrom + "WHERE ((`keyset_test_models`.`name` > ? OR `keyset_test_models`.`name` IS NULL) OR (`keyset_test_models`.`name` = ? AND `keyset_test_models`.`score` < ?) OR (`keyset_test_models`.`name` = ? AND `keyset_test_models`.`score` = ? AND `keyset_test_models`.`id` > ?))" + orderBy,
+		selectFrom + "WHERE ((`keyset_test_models`.`name` IS NULL AND `keyset_test_models`.`score` < ?) OR (`keyset_test_models`.`name` IS NULL AND `keyset_test_models`.`score` = ? AND `keyset_test_models`.`id` > ?))" + orderBy,
+	}, queries)
+	assert.Equal(t, [][]any{
+		{2},
+		{lo.ToPtr("b"), lo.ToPtr("b"), 1, lo.ToPtr("b"), 1, uint(1), 2},
+		{2, 2, uint(4), 2},
+	}, vars)
+}
+
+func TestKeysetKeyAfter(t *testing.T) {
+	db := openDryRunDB(t)
+	sch, err := parseModel(db, &KeysetTestModel{})
+	require.NoError(t, err)
+	name := sch.LookUpField("name")
+	score := sch.LookUpField("score")
+
+	cases := []struct {
+		value any
+		want  clause.Expression
+		desc  string
+		key   keysetKey
+	}{
+		{desc: "asc_null", key: keysetKey{field: name}, value: nil, want: nil},
+		{desc: "desc_null", key: keysetKey{field: name, desc: true}, value: nil, want: clause.Expr{SQL: "col IS NOT NULL"}},
+		{desc: "desc", key: keysetKey{field: name, desc: true}, value: "a", want: clause.Expr{SQL: "col < ?", Vars: []any{"a"}}},
+		{
+			desc:  "asc_nullable",
+			key:   keysetKey{field: name},
+			value: "a",
+			want:  clause.Or(clause.Expr{SQL: "col > ?", Vars: []any{"a"}}, clause.Expr{SQL: "col IS NULL"}),
+		},
+		{desc: "asc_not_null", key: keysetKey{field: score}, value: 1, want: clause.Expr{SQL: "col > ?", Vars: []any{1}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.want, c.key.after("col", c.value))
+		})
+	}
+}
+
+func TestKeysetFallback(t *testing.T) {
+	db := openDryRunDB(t)
+	sch, err := parseModel(db, &KeysetTestModel{})
+	require.NoError(t, err)
+
+	cases := []struct {
+		request  *Request
+		settings *Settings[*KeysetTestModel]
+		desc     string
+		want     []string
+	}{
+		{desc: "default", request: &Request{}, settings: &Settings[*KeysetTestModel]{}, want: []string{"id"}},
+		{
+			desc:     "default_sort",
+			request:  &Request{},
+			settings: &Settings[*KeysetTestModel]{DefaultSort: []*Sort{{Field: "score", Order: SortDescending}}},
+			want:     []string{"score", "id"},
+		},
+		{
+			desc:     "disable_sort",
+			request:  &Request{Sort: typeutil.NewUndefined([]*Sort{{Field: "score", Order: SortAscending}})},
+			settings: &Settings[*KeysetTestModel]{DisableSort: true},
+			want:     []string{"id"},
+		},
+		{
+			desc:     "ignored_sort",
+			request:  &Request{Sort: typeutil.NewUndefined([]*Sort{{Field: "notacolumn", Order: SortAscending}, {Field: "id", Order: SortDescending}})},
+			settings: &Settings[*KeysetTestModel]{},
+			want:     []string{"id"},
+		},
+		{
+			desc:     "relation",
+			request:  &Request{Sort: typeutil.NewUndefined([]*Sort{{Field: "Relation.name", Order: SortAscending}})},
+			settings: &Settings[*KeysetTestModel]{},
+		},
+		{
+			desc:     "case_insensitive",
+			request:  &Request{Sort: typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortAscending}})},
+			settings: &Settings[*KeysetTestModel]{CaseInsensitiveSort: true},
+		},
+		{
+			desc:     "not_selected",
+			request:  &Request{Fields: typeutil.NewUndefined([]string{"name", "score"})},
+			settings: &Settings[*KeysetTestModel]{},
+		},
+		{
+			desc:     "blacklisted",
+			request:  &Request{},
+			settings: &Settings[*KeysetTestModel]{Blacklist: Blacklist{FieldsBlacklist: []string{"id"}}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			k := c.settings.newKeyset(c.request, sch)
+			if c.want == nil {
+				assert.Nil(t, k)
+				return
+			}
+			require.NotNil(t, k)
+			assert.Equal(t, c.want, lo.Map(k.keys, func(key keysetKey, _ int) string { return key.field.DBName }))
+		})
+	}
+}
+
+func TestKeysetValue(t *testing.T) {
+	assert.Nil(t, keysetValue(nil))
+	assert.Nil(t, keysetValue((*string)(nil)))
+	assert.Nil(t, keysetValue(sql.NullString{}))
+	assert.Equal(t, sql.NullString{String: "a", Valid: true}, keysetValue(sql.NullString{String: "a", Valid: true}))
+	assert.Equal(t, "", keysetValue(""))
+	assert.Equal(t, 0, keysetValue(0))
+}
@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm/logger"
+)
+
+// redactedParam the value replacing the parameters of the logged queries.
+const redactedParam = "[REDACTED]"
+
+// queryLogger GORM logger logging the queries at debug level using a `*slog.Logger`
+// with their parameters redacted, or hashed using an HMAC if `hashKey` is not empty.
+// All calls are also forwarded to the original GORM logger, which receives the redacted
+// queries too.
+type queryLogger struct {
+	logger.Interface
+	logger  *slog.Logger
+	hashKey []byte
+}
+
+func (l *queryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &queryLogger{
+		Interface: l.Interface.LogMode(level),
+		logger:    l.logger,
+		hashKey:   l.hashKey,
+	}
+}
+
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	sql, rows := fc()
+	attrs := []any{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Duration("elapsed", time.Since(begin)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.logger.DebugContext(ctx, "filter query", attrs...)
+	l.Interface.Trace(ctx, begin, func() (string, int64) { return sql, rows }, err)
+}
+
+// ParamsFilter replaces the parameters of the query with a redacted value or their
+// HMAC-SHA256 keyed with `hashKey`, truncated to 8 bytes.
+func (l *queryLogger) ParamsFilter(_ context.Context, sql string, params ...any) (string, []any) {
+	filtered := make([]any, len(params))
+	for i, p := range params {
+		if len(l.hashKey) > 0 {
+			mac := hmac.New(sha256.New, l.hashKey)
+			mac.Write([]byte(fmt.Sprint(p)))
+			filtered[i] = "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+		} else {
+			filtered[i] = redactedParam
+		}
+	}
+	return sql, filtered
+}
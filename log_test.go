@@ -0,0 +1,101 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/logger"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type testGORMLogger struct {
+	logger.Interface
+	sql   []string
+	level logger.LogLevel
+}
+
+func (l *testGORMLogger) LogMode(level logger.LogLevel) logger.Interface {
+	l.level = level
+	return l
+}
+
+func (l *testGORMLogger) Trace(_ context.Context, _ time.Time, fc func() (sql string, rowsAffected int64), _ error) {
+	sql, _ := fc()
+	l.sql = append(l.sql, sql)
+}
+
+func TestQueryLogger(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	slogger := slog.New(slog.NewTextHandler(buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	t.Run("redacted", func(t *testing.T) {
+		buffer.Reset()
+		gormLogger := &testGORMLogger{}
+		l := &queryLogger{Interface: gormLogger, logger: slogger}
+
+		sql, params := l.ParamsFilter(context.Background(), "SELECT ?", "secret", 1)
+		assert.Equal(t, "SELECT ?", sql)
+		assert.Equal(t, []any{redactedParam, redactedParam}, params)
+
+		l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT '[REDACTED]'", 3 }, errors.New("test error"))
+		assert.Contains(t, buffer.String(), `level=DEBUG msg="filter query" sql="SELECT '[REDACTED]'" rows=3`)
+		assert.Contains(t, buffer.String(), `error="test error"`)
+		assert.Equal(t, []string{"SELECT '[REDACTED]'"}, gormLogger.sql)
+
+		mode := l.LogMode(logger.Info)
+		assert.Equal(t, logger.Info, gormLogger.level)
+		assert.Equal(t, &queryLogger{Interface: gormLogger, logger: slogger}, mode)
+	})
+
+	t.Run("hashed", func(t *testing.T) {
+		l := &queryLogger{Interface: &testGORMLogger{}, logger: slogger, hashKey: []byte("key")}
+		_, params := l.ParamsFilter(context.Background(), "SELECT ?", "secret", "secret", 1)
+		require.Len(t, params, 3)
+		assert.Equal(t, params[0], params[1])
+		assert.NotEqual(t, params[0], params[2])
+		assert.Regexp(t, "^hmac:[0-9a-f]{16}$", params[0])
+
+		other := &queryLogger{Interface: &testGORMLogger{}, logger: slogger, hashKey: []byte("other key")}
+		_, otherParams := other.ParamsFilter(context.Background(), "SELECT ?", "secret")
+		assert.NotEqual(t, params[0], otherParams[0])
+	})
+}
+
+func TestScopeQueryLogger(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	settings := &Settings[*FilterTestModel]{
+		QueryLogger: slog.New(slog.NewTextHandler(buffer, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"secret"}, Operator: Operators["$eq"]}}),
+	}
+
+	db := openDryRunDB(t)
+	gormLogger := &testGORMLogger{}
+	db.Logger = gormLogger
+	results := []*FilterTestModel{}
+	_, err := settings.Scope(db, request, &results)
+	require.NoError(t, err)
+
+	assert.NotContains(t, buffer.String(), "secret")
+	assert.Contains(t, buffer.String(), "SELECT count(*) FROM `filter_test_models` WHERE `filter_test_models`.`name` = \\\"[REDACTED]\\\"")
+	require.Len(t, gormLogger.sql, 2)
+	for _, sql := range gormLogger.sql {
+		assert.NotContains(t, sql, "secret")
+	}
+
+	// The logger is not used if not defined in the settings
+	buffer.Reset()
+	gormLogger.sql = nil
+	_, err = (&Settings[*FilterTestModel]{}).Scope(db, request, &results)
+	require.NoError(t, err)
+	assert.Empty(t, buffer.String())
+	require.Len(t, gormLogger.sql, 2)
+	assert.Contains(t, gormLogger.sql[0], "secret")
+}
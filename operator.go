@@ -2,9 +2,12 @@ package filter
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/samber/lo"
 	"gorm.io/gorm"
-	"goyave.dev/goyave/v5/util/sqlutil"
 )
 
 // Operator used by filters to build the SQL query.
@@ -17,8 +20,15 @@ import (
 //
 // Operators may return the given tx without change if they don't support the given dataType or
 // add a condition that will always be false.
+//
+// The operator function is applied to a group of conditions, so only the WHERE conditions it adds
+// are kept. Operators needing other clauses (e.g. joins, GROUP BY or HAVING to filter on a relation
+// aggregate) can add them using the optional `Scope` function, which receives the main query instead.
+// It is applied once for each filter using the operator, with the same arguments as the operator
+// function, and the clauses it adds are always ANDed with the other conditions.
 type Operator struct {
 	Function          func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB
+	Scope             func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB
 	RequiredArguments uint8
 }
 
@@ -34,10 +44,11 @@ var (
 		"$starts": {
 			Function: func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
 				if dataType != DataTypeText && dataType != DataTypeEnum {
-					return filter.Where(tx, "FALSE")
+					return filter.Where(tx, getDialect(tx).False)
 				}
-				query := castEnumAsText(column, dataType) + " LIKE ?"
-				value := sqlutil.EscapeLike(filter.Args[0]) + "%"
+				dialect := getDialect(tx)
+				query := dialect.like(column, dataType, false)
+				value := dialect.escapeLike(filter.Args[0]) + "%"
 				return filter.Where(tx, query, value)
 			},
 			RequiredArguments: 1,
@@ -45,33 +56,45 @@ var (
 		"$ends": {
 			Function: func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
 				if dataType != DataTypeText && dataType != DataTypeEnum {
-					return filter.Where(tx, "FALSE")
+					return filter.Where(tx, getDialect(tx).False)
 				}
-				query := castEnumAsText(column, dataType) + " LIKE ?"
-				value := "%" + sqlutil.EscapeLike(filter.Args[0])
+				dialect := getDialect(tx)
+				query := dialect.like(column, dataType, false)
+				value := "%" + dialect.escapeLike(filter.Args[0])
 				return filter.Where(tx, query, value)
 			},
 			RequiredArguments: 1,
 		},
-		"$cont": {
+		// "$startsany" matches the values starting with any of the given prefixes.
+		"$startsany": {Function: startsAnyComparison(false), RequiredArguments: 1},
+		"$cont":      {Function: containsComparison, RequiredArguments: 1},
+		// "$search" applies the `Settings.SearchOperator` to a single field.
+		// Behaves like "$cont" if the settings don't define a search operator.
+		"$search": {Function: containsComparison, RequiredArguments: 1},
+		"$excl": {
 			Function: func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
 				if dataType != DataTypeText && dataType != DataTypeEnum {
-					return filter.Where(tx, "FALSE")
+					return filter.Where(tx, getDialect(tx).False)
 				}
-				query := castEnumAsText(column, dataType) + " LIKE ?"
-				value := "%" + sqlutil.EscapeLike(filter.Args[0]) + "%"
+				dialect := getDialect(tx)
+				query := dialect.like(column, dataType, true)
+				value := "%" + dialect.escapeLike(filter.Args[0]) + "%"
 				return filter.Where(tx, query, value)
 			},
 			RequiredArguments: 1,
 		},
-		"$excl": {
+		"$day": {
 			Function: func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
-				if dataType != DataTypeText && dataType != DataTypeEnum {
-					return filter.Where(tx, "FALSE")
+				if dataType != DataTypeTime {
+					return filter.Where(tx, getDialect(tx).False)
 				}
-				query := castEnumAsText(column, dataType) + " NOT LIKE ?"
-				value := "%" + sqlutil.EscapeLike(filter.Args[0]) + "%"
-				return filter.Where(tx, query, value)
+				t, ok := parseTime(filter.Args[0])
+				if !ok {
+					return filter.Where(tx, getDialect(tx).False)
+				}
+				start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+				query := fmt.Sprintf("%s >= ? AND %s < ?", column, column)
+				return filter.Where(tx, query, start.Format(time.DateOnly), start.AddDate(0, 0, 1).Format(time.DateOnly))
 			},
 			RequiredArguments: 1,
 		},
@@ -86,18 +109,18 @@ var (
 		"$istrue": {
 			Function: func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
 				if dataType != DataTypeBool {
-					return filter.Where(tx, "FALSE")
+					return filter.Where(tx, getDialect(tx).False)
 				}
-				return filter.Where(tx, column+" IS TRUE")
+				return filter.Where(tx, fmt.Sprintf(getDialect(tx).IsTrue, column))
 			},
 			RequiredArguments: 0,
 		},
 		"$isfalse": {
 			Function: func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
 				if dataType != DataTypeBool {
-					return filter.Where(tx, "FALSE")
+					return filter.Where(tx, getDialect(tx).False)
 				}
-				return filter.Where(tx, column+" IS FALSE")
+				return filter.Where(tx, fmt.Sprintf(getDialect(tx).IsFalse, column))
 			},
 			RequiredArguments: 0,
 		},
@@ -107,56 +130,257 @@ var (
 			},
 			RequiredArguments: 0,
 		},
+		// "$between" accepts 2N arguments, interpreted as N ranges ORed together.
+		// An unpaired last argument is ignored.
 		"$between": {
+			Function:          betweenComparison,
+			RequiredArguments: 2,
+		},
+		// "$mod" matches the records for which the remainder of the division of the column
+		// by the first argument equals the second argument (e.g. "every 10th record").
+		"$mod": {
 			Function: func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
-				if dataType.IsArray() {
-					return filter.Where(tx, "FALSE")
+				if !dataType.IsInteger() {
+					return filter.Where(tx, getDialect(tx).False)
 				}
-				args, ok := ConvertArgsToSafeType(filter.Args[:2], dataType)
+				divisor, ok := validateInt(filter.Args[0], 64)
+				if !ok || divisor == 0 {
+					return filter.Where(tx, getDialect(tx).False)
+				}
+				remainder, ok := validateInt(filter.Args[1], 64)
 				if !ok {
-					return filter.Where(tx, "FALSE")
+					return filter.Where(tx, getDialect(tx).False)
 				}
-				query := castEnumAsText(column, dataType) + " BETWEEN ? AND ?"
-				return filter.Where(tx, query, args...)
+				return filter.Where(tx, getDialect(tx).mod(column)+" = ?", divisor, remainder)
 			},
 			RequiredArguments: 2,
 		},
+		// "$bitand" matches the records for which at least one of the bits of
+		// the mask given as argument is set in the column (e.g. flags or permissions).
+		"$bitand": {
+			Function: func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+				if !dataType.IsInteger() {
+					return filter.Where(tx, getDialect(tx).False)
+				}
+				mask, ok := ConvertToSafeType(filter.Args[0], dataType)
+				if !ok {
+					return filter.Where(tx, getDialect(tx).False)
+				}
+				return filter.Where(tx, getDialect(tx).bitAnd(column), mask)
+			},
+			RequiredArguments: 1,
+		},
+		"$lengt": {Function: lengthComparison(">"), RequiredArguments: 1},
+		"$lenlt": {Function: lengthComparison("<"), RequiredArguments: 1},
+		"$leneq": {Function: lengthComparison("="), RequiredArguments: 1},
 	}
+
+	// OperatorAliases alternative names of the operators that can be used in queries.
+	// The key is the alias (e.g. "$contains") and the value is the name of the operator
+	// in the `Operators` map (e.g. "$cont"). Filters using an alias are encoded
+	// with the name of the operator.
+	OperatorAliases = map[string]string{}
+
+	// DeprecatedOperators the operators and aliases clients should stop using. The key is
+	// the name used in queries and the value is an optional message (e.g. "use $cont instead").
+	// Deprecated operators still work, but their use is reported by `Settings.Check()`
+	// and stored in the `DeprecationsSetting` statement setting by the scopes.
+	DeprecatedOperators = map[string]string{}
 )
 
-func castEnumAsText(column string, dataType DataType) string {
-	if dataType == DataTypeEnum || dataType == DataTypeEnumArray {
-		return fmt.Sprintf("CAST(%s AS TEXT)", column)
+// lookupOperator returns the operator registered under the given name in the `Operators`
+// map, or the operator the given alias refers to in the `OperatorAliases` map.
+func lookupOperator(name string) (*Operator, bool) {
+	if operator, ok := Operators[name]; ok {
+		return operator, true
+	}
+	if target, ok := OperatorAliases[name]; ok {
+		operator, ok := Operators[target]
+		return operator, ok
 	}
-	return column
+	return nil, false
+}
+
+// deprecationMessage returns the message describing why the operator used by the given
+// filter is deprecated, and true if the alias used in the query or the name of the operator
+// is in `DeprecatedOperators`.
+func deprecationMessage(f *Filter) (string, bool) {
+	name := f.queryOperator
+	if name == "" {
+		name, _ = operatorName(f.Operator)
+	}
+	message, ok := DeprecatedOperators[name]
+	if !ok {
+		return "", false
+	}
+	if message == "" {
+		return fmt.Sprintf("operator %q is deprecated", name), true
+	}
+	return fmt.Sprintf("operator %q is deprecated: %s", name, message), true
+}
+
+// deprecationMessages returns the distinct messages describing the deprecated operators
+// used by the filters of the given request (see `deprecationMessage()`).
+func deprecationMessages(request *Request) []string {
+	var messages []string
+	filters := slices.Concat(request.Filter.Val, request.Or.Val, lo.Flatten(request.FilterGroups.Val))
+	for _, f := range filters {
+		if message, ok := deprecationMessage(f); ok && !lo.Contains(messages, message) {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// caseInsensitiveOperators the case-insensitive variants of the text operators, used
+// when `Settings.CaseInsensitiveFilter` is enabled. The key is the name of the operator
+// in the `Operators` map. The variants only differ for text and enum fields.
+var caseInsensitiveOperators = map[string]*Operator{
+	"$eq":     {Function: caseInsensitiveComparison("$eq", "", ""), RequiredArguments: 1},
+	"$cont":   {Function: caseInsensitiveComparison("$cont", "%", "%"), RequiredArguments: 1},
+	"$starts": {Function: caseInsensitiveComparison("$starts", "", "%"), RequiredArguments: 1},
+	"$ends":   {Function: caseInsensitiveComparison("$ends", "%", ""), RequiredArguments: 1},
+
+	"$startsany": {Function: startsAnyComparison(true), RequiredArguments: 1},
+}
+
+// caseInsensitiveOperator returns the case-insensitive variant of the given operator,
+// or the operator itself if it doesn't have one.
+func caseInsensitiveOperator(operator *Operator) *Operator {
+	for name, op := range caseInsensitiveOperators {
+		if Operators[name] == operator {
+			return op
+		}
+	}
+	return operator
+}
+
+// caseInsensitiveComparison returns an operator function comparing text fields ignoring the case.
+// The value is wrapped with the given prefix and suffix (`%` for `LIKE` patterns). If the value
+// is not a pattern and the dialect doesn't support `ILIKE`, the comparison uses `=`.
+// Other data types are handled by the original operator.
+func caseInsensitiveComparison(name, prefix, suffix string) func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+	return func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+		if dataType != DataTypeText && dataType != DataTypeEnum {
+			return Operators[name].Function(tx, filter, column, dataType)
+		}
+		dialect := getDialect(tx)
+		if !dialect.ILike && prefix == "" && suffix == "" {
+			return filter.Where(tx, dialect.caseInsensitive(column, dataType, "="), filter.Args[0])
+		}
+		value := prefix + dialect.escapeLike(filter.Args[0]) + suffix
+		return filter.Where(tx, dialect.caseInsensitive(column, dataType, "LIKE"), value)
+	}
+}
+
+// operatorName returns the name of the given operator in the `Operators` map.
+// If the operator is registered under several names, the first one in alphabetical
+// order is returned so the result is deterministic.
+func operatorName(operator *Operator) (string, bool) {
+	names := make([]string, 0, 1)
+	for name, op := range Operators {
+		if op == operator {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	return slices.Min(names), true
 }
 
 func basicComparison(op string) func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
 	return func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
 		if dataType.IsArray() {
-			return filter.Where(tx, "FALSE")
+			return filter.Where(tx, getDialect(tx).False)
 		}
 		arg, ok := ConvertToSafeType(filter.Args[0], dataType)
 		if !ok {
-			return filter.Where(tx, "FALSE")
+			return filter.Where(tx, getDialect(tx).False)
 		}
 
-		query := fmt.Sprintf("%s %s ?", castEnumAsText(column, dataType), op)
+		query := fmt.Sprintf("%s %s ?", getDialect(tx).castEnumAsText(column, dataType), op)
 		return filter.Where(tx, query, arg)
 	}
 }
 
+// lengthComparison returns an operator function comparing the number of characters
+// of a text or enum column to a non-negative integer.
+func lengthComparison(op string) func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+	return func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+		if dataType != DataTypeText && dataType != DataTypeEnum {
+			return filter.Where(tx, getDialect(tx).False)
+		}
+		length, ok := validateUint(filter.Args[0], 32)
+		if !ok {
+			return filter.Where(tx, getDialect(tx).False)
+		}
+		query := fmt.Sprintf("%s %s ?", getDialect(tx).length(column, dataType), op)
+		return filter.Where(tx, query, length)
+	}
+}
+
+// startsAnyComparison returns an operator function matching text and enum values starting
+// with any of the filter arguments. Generates one `LIKE` condition per prefix, combined with `OR`,
+// so the conditions can still use an index on the column.
+func startsAnyComparison(caseInsensitive bool) func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+	return func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+		if dataType != DataTypeText && dataType != DataTypeEnum {
+			return filter.Where(tx, getDialect(tx).False)
+		}
+		dialect := getDialect(tx)
+		condition := dialect.like(column, dataType, false)
+		if caseInsensitive {
+			condition = dialect.caseInsensitive(column, dataType, "LIKE")
+		}
+		prefixes := lo.Uniq(filter.Args)
+		conditions := make([]string, 0, len(prefixes))
+		args := make([]any, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			conditions = append(conditions, condition)
+			args = append(args, dialect.escapeLike(prefix)+"%")
+		}
+		return filter.Where(tx, strings.Join(conditions, " OR "), args...)
+	}
+}
+
+func betweenComparison(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+	if dataType.IsArray() {
+		return filter.Where(tx, getDialect(tx).False)
+	}
+	args, ok := ConvertArgsToSafeType(filter.Args[:len(filter.Args)/2*2], dataType)
+	if !ok {
+		return filter.Where(tx, getDialect(tx).False)
+	}
+	condition := getDialect(tx).castEnumAsText(column, dataType) + " BETWEEN ? AND ?"
+	conditions := make([]string, 0, len(args)/2)
+	for range len(args) / 2 {
+		conditions = append(conditions, condition)
+	}
+	return filter.Where(tx, strings.Join(conditions, " OR "), args...)
+}
+
+func containsComparison(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+	if dataType != DataTypeText && dataType != DataTypeEnum {
+		return filter.Where(tx, getDialect(tx).False)
+	}
+	dialect := getDialect(tx)
+	query := dialect.like(column, dataType, false)
+	value := "%" + dialect.escapeLike(filter.Args[0]) + "%"
+	return filter.Where(tx, query, value)
+}
+
 func multiComparison(op string) func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
 	return func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
 		if dataType.IsArray() {
-			return filter.Where(tx, "FALSE")
+			return filter.Where(tx, getDialect(tx).False)
 		}
 		args, ok := ConvertArgsToSafeType(filter.Args, dataType)
 		if !ok {
-			return filter.Where(tx, "FALSE")
+			return filter.Where(tx, getDialect(tx).False)
 		}
 
-		query := fmt.Sprintf("%s %s ?", castEnumAsText(column, dataType), op)
+		query := fmt.Sprintf("%s %s ?", getDialect(tx).castEnumAsText(column, dataType), op)
 		return filter.Where(tx, query, args)
 	}
 }
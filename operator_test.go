@@ -1,10 +1,14 @@
 package filter
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"goyave.dev/goyave/v5/util/typeutil"
 )
 
 type operatorTestCase struct {
@@ -583,6 +587,104 @@ func TestStarts(t *testing.T) {
 	}
 }
 
+func TestStartsAny(t *testing.T) {
+	cases := []operatorTestCase{
+		{
+			desc:     "single",
+			op:       "$startsany",
+			filter:   &Filter{Field: "sku", Args: []string{"AB"}},
+			column:   "`test_models`.`sku`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`test_models`.`sku` LIKE ?", Vars: []any{"AB%"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "multiple",
+			op:       "$startsany",
+			filter:   &Filter{Field: "sku", Args: []string{"AB", "C_D", "AB"}},
+			column:   "`test_models`.`sku`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`test_models`.`sku` LIKE ? OR `test_models`.`sku` LIKE ?", Vars: []any{"AB%", "C\\_D%"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "enum",
+			op:       "$startsany",
+			filter:   &Filter{Field: "sku", Args: []string{"AB", "CD"}},
+			column:   "`test_models`.`sku`",
+			dataType: DataTypeEnum,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "CAST(`test_models`.`sku` AS TEXT) LIKE ? OR CAST(`test_models`.`sku` AS TEXT) LIKE ?", Vars: []any{"AB%", "CD%"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_int",
+			op:       "$startsany",
+			filter:   &Filter{Field: "sku", Args: []string{"1"}},
+			column:   "`test_models`.`sku`",
+			dataType: DataTypeInt64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_array",
+			op:       "$startsany",
+			filter:   &Filter{Field: "sku", Args: []string{"AB"}},
+			column:   "`test_models`.`sku`",
+			dataType: DataTypeTextArray,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			db = Operators[c.op].Function(db, c.filter, c.column, c.dataType)
+			assert.Equal(t, c.want, db.Statement.Clauses)
+		})
+	}
+}
+
 func TestEnds(t *testing.T) {
 	cases := []operatorTestCase{
 		{
@@ -745,6 +847,53 @@ func TestContains(t *testing.T) {
 	}
 }
 
+func TestSearch(t *testing.T) {
+	cases := []operatorTestCase{
+		{
+			desc:     "ok",
+			op:       "$search",
+			filter:   &Filter{Field: "name", Args: []string{"te%_st"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`test_models`.`name` LIKE ?", Vars: []any{"%te\\%\\_st%"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "not_text",
+			op:       "$search",
+			filter:   &Filter{Field: "name", Args: []string{"test"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeInt64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			db = Operators[c.op].Function(db, c.filter, c.column, c.dataType)
+			assert.Equal(t, c.want, db.Statement.Clauses)
+		})
+	}
+}
+
 func TestNotContains(t *testing.T) {
 	cases := []operatorTestCase{
 		{
@@ -1101,6 +1250,43 @@ func TestBetween(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:     "multiple_ranges",
+			op:       "$between",
+			filter:   &Filter{Field: "age", Args: []string{"18", "25", "40", "50", "60"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeUint64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{
+								SQL:  "`test_models`.`age` BETWEEN ? AND ? OR `test_models`.`age` BETWEEN ? AND ?",
+								Vars: []any{uint64(18), uint64(25), uint64(40), uint64(50)},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "multiple_ranges_cannot_convert",
+			op:       "$between",
+			filter:   &Filter{Field: "age", Args: []string{"18", "25", "40", "val"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeUint64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
 		{
 			desc:     "cannot_compare_array",
 			op:       "$between",
@@ -1146,31 +1332,82 @@ func TestBetween(t *testing.T) {
 	}
 }
 
-func TestIsTrue(t *testing.T) {
+func TestDay(t *testing.T) {
 	cases := []operatorTestCase{
 		{
-			desc:     "ok",
-			op:       "$istrue",
-			filter:   &Filter{Field: "is_active"},
-			column:   "`test_models`.`is_active`",
-			dataType: DataTypeBool,
+			desc:     "ok_date",
+			op:       "$day",
+			filter:   &Filter{Field: "birthday", Args: []string{"2023-04-30"}},
+			column:   "`test_models`.`birthday`",
+			dataType: DataTypeTime,
 			want: map[string]clause.Clause{
 				"WHERE": {
 					Name: "WHERE",
 					Expression: clause.Where{
 						Exprs: []clause.Expression{
-							clause.Expr{SQL: "`test_models`.`is_active` IS TRUE"},
+							clause.Expr{SQL: "`test_models`.`birthday` >= ? AND `test_models`.`birthday` < ?", Vars: []any{"2023-04-30", "2023-05-01"}},
 						},
 					},
 				},
 			},
 		},
 		{
-			desc:     "cannot_use_with_int",
-			op:       "$istrue",
-			filter:   &Filter{Field: "is_active"},
-			column:   "`test_models`.`is_active`",
-			dataType: DataTypeInt64,
+			desc:     "ok_datetime",
+			op:       "$day",
+			filter:   &Filter{Field: "birthday", Args: []string{"2023-12-31 12:30:00"}},
+			column:   "`test_models`.`birthday`",
+			dataType: DataTypeTime,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`test_models`.`birthday` >= ? AND `test_models`.`birthday` < ?", Vars: []any{"2023-12-31", "2024-01-01"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "invalid_time",
+			op:       "$day",
+			filter:   &Filter{Field: "birthday", Args: []string{"val"}},
+			column:   "`test_models`.`birthday`",
+			dataType: DataTypeTime,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "not_time",
+			op:       "$day",
+			filter:   &Filter{Field: "birthday", Args: []string{"2023-04-30"}},
+			column:   "`test_models`.`birthday`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_compare_array",
+			op:       "$day",
+			filter:   &Filter{Field: "birthday", Args: []string{"2023-04-30"}},
+			column:   "`test_models`.`birthday`",
+			dataType: DataTypeTimeArray,
 			want: map[string]clause.Clause{
 				"WHERE": {
 					Name: "WHERE",
@@ -1193,30 +1430,64 @@ func TestIsTrue(t *testing.T) {
 	}
 }
 
-func TestIsFalse(t *testing.T) {
+func TestMod(t *testing.T) {
 	cases := []operatorTestCase{
 		{
 			desc:     "ok",
-			op:       "$isfalse",
-			filter:   &Filter{Field: "is_active"},
-			column:   "`test_models`.`is_active`",
-			dataType: DataTypeBool,
+			op:       "$mod",
+			filter:   &Filter{Field: "age", Args: []string{"10", "0"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeInt64,
 			want: map[string]clause.Clause{
 				"WHERE": {
 					Name: "WHERE",
 					Expression: clause.Where{
 						Exprs: []clause.Expression{
-							clause.Expr{SQL: "`test_models`.`is_active` IS FALSE"},
+							clause.Expr{SQL: "`test_models`.`age` % ? = ?", Vars: []any{int64(10), int64(0)}},
 						},
 					},
 				},
 			},
 		},
 		{
-			desc:     "cannot_use_with_int",
-			op:       "$isfalse",
-			filter:   &Filter{Field: "is_active"},
-			column:   "`test_models`.`is_active`",
+			desc:     "ok_uint",
+			op:       "$mod",
+			filter:   &Filter{Field: "age", Args: []string{"3", "2"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeUint8,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`test_models`.`age` % ? = ?", Vars: []any{int64(3), int64(2)}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "ok_negative",
+			op:       "$mod",
+			filter:   &Filter{Field: "age", Args: []string{"-3", "-1"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeInt32,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`test_models`.`age` % ? = ?", Vars: []any{int64(-3), int64(-1)}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "division_by_zero",
+			op:       "$mod",
+			filter:   &Filter{Field: "age", Args: []string{"0", "0"}},
+			column:   "`test_models`.`age`",
 			dataType: DataTypeInt64,
 			want: map[string]clause.Clause{
 				"WHERE": {
@@ -1229,13 +1500,624 @@ func TestIsFalse(t *testing.T) {
 				},
 			},
 		},
-	}
-
-	for _, c := range cases {
-		t.Run(c.desc, func(t *testing.T) {
-			db := openDryRunDB(t)
-			db = Operators[c.op].Function(db, c.filter, c.column, c.dataType)
-			assert.Equal(t, c.want, db.Statement.Clauses)
-		})
-	}
+		{
+			desc:     "cannot_convert_divisor",
+			op:       "$mod",
+			filter:   &Filter{Field: "age", Args: []string{"1.5", "0"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeInt64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_convert_remainder",
+			op:       "$mod",
+			filter:   &Filter{Field: "age", Args: []string{"10", "a"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeInt64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "overflow",
+			op:       "$mod",
+			filter:   &Filter{Field: "age", Args: []string{"9223372036854775808", "0"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeUint64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_float",
+			op:       "$mod",
+			filter:   &Filter{Field: "age", Args: []string{"10", "0"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeFloat64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_array",
+			op:       "$mod",
+			filter:   &Filter{Field: "age", Args: []string{"10", "0"}},
+			column:   "`test_models`.`age`",
+			dataType: DataTypeInt64Array,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			db = Operators[c.op].Function(db, c.filter, c.column, c.dataType)
+			assert.Equal(t, c.want, db.Statement.Clauses)
+		})
+	}
+}
+
+func TestBitAnd(t *testing.T) {
+	cases := []operatorTestCase{
+		{
+			desc:     "ok",
+			op:       "$bitand",
+			filter:   &Filter{Field: "flags", Args: []string{"4"}},
+			column:   "`test_models`.`flags`",
+			dataType: DataTypeInt64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "(`test_models`.`flags` & ?) <> 0", Vars: []any{int64(4)}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "ok_uint",
+			op:       "$bitand",
+			filter:   &Filter{Field: "flags", Args: []string{"6"}},
+			column:   "`test_models`.`flags`",
+			dataType: DataTypeUint32,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "(`test_models`.`flags` & ?) <> 0", Vars: []any{uint64(6)}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "out_of_range",
+			op:       "$bitand",
+			filter:   &Filter{Field: "flags", Args: []string{"256"}},
+			column:   "`test_models`.`flags`",
+			dataType: DataTypeUint8,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_convert",
+			op:       "$bitand",
+			filter:   &Filter{Field: "flags", Args: []string{"a"}},
+			column:   "`test_models`.`flags`",
+			dataType: DataTypeInt64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_text",
+			op:       "$bitand",
+			filter:   &Filter{Field: "flags", Args: []string{"4"}},
+			column:   "`test_models`.`flags`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_array",
+			op:       "$bitand",
+			filter:   &Filter{Field: "flags", Args: []string{"4"}},
+			column:   "`test_models`.`flags`",
+			dataType: DataTypeInt64Array,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			db = Operators[c.op].Function(db, c.filter, c.column, c.dataType)
+			assert.Equal(t, c.want, db.Statement.Clauses)
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	cases := []operatorTestCase{
+		{
+			desc:     "greater_than",
+			op:       "$lengt",
+			filter:   &Filter{Field: "name", Args: []string{"3"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "LENGTH(`test_models`.`name`) > ?", Vars: []any{uint64(3)}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "lower_than",
+			op:       "$lenlt",
+			filter:   &Filter{Field: "name", Args: []string{"3"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "LENGTH(`test_models`.`name`) < ?", Vars: []any{uint64(3)}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "equals",
+			op:       "$leneq",
+			filter:   &Filter{Field: "name", Args: []string{"0"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "LENGTH(`test_models`.`name`) = ?", Vars: []any{uint64(0)}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "enum",
+			op:       "$leneq",
+			filter:   &Filter{Field: "name", Args: []string{"2"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeEnum,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "LENGTH(CAST(`test_models`.`name` AS TEXT)) = ?", Vars: []any{uint64(2)}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "negative",
+			op:       "$lengt",
+			filter:   &Filter{Field: "name", Args: []string{"-1"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_convert",
+			op:       "$lengt",
+			filter:   &Filter{Field: "name", Args: []string{"a"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeText,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_int",
+			op:       "$lengt",
+			filter:   &Filter{Field: "name", Args: []string{"3"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeInt64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_array",
+			op:       "$lengt",
+			filter:   &Filter{Field: "name", Args: []string{"3"}},
+			column:   "`test_models`.`name`",
+			dataType: DataTypeTextArray,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			db = Operators[c.op].Function(db, c.filter, c.column, c.dataType)
+			assert.Equal(t, c.want, db.Statement.Clauses)
+		})
+	}
+}
+
+func TestIsTrue(t *testing.T) {
+	cases := []operatorTestCase{
+		{
+			desc:     "ok",
+			op:       "$istrue",
+			filter:   &Filter{Field: "is_active"},
+			column:   "`test_models`.`is_active`",
+			dataType: DataTypeBool,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`test_models`.`is_active` IS TRUE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_int",
+			op:       "$istrue",
+			filter:   &Filter{Field: "is_active"},
+			column:   "`test_models`.`is_active`",
+			dataType: DataTypeInt64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			db = Operators[c.op].Function(db, c.filter, c.column, c.dataType)
+			assert.Equal(t, c.want, db.Statement.Clauses)
+		})
+	}
+}
+
+func TestIsFalse(t *testing.T) {
+	cases := []operatorTestCase{
+		{
+			desc:     "ok",
+			op:       "$isfalse",
+			filter:   &Filter{Field: "is_active"},
+			column:   "`test_models`.`is_active`",
+			dataType: DataTypeBool,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`test_models`.`is_active` IS FALSE"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc:     "cannot_use_with_int",
+			op:       "$isfalse",
+			filter:   &Filter{Field: "is_active"},
+			column:   "`test_models`.`is_active`",
+			dataType: DataTypeInt64,
+			want: map[string]clause.Clause{
+				"WHERE": {
+					Name: "WHERE",
+					Expression: clause.Where{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "FALSE"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			db = Operators[c.op].Function(db, c.filter, c.column, c.dataType)
+			assert.Equal(t, c.want, db.Statement.Clauses)
+		})
+	}
+}
+
+func TestOperatorName(t *testing.T) {
+	name, ok := operatorName(Operators["$cont"])
+	assert.True(t, ok)
+	assert.Equal(t, "$cont", name)
+
+	name, ok = operatorName(&Operator{})
+	assert.False(t, ok)
+	assert.Empty(t, name)
+
+	Operators["$zalias"] = Operators["$eq"]
+	Operators["$aalias"] = Operators["$eq"]
+	t.Cleanup(func() {
+		delete(Operators, "$zalias")
+		delete(Operators, "$aalias")
+	})
+	name, ok = operatorName(Operators["$eq"])
+	assert.True(t, ok)
+	assert.Equal(t, "$aalias", name)
+}
+
+func TestCaseInsensitiveOperators(t *testing.T) {
+	cases := []struct {
+		desc     string
+		dialect  string
+		op       string
+		arg      string
+		want     clause.Expr
+		dataType DataType
+	}{
+		{desc: "default_eq", dialect: "sqlite", op: "$eq", arg: "a_", dataType: DataTypeText, want: clause.Expr{SQL: "LOWER(`name`) = LOWER(?)", Vars: []any{"a_"}}},
+		{desc: "default_cont", dialect: "sqlite", op: "$cont", arg: "a_", dataType: DataTypeText, want: clause.Expr{SQL: "LOWER(`name`) LIKE LOWER(?)", Vars: []any{"%a\\_%"}}},
+		{desc: "default_starts_enum", dialect: "sqlite", op: "$starts", arg: "a", dataType: DataTypeEnum, want: clause.Expr{SQL: "LOWER(CAST(`name` AS TEXT)) LIKE LOWER(?)", Vars: []any{"a%"}}},
+		{desc: "default_ends", dialect: "sqlite", op: "$ends", arg: "a", dataType: DataTypeText, want: clause.Expr{SQL: "LOWER(`name`) LIKE LOWER(?)", Vars: []any{"%a"}}},
+		{desc: "default_eq_int", dialect: "sqlite", op: "$eq", arg: "1", dataType: DataTypeInt64, want: clause.Expr{SQL: "`name` = ?", Vars: []any{int64(1)}}},
+		{desc: "postgres_eq", dialect: "postgres", op: "$eq", arg: "a_", dataType: DataTypeText, want: clause.Expr{SQL: "`name` ILIKE ?", Vars: []any{"a\\_"}}},
+		{desc: "postgres_cont_enum", dialect: "postgres", op: "$cont", arg: "a", dataType: DataTypeEnum, want: clause.Expr{SQL: "CAST(`name` AS TEXT) ILIKE ?", Vars: []any{"%a%"}}},
+		{desc: "default_startsany", dialect: "sqlite", op: "$startsany", arg: "a_", dataType: DataTypeText, want: clause.Expr{SQL: "LOWER(`name`) LIKE LOWER(?)", Vars: []any{"a\\_%"}}},
+		{desc: "postgres_startsany", dialect: "postgres", op: "$startsany", arg: "a", dataType: DataTypeText, want: clause.Expr{SQL: "`name` ILIKE ?", Vars: []any{"a%"}}},
+		{desc: "sqlserver_cont", dialect: "sqlserver", op: "$cont", arg: "a", dataType: DataTypeText, want: clause.Expr{SQL: "LOWER(`name`) LIKE LOWER(?) ESCAPE '\\'", Vars: []any{"%a%"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			dryRunDB := openDryRunDB(t)
+			db, err := gorm.Open(numberedBindVarDialector{Dialector: dryRunDB.Dialector, name: c.dialect, prefix: "$"}, &gorm.Config{DryRun: true})
+			require.NoError(t, err)
+
+			operator := caseInsensitiveOperator(Operators[c.op])
+			require.NotSame(t, Operators[c.op], operator)
+			filter := &Filter{Field: "name", Operator: operator, Args: []string{c.arg}}
+			db = operator.Function(db, filter, "`name`", c.dataType)
+			assert.Equal(t, clause.Where{Exprs: []clause.Expression{c.want}}, db.Statement.Clauses["WHERE"].Expression)
+		})
+	}
+
+	assert.Same(t, Operators["$gt"], caseInsensitiveOperator(Operators["$gt"]))
+}
+
+func TestOperatorScope(t *testing.T) {
+	// Filters the models having at least the given number of relations
+	operator := &Operator{
+		Function: func(tx *gorm.DB, _ *Filter, _ string, _ DataType) *gorm.DB {
+			return tx
+		},
+		Scope: func(tx *gorm.DB, filter *Filter, column string, _ DataType) *gorm.DB {
+			return tx.Joins("LEFT JOIN `filter_test_relations` `Relations` ON `Relations`.`parent_id` = "+column).
+				Group(column).
+				Having("COUNT(`Relations`.`id`) >= ?", filter.Args[0])
+		},
+		RequiredArguments: 1,
+	}
+	filter := &Filter{Field: "id", Operator: operator, Args: []string{"2"}}
+
+	db := openDryRunDB(t)
+	results := []*FilterTestModel{}
+	schema, err := parseModel(db, &results)
+	require.NoError(t, err)
+
+	joinScope, conditionScope := filter.Scope(Blacklist{}, schema)
+	db = db.Model(&results).Scopes(joinScope, conditionScope).Find(&results)
+	require.NoError(t, db.Error)
+	assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` LEFT JOIN `filter_test_relations` `Relations` ON `Relations`.`parent_id` = `filter_test_models`.`id` GROUP BY `filter_test_models`.`id` HAVING COUNT(`Relations`.`id`) >= ?", db.Statement.SQL.String())
+
+	t.Run("settings", func(t *testing.T) {
+		request := &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}}, filter})}
+		results := []*FilterTestModel{}
+		db := (&Settings[*FilterTestModel]{}).ScopeUnpaginated(openDryRunDB(t), request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` LEFT JOIN `filter_test_relations` `Relations` ON `Relations`.`parent_id` = `filter_test_models`.`id` WHERE `filter_test_models`.`name` = ? GROUP BY `filter_test_models`.`id` HAVING COUNT(`Relations`.`id`) >= ?", db.Statement.SQL.String())
+	})
+}
+
+func TestOperatorAliases(t *testing.T) {
+	OperatorAliases["$contains"] = "$cont"
+	OperatorAliases["$broken"] = "$notanoperator"
+	t.Cleanup(func() {
+		delete(OperatorAliases, "$contains")
+		delete(OperatorAliases, "$broken")
+	})
+
+	f, err := ParseFilter("name||$contains||val")
+	require.NoError(t, err)
+	assert.Equal(t, Operators["$cont"], f.Operator)
+
+	data, err := json.Marshal(f)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"field":"name","operator":"$cont","args":["val"],"or":false}`, string(data))
+
+	unmarshaled := &Filter{}
+	require.NoError(t, json.Unmarshal([]byte(`{"field":"name","operator":"$contains","args":["val"]}`), unmarshaled))
+	assert.Equal(t, Operators["$cont"], unmarshaled.Operator)
+
+	_, err = ParseFilter("name||$broken||val")
+	require.EqualError(t, err, `unknown operator: "$broken"`)
+}
+
+func TestDeprecatedOperators(t *testing.T) {
+	OperatorAliases["$contains"] = "$cont"
+	DeprecatedOperators["$contains"] = "use $cont instead"
+	DeprecatedOperators["$ne"] = ""
+	t.Cleanup(func() {
+		delete(OperatorAliases, "$contains")
+		delete(DeprecatedOperators, "$contains")
+		delete(DeprecatedOperators, "$ne")
+	})
+
+	parse := func(filter string) *Filter {
+		f, err := ParseFilter(filter)
+		require.NoError(t, err)
+		return f
+	}
+
+	message, ok := deprecationMessage(parse("name||$contains||val"))
+	assert.True(t, ok)
+	assert.Equal(t, `operator "$contains" is deprecated: use $cont instead`, message)
+
+	message, ok = deprecationMessage(parse("name||$ne||val"))
+	assert.True(t, ok)
+	assert.Equal(t, `operator "$ne" is deprecated`, message)
+
+	_, ok = deprecationMessage(parse("name||$cont||val"))
+	assert.False(t, ok)
+
+	request := &Request{Filter: typeutil.NewUndefined([]*Filter{parse("name||$contains||val")})}
+	issues, err := (&Settings[*FilterTestModel]{}).Check(openDryRunDB(t), request)
+	require.NoError(t, err)
+	assert.Equal(t, []*Issue{{Param: "filter", Value: "name", Message: `operator "$contains" is deprecated: use $cont instead`}}, issues)
+
+	t.Run("setting", func(t *testing.T) {
+		request := &Request{
+			Filter: typeutil.NewUndefined([]*Filter{parse("name||$contains||a"), parse("name||$contains||b")}),
+			Or:     typeutil.NewUndefined([]*Filter{parse("name||$ne||c")}),
+		}
+		results := []*FilterTestModel{}
+		paginator, err := (&Settings[*FilterTestModel]{}).Scope(openDryRunDB(t), request, &results)
+		require.NoError(t, err)
+		deprecations, ok := paginator.DB.Get(DeprecationsSetting)
+		require.True(t, ok)
+		assert.Equal(t, []string{`operator "$contains" is deprecated: use $cont instead`, `operator "$ne" is deprecated`}, deprecations)
+
+		request = &Request{Filter: typeutil.NewUndefined([]*Filter{parse("name||$cont||a")})}
+		paginator, err = (&Settings[*FilterTestModel]{}).Scope(openDryRunDB(t), request, &results)
+		require.NoError(t, err)
+		_, ok = paginator.DB.Get(DeprecationsSetting)
+		assert.False(t, ok)
+	})
 }
@@ -0,0 +1,97 @@
+package filter
+
+import (
+	"slices"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm/schema"
+)
+
+// optimizeFilters returns a group of filters equivalent to the given group but producing
+// a smaller query. The group is only rewritten if its filters are all combined the same
+// way (all ANDed or all ORed), so their order doesn't matter:
+//   - identical filters are removed
+//   - if the filters are ORed, the "$eq" and "$in" filters on the same field are merged
+//     into a single "$in" filter, placed where the first of them was.
+//
+// The "$eq" and "$in" filters having an argument that cannot be converted to the data type
+// of the field are not merged because the "$in" filter would be false for all the values.
+func optimizeFilters(filters []*Filter, sch *schema.Schema, blacklist *Blacklist) []*Filter {
+	if len(filters) < 2 {
+		return filters
+	}
+	or := filters[0].Or
+	if lo.SomeBy(filters, func(f *Filter) bool { return f.Or != or }) {
+		return filters
+	}
+
+	optimized := make([]*Filter, 0, len(filters))
+	for _, f := range filters {
+		duplicate := slices.ContainsFunc(optimized, func(o *Filter) bool {
+			return o.Field == f.Field && o.Operator == f.Operator && slices.Equal(o.Args, f.Args)
+		})
+		if !duplicate {
+			optimized = append(optimized, f)
+		}
+	}
+	if !or {
+		return optimized
+	}
+
+	mergeable := map[string][]int{}
+	for i, f := range optimized {
+		if _, ok := inArgs(f, sch, blacklist); ok {
+			mergeable[f.Field] = append(mergeable[f.Field], i)
+		}
+	}
+	removed := make([]bool, len(optimized))
+	for _, indexes := range mergeable {
+		if len(indexes) < 2 {
+			continue
+		}
+		first := optimized[indexes[0]]
+		merged := &Filter{Field: first.Field, Operator: Operators["$in"], Or: first.Or}
+		for _, i := range indexes {
+			args, _ := inArgs(optimized[i], sch, blacklist)
+			merged.Args = append(merged.Args, args...)
+			removed[i] = true
+		}
+		merged.Args = lo.Uniq(merged.Args)
+		optimized[indexes[0]] = merged
+		removed[indexes[0]] = false
+	}
+
+	result := make([]*Filter, 0, len(optimized))
+	for i, f := range optimized {
+		if !removed[i] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// inArgs returns the values the given filter compares its field to if it is a "$eq"
+// or "$in" filter that can be merged into a "$in" filter.
+func inArgs(f *Filter, sch *schema.Schema, blacklist *Blacklist) ([]string, bool) {
+	var args []string
+	switch {
+	case f.Operator == Operators["$eq"] && len(f.Args) > 0:
+		args = f.Args[:1]
+	case f.Operator == Operators["$in"] && len(f.Args) > 0:
+		args = f.Args
+	default:
+		return nil, false
+	}
+	field, _, _ := getField(f.Field, sch, blacklist)
+	if field == nil {
+		return nil, false
+	}
+	dataType := getDataType(field)
+	if dataType == DataTypeUnsupported || dataType.IsArray() {
+		return nil, false
+	}
+	if _, ok := ConvertArgsToSafeType(args, dataType); !ok {
+		return nil, false
+	}
+	return args, true
+}
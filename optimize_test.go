@@ -0,0 +1,103 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+func TestOptimizeFilters(t *testing.T) {
+	db := openDryRunDB(t)
+	sch, err := parseModel(db, &FilterTestModel{})
+	require.NoError(t, err)
+
+	eq := Operators["$eq"]
+	in := Operators["$in"]
+	cont := Operators["$cont"]
+
+	cases := []struct {
+		desc    string
+		filters []*Filter
+		want    []*Filter
+	}{
+		{
+			desc:    "single",
+			filters: []*Filter{{Field: "name", Operator: eq, Args: []string{"a"}, Or: true}},
+			want:    []*Filter{{Field: "name", Operator: eq, Args: []string{"a"}, Or: true}},
+		},
+		{
+			desc: "merge_or",
+			filters: []*Filter{
+				{Field: "name", Operator: eq, Args: []string{"a"}, Or: true},
+				{Field: "id", Operator: eq, Args: []string{"1"}, Or: true},
+				{Field: "name", Operator: in, Args: []string{"b", "a"}, Or: true},
+				{Field: "name", Operator: eq, Args: []string{"c", "ignored"}, Or: true},
+				{Field: "name", Operator: cont, Args: []string{"d"}, Or: true},
+			},
+			want: []*Filter{
+				{Field: "name", Operator: in, Args: []string{"a", "b", "c"}, Or: true},
+				{Field: "id", Operator: eq, Args: []string{"1"}, Or: true},
+				{Field: "name", Operator: cont, Args: []string{"d"}, Or: true},
+			},
+		},
+		{
+			desc: "not_convertible",
+			filters: []*Filter{
+				{Field: "id", Operator: eq, Args: []string{"1"}, Or: true},
+				{Field: "id", Operator: eq, Args: []string{"a"}, Or: true},
+			},
+			want: []*Filter{
+				{Field: "id", Operator: eq, Args: []string{"1"}, Or: true},
+				{Field: "id", Operator: eq, Args: []string{"a"}, Or: true},
+			},
+		},
+		{
+			desc: "duplicates_and",
+			filters: []*Filter{
+				{Field: "name", Operator: eq, Args: []string{"a"}},
+				{Field: "name", Operator: eq, Args: []string{"b"}},
+				{Field: "name", Operator: eq, Args: []string{"a"}},
+			},
+			want: []*Filter{
+				{Field: "name", Operator: eq, Args: []string{"a"}},
+				{Field: "name", Operator: eq, Args: []string{"b"}},
+			},
+		},
+		{
+			desc: "mixed",
+			filters: []*Filter{
+				{Field: "name", Operator: eq, Args: []string{"a"}},
+				{Field: "name", Operator: eq, Args: []string{"b"}, Or: true},
+				{Field: "name", Operator: eq, Args: []string{"a"}, Or: true},
+			},
+			want: []*Filter{
+				{Field: "name", Operator: eq, Args: []string{"a"}},
+				{Field: "name", Operator: eq, Args: []string{"b"}, Or: true},
+				{Field: "name", Operator: eq, Args: []string{"a"}, Or: true},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.want, optimizeFilters(c.filters, sch, &Blacklist{}))
+		})
+	}
+}
+
+func TestScopeOptimizeFilters(t *testing.T) {
+	request := &Request{
+		Or: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}, Or: true},
+			{Field: "name", Operator: Operators["$eq"], Args: []string{"b"}, Or: true},
+			{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}, Or: true},
+		}),
+	}
+	results := []*FilterTestModel{}
+	db := (&Settings[*FilterTestModel]{OptimizeFilters: true}).ScopeUnpaginated(openDryRunDB(t), request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` WHERE `filter_test_models`.`name` IN (?,?)", db.Statement.SQL.String())
+	assert.Equal(t, []any{"a", "b"}, db.Statement.Vars)
+}
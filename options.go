@@ -0,0 +1,163 @@
+package filter
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// Option a functional option configuring `Settings`. See `NewSettings()`.
+type Option[T any] func(*Settings[T])
+
+// NewSettings creates new settings configured using the given options.
+//
+//	settings := filter.NewSettings(
+//		filter.WithFieldsSearch[*model.User]("name", "email"),
+//		filter.WithDefaultSort[*model.User](&filter.Sort{Field: "name", Order: filter.SortAscending}),
+//		filter.WithDisableJoin[*model.User](true),
+//	)
+func NewSettings[T any](opts ...Option[T]) *Settings[T] {
+	s := &Settings[T]{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// With returns a clone of the settings (see `Clone()`) configured using the given
+// options. The original settings are not modified, so this is safe to use on settings
+// shared by concurrent requests to derive the settings of an endpoint variant.
+func (s *Settings[T]) With(opts ...Option[T]) *Settings[T] {
+	clone := s.Clone()
+	for _, opt := range opts {
+		opt(clone)
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the settings: the slices, maps, sorts, blacklists, virtual
+// relations and computed filters can be modified without affecting the original settings.
+// The operators, the models of the virtual relations, the query logger and the selectivity
+// hinter are shared.
+func (s *Settings[T]) Clone() *Settings[T] {
+	clone := *s
+	if s.DefaultSort != nil {
+		clone.DefaultSort = make([]*Sort, 0, len(s.DefaultSort))
+		for _, sort := range s.DefaultSort {
+			clone.DefaultSort = append(clone.DefaultSort, &Sort{Field: sort.Field, Order: sort.Order})
+		}
+	}
+	clone.FieldsSearch = slices.Clone(s.FieldsSearch)
+	clone.SearchOperators = maps.Clone(s.SearchOperators)
+	clone.DistinctOn = slices.Clone(s.DistinctOn)
+	clone.SubqueryRelations = maps.Clone(s.SubqueryRelations)
+	clone.FieldMaxArgLength = maps.Clone(s.FieldMaxArgLength)
+	clone.QueryLogHashKey = slices.Clone(s.QueryLogHashKey)
+	clone.SensitiveFields = slices.Clone(s.SensitiveFields)
+	clone.Blacklist = *s.Blacklist.Clone()
+	if s.VirtualRelations != nil {
+		clone.VirtualRelations = make(map[string]*VirtualRelation, len(s.VirtualRelations))
+		for name, relation := range s.VirtualRelations {
+			r := *relation
+			clone.VirtualRelations[name] = &r
+		}
+	}
+	if s.ComputedFilters != nil {
+		clone.ComputedFilters = make(map[string]*ComputedFilter, len(s.ComputedFilters))
+		for name, filter := range s.ComputedFilters {
+			f := *filter
+			clone.ComputedFilters[name] = &f
+		}
+	}
+	if hints, ok := s.SelectivityHints.(SelectivityHints); ok {
+		clone.SelectivityHints = maps.Clone(hints)
+	}
+	return &clone
+}
+
+// WithDefaultSort sets `Settings.DefaultSort`.
+func WithDefaultSort[T any](sorts ...*Sort) Option[T] {
+	return func(s *Settings[T]) {
+		s.DefaultSort = sorts
+	}
+}
+
+// WithFieldsSearch sets `Settings.FieldsSearch`.
+func WithFieldsSearch[T any](fields ...string) Option[T] {
+	return func(s *Settings[T]) {
+		s.FieldsSearch = fields
+	}
+}
+
+// WithSearchOperator sets `Settings.SearchOperator`.
+func WithSearchOperator[T any](operator *Operator) Option[T] {
+	return func(s *Settings[T]) {
+		s.SearchOperator = operator
+	}
+}
+
+// WithBlacklist sets `Settings.Blacklist`.
+func WithBlacklist[T any](blacklist Blacklist) Option[T] {
+	return func(s *Settings[T]) {
+		s.Blacklist = blacklist
+	}
+}
+
+// WithDisableFields sets `Settings.DisableFields`.
+func WithDisableFields[T any](disable bool) Option[T] {
+	return func(s *Settings[T]) {
+		s.DisableFields = disable
+	}
+}
+
+// WithDisableFilter sets `Settings.DisableFilter`.
+func WithDisableFilter[T any](disable bool) Option[T] {
+	return func(s *Settings[T]) {
+		s.DisableFilter = disable
+	}
+}
+
+// WithDisableSort sets `Settings.DisableSort`.
+func WithDisableSort[T any](disable bool) Option[T] {
+	return func(s *Settings[T]) {
+		s.DisableSort = disable
+	}
+}
+
+// WithDisableJoin sets `Settings.DisableJoin`.
+func WithDisableJoin[T any](disable bool) Option[T] {
+	return func(s *Settings[T]) {
+		s.DisableJoin = disable
+	}
+}
+
+// WithDisableSearch sets `Settings.DisableSearch`.
+func WithDisableSearch[T any](disable bool) Option[T] {
+	return func(s *Settings[T]) {
+		s.DisableSearch = disable
+	}
+}
+
+// WithMaxJoins sets `Settings.MaxJoins`.
+func WithMaxJoins[T any](maxJoins int) Option[T] {
+	return func(s *Settings[T]) {
+		s.MaxJoins = maxJoins
+	}
+}
+
+// WithVirtualRelation adds a virtual relation identified by the given name to `Settings.VirtualRelations`.
+func WithVirtualRelation[T any](name string, relation *VirtualRelation) Option[T] {
+	return func(s *Settings[T]) {
+		if s.VirtualRelations == nil {
+			s.VirtualRelations = map[string]*VirtualRelation{}
+		}
+		s.VirtualRelations[name] = relation
+	}
+}
+
+// WithQueryLogger sets `Settings.QueryLogger`.
+func WithQueryLogger[T any](logger *slog.Logger) Option[T] {
+	return func(s *Settings[T]) {
+		s.QueryLogger = logger
+	}
+}
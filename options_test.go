@@ -0,0 +1,110 @@
+package filter
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSettings(t *testing.T) {
+	logger := slog.Default()
+	relation := &VirtualRelation{Model: &FilterTestRelation{}, ForeignKey: "id", References: "parent_id"}
+	sort := &Sort{Field: "name", Order: SortAscending}
+	settings := NewSettings(
+		WithDefaultSort[*FilterTestModel](sort),
+		WithFieldsSearch[*FilterTestModel]("name", "email"),
+		WithSearchOperator[*FilterTestModel](Operators["$eq"]),
+		WithBlacklist[*FilterTestModel](Blacklist{FieldsBlacklist: []string{"id"}}),
+		WithDisableFields[*FilterTestModel](true),
+		WithDisableFilter[*FilterTestModel](true),
+		WithDisableSort[*FilterTestModel](true),
+		WithDisableJoin[*FilterTestModel](true),
+		WithDisableSearch[*FilterTestModel](true),
+		WithMaxJoins[*FilterTestModel](3),
+		WithVirtualRelation[*FilterTestModel]("Stats", relation),
+		WithQueryLogger[*FilterTestModel](logger),
+	)
+
+	expected := &Settings[*FilterTestModel]{
+		DefaultSort:      []*Sort{sort},
+		FieldsSearch:     []string{"name", "email"},
+		SearchOperator:   Operators["$eq"],
+		Blacklist:        Blacklist{FieldsBlacklist: []string{"id"}},
+		DisableFields:    true,
+		DisableFilter:    true,
+		DisableSort:      true,
+		DisableJoin:      true,
+		DisableSearch:    true,
+		MaxJoins:         3,
+		VirtualRelations: map[string]*VirtualRelation{"Stats": relation},
+		QueryLogger:      logger,
+	}
+	assert.Equal(t, expected, settings)
+	assert.Equal(t, &Settings[*FilterTestModel]{}, NewSettings[*FilterTestModel]())
+}
+
+func TestSettingsClone(t *testing.T) {
+	settings := &Settings[*FilterTestModel]{
+		DefaultSort:       []*Sort{{Field: "name", Order: SortAscending}},
+		FieldsSearch:      []string{"name"},
+		SearchOperators:   map[DataType]*Operator{DataTypeEnum: Operators["$eq"]},
+		DistinctOn:        []string{"name"},
+		SubqueryRelations: map[string]bool{"Relation": true},
+		FieldMaxArgLength: map[string]int{"name": 10},
+		Blacklist: Blacklist{
+			FieldsBlacklist: []string{"id"},
+			Relations: map[string]*Blacklist{
+				"Relation": {FieldsBlacklist: []string{"name"}},
+			},
+		},
+		VirtualRelations: map[string]*VirtualRelation{"Stats": {ForeignKey: "id"}},
+		SelectivityHints: SelectivityHints{"name": 0.5},
+		QueryLogHashKey:  []byte("key"),
+		SensitiveFields:  []string{"email"},
+		ComputedFilters:  map[string]*ComputedFilter{"distance": {SQL: "1", Type: DataTypeFloat64}},
+	}
+
+	clone := settings.Clone()
+	assert.Equal(t, settings, clone)
+
+	clone.DefaultSort[0].Order = SortDescending
+	clone.FieldsSearch[0] = "email"
+	clone.SearchOperators[DataTypeText] = Operators["$eq"]
+	clone.DistinctOn[0] = "email"
+	clone.SubqueryRelations["Relation"] = false
+	clone.FieldMaxArgLength["name"] = 20
+	clone.QueryLogHashKey[0] = 'K'
+	clone.SensitiveFields[0] = "name"
+	clone.ComputedFilters["distance"].SQL = "2"
+	clone.FieldsBlacklist[0] = "name"
+	clone.Relations["Relation"].FieldsBlacklist[0] = "id"
+	clone.Relations["Other"] = &Blacklist{}
+	clone.VirtualRelations["Stats"].ForeignKey = "name"
+	clone.SelectivityHints.(SelectivityHints)["name"] = 1
+
+	assert.Equal(t, SortAscending, settings.DefaultSort[0].Order)
+	assert.Equal(t, []string{"name"}, settings.FieldsSearch)
+	assert.Len(t, settings.SearchOperators, 1)
+	assert.Equal(t, []string{"name"}, settings.DistinctOn)
+	assert.Equal(t, map[string]bool{"Relation": true}, settings.SubqueryRelations)
+	assert.Equal(t, map[string]int{"name": 10}, settings.FieldMaxArgLength)
+	assert.Equal(t, []byte("key"), settings.QueryLogHashKey)
+	assert.Equal(t, []string{"email"}, settings.SensitiveFields)
+	assert.Equal(t, "1", settings.ComputedFilters["distance"].SQL)
+	assert.Equal(t, []string{"id"}, settings.FieldsBlacklist)
+	assert.Equal(t, []string{"name"}, settings.Relations["Relation"].FieldsBlacklist)
+	assert.NotContains(t, settings.Relations, "Other")
+	assert.Equal(t, "id", settings.VirtualRelations["Stats"].ForeignKey)
+	assert.Equal(t, SelectivityHints{"name": 0.5}, settings.SelectivityHints)
+
+	assert.Equal(t, &Settings[*FilterTestModel]{}, (&Settings[*FilterTestModel]{}).Clone())
+}
+
+func TestSettingsWith(t *testing.T) {
+	settings := &Settings[*FilterTestModel]{FieldsSearch: []string{"name"}}
+	variant := settings.With(WithDisableJoin[*FilterTestModel](true))
+
+	assert.Equal(t, &Settings[*FilterTestModel]{FieldsSearch: []string{"name"}, DisableJoin: true}, variant)
+	assert.False(t, settings.DisableJoin)
+}
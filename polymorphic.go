@@ -0,0 +1,141 @@
+package filter
+
+import (
+	"strings"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"goyave.dev/goyave/v5/database"
+	"goyave.dev/goyave/v5/util/errors"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+const (
+	// PolymorphicTypeColumn the name of the column identifying the model of each
+	// record of a polymorphic listing (see `ScopePolymorphic()`).
+	PolymorphicTypeColumn = "type"
+
+	// PolymorphicIDColumn the name of the column containing the primary key of each
+	// record of a polymorphic listing (see `ScopePolymorphic()`).
+	PolymorphicIDColumn = "id"
+)
+
+// PolymorphicModel a model included in a polymorphic listing. Use `NewPolymorphicModel()`
+// to create it.
+type PolymorphicModel struct {
+	subquery func(db *gorm.DB, request *Request, columns []string) (*gorm.DB, error)
+}
+
+// NewPolymorphicModel returns a model included in a polymorphic listing (see `ScopePolymorphic()`).
+// The records of the model are tagged with the given type. The given mapping associates the
+// columns of the listing with the fields of the model (e.g. "title" => "name"). The columns
+// that are not mapped, or mapped to a field that doesn't exist, is blacklisted or belongs
+// to a relation, are `NULL` for this model.
+//
+// The filters and search of the request are applied to the model using the given settings.
+// If settings is nil, the default settings are used.
+func NewPolymorphicModel[T any](typ string, settings *Settings[T], mapping map[string]string) *PolymorphicModel {
+	if settings == nil {
+		settings = &Settings[T]{}
+	}
+	return &PolymorphicModel{
+		subquery: func(db *gorm.DB, request *Request, columns []string) (*gorm.DB, error) {
+			return settings.polymorphicSubquery(db, request, typ, columns, mapping)
+		},
+	}
+}
+
+// ScopePolymorphic runs the same request on several models (e.g. a global search over users,
+// articles and comments) and returns a single paginated listing of the matching records.
+// Each model is queried in a subquery selecting the same normalized columns, and the
+// subqueries are combined using `UNION ALL`:
+//
+//	SELECT * FROM (SELECT 'user' AS type, id, ... UNION ALL SELECT 'article' AS type, id, ...) AS polymorphic
+//
+// Each record is a map containing the `PolymorphicTypeColumn` and `PolymorphicIDColumn` columns,
+// followed by the given columns. Only the sorts on these columns are applied. The records
+// are then sorted by type and id so the pagination is stable. The joins, fields and
+// "distinct_on" of the request are ignored. `per_page=all` is not supported: the error
+// wraps `ErrAllNotAllowed`.
+//
+// The columns of the different models sharing a name must have compatible types.
+// The given request is expected to be validated using `ApplyValidation`.
+func ScopePolymorphic(db *gorm.DB, request *Request, columns []string, models ...*PolymorphicModel) (*database.Paginator[map[string]any], error) {
+	page := request.Page.Default(1)
+	pageSize := request.PerPage.Default(DefaultPageSize)
+	if pageSize == PerPageAll {
+		return nil, errors.New(ErrAllNotAllowed)
+	}
+
+	var paginator *database.Paginator[map[string]any]
+	err := db.Transaction(func(tx *gorm.DB) error {
+		subqueries := make([]any, 0, len(models))
+		for _, m := range models {
+			subquery, err := m.subquery(tx, request, columns)
+			if err != nil {
+				return errors.New(err)
+			}
+			subqueries = append(subqueries, subquery)
+		}
+
+		union := strings.TrimSuffix(strings.Repeat("? UNION ALL ", len(subqueries)), " UNION ALL ")
+		tx = tx.Session(&gorm.Session{NewDB: true}).Table("("+union+") AS polymorphic", subqueries...)
+		allColumns := append([]string{PolymorphicTypeColumn, PolymorphicIDColumn}, columns...)
+		for _, sort := range request.Sort.Default(nil) {
+			if lo.Contains(allColumns, sort.Field) {
+				tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: sort.Field}, Desc: sort.Order == SortDescending})
+			}
+		}
+		tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: PolymorphicTypeColumn}}).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: PolymorphicIDColumn}})
+
+		records := []map[string]any{}
+		paginator = database.NewPaginator(tx, page, pageSize, &records)
+		if err := paginator.UpdatePageInfo(); err != nil {
+			return errors.New(err)
+		}
+		if err := paginator.Find(); err != nil {
+			return errors.New(err)
+		}
+		return nil
+	})
+
+	return paginator, err
+}
+
+// polymorphicSubquery returns the subquery applying the filters and search of the given
+// request to the model and selecting the normalized columns of a polymorphic listing.
+func (s *Settings[T]) polymorphicSubquery(db *gorm.DB, request *Request, typ string, columns []string, mapping map[string]string) (*gorm.DB, error) {
+	r := *request
+	r.Join = typeutil.Undefined[[]*Join]{}
+	r.Fields = typeutil.Undefined[[]string]{}
+	r.Sort = typeutil.Undefined[[]*Sort]{}
+	r.DistinctOn = typeutil.Undefined[[]string]{}
+	dest := []T{}
+	tx, sch, _ := s.scopeCommon(db.Session(&gorm.Session{}), &r, &dest)
+	if sch == nil {
+		return nil, tx.Error
+	}
+	if len(sch.PrimaryFields) != 1 {
+		return nil, ErrNoPrimaryKey
+	}
+
+	selects := make([]string, 0, len(columns)+2)
+	vars := make([]any, 0, len(columns)+3)
+	selects = append(selects, "? AS ?", "? AS ?")
+	vars = append(vars,
+		typ, clause.Column{Name: PolymorphicTypeColumn},
+		clause.Column{Table: sch.Table, Name: sch.PrimaryFields[0].DBName}, clause.Column{Name: PolymorphicIDColumn},
+	)
+	for _, column := range columns {
+		field, _, joinName := getField(mapping[column], sch, &s.Blacklist)
+		if field == nil || joinName != "" {
+			selects = append(selects, "NULL AS ?")
+		} else {
+			selects = append(selects, columnExpression(tx.Statement, sch.Table, field)+" AS ?")
+		}
+		vars = append(vars, clause.Column{Name: column})
+	}
+	return tx.Select(strings.Join(selects, ", "), vars...), nil
+}
@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type PolymorphicTestUser struct {
+	Name     string
+	Password string
+	ID       uint
+}
+
+type PolymorphicTestArticle struct {
+	Title string
+	Body  string
+	ID    uint
+}
+
+func TestScopePolymorphic(t *testing.T) {
+	db := openDryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Query().After("gorm:query").Register("test:queries", func(tx *gorm.DB) {
+		// The subqueries are also built using the query callbacks
+		if !strings.HasPrefix(tx.Statement.SQL.String(), "SELECT ?") {
+			queries = append(queries, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+		}
+	})
+	require.NoError(t, err)
+
+	request := &Request{
+		Search:  typeutil.NewUndefined("john"),
+		Sort:    typeutil.NewUndefined([]*Sort{{Field: "title", Order: SortDescending}, {Field: "password", Order: SortAscending}}),
+		Page:    typeutil.NewUndefined(2),
+		PerPage: typeutil.NewUndefined(10),
+		Join:    typeutil.NewUndefined([]*Join{{Relation: "Author"}}),
+	}
+	users := NewPolymorphicModel("user", &Settings[*PolymorphicTestUser]{
+		FieldsSearch: []string{"name"},
+		Blacklist:    Blacklist{FieldsBlacklist: []string{"password"}},
+	}, map[string]string{"title": "name", "body": "password"})
+	articles := NewPolymorphicModel[*PolymorphicTestArticle]("article", nil, map[string]string{"title": "title", "body": "body"})
+
+	paginator, err := ScopePolymorphic(db, request, []string{"title", "body"}, users, articles)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, paginator.CurrentPage)
+
+	union := "(SELECT \"user\" AS `type`, `polymorphic_test_users`.`id` AS `id`, `polymorphic_test_users`.`name` AS `title`, NULL AS `body` FROM `polymorphic_test_users` WHERE `polymorphic_test_users`.`name` LIKE \"%john%\" " +
+		"UNION ALL SELECT \"article\" AS `type`, `polymorphic_test_articles`.`id` AS `id`, `polymorphic_test_articles`.`title` AS `title`, `polymorphic_test_articles`.`body` AS `body` FROM `polymorphic_test_articles` WHERE `polymorphic_test_articles`.`title` LIKE \"%john%\" OR `polymorphic_test_articles`.`body` LIKE \"%john%\" OR FALSE) AS polymorphic"
+	assert.Equal(t, []string{
+		"SELECT count(*) FROM " + union,
+		"SELECT * FROM " + union + " ORDER BY `title` DESC,`type`,`id` LIMIT 10 OFFSET 10",
+	}, queries)
+}
+
+func TestScopePolymorphicError(t *testing.T) {
+	request := &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "title", Operator: Operators["$eq"], Args: []string{"too long"}}})}
+	articles := NewPolymorphicModel("article", &Settings[*PolymorphicTestArticle]{MaxArgLength: 3}, map[string]string{"title": "title"})
+
+	_, err := ScopePolymorphic(openDryRunDB(t), request, []string{"title"}, articles)
+	require.ErrorIs(t, err, ErrArgTooLong)
+}
+
+func TestScopePolymorphicPerPageAll(t *testing.T) {
+	request := &Request{PerPage: typeutil.NewUndefined(PerPageAll)}
+	articles := NewPolymorphicModel("article", &Settings[*PolymorphicTestArticle]{AllowAll: true}, map[string]string{"title": "title"})
+
+	_, err := ScopePolymorphic(openDryRunDB(t), request, []string{"title"}, articles)
+	require.ErrorIs(t, err, ErrAllNotAllowed)
+}
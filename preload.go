@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// preloadLimitScope returns the scope limiting the number of records loaded by each
+// "has many" and "many to many" preload of the statement to `limit + 1`, so exceeding
+// the limit can be detected by `checkPreloadRows()` without loading all the records.
+// The scope must be applied after the scopes adding the preloads.
+func preloadLimitScope(limit int, sch *schema.Schema) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		for name, conds := range tx.Statement.Preloads {
+			if !isToManyPreload(name, sch) {
+				continue
+			}
+			tx.Statement.Preloads[name] = append(slices.Clip(conds), func(tx *gorm.DB) *gorm.DB {
+				return tx.Limit(limit + 1)
+			})
+		}
+		return tx
+	}
+}
+
+// checkPreloadRows returns an error wrapping `ErrTooManyPreloadRows` if one of the "has many"
+// or "many to many" preloads of the given executed statement loaded more than `limit` records.
+func checkPreloadRows(db *gorm.DB, limit int, sch *schema.Schema, dest any) error {
+	names := make([]string, 0, len(db.Statement.Preloads))
+	for name := range db.Statement.Preloads {
+		if isToManyPreload(name, sch) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if count := countPreloaded(db.Statement.Context, reflect.ValueOf(dest), sch, strings.Split(name, ".")); count > limit {
+			return errors.Errorf("%w: the relation %q would load more than %d records", ErrTooManyPreloadRows, name, limit)
+		}
+	}
+	return nil
+}
+
+// isToManyPreload returns true if the last relation of the given preload path
+// (e.g. "Relation.Nested") is a "has many" or "many to many" relation.
+func isToManyPreload(name string, sch *schema.Schema) bool {
+	var rel *schema.Relationship
+	for _, n := range strings.Split(name, ".") {
+		if sch == nil {
+			return false
+		}
+		r, ok := sch.Relationships.Relations[n]
+		if !ok {
+			return false
+		}
+		rel = r
+		sch = r.FieldSchema
+	}
+	return rel != nil && (rel.Type == schema.HasMany || rel.Type == schema.Many2Many)
+}
+
+// countPreloaded returns the number of records loaded in the relation identified by the
+// given path, for all the given records.
+func countPreloaded(ctx context.Context, value reflect.Value, sch *schema.Schema, path []string) int {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		count := 0
+		for i := range value.Len() {
+			count += countPreloaded(ctx, value.Index(i), sch, path)
+		}
+		return count
+	case reflect.Struct:
+		if len(path) == 0 {
+			return 1
+		}
+		rel, ok := sch.Relationships.Relations[path[0]]
+		if !ok {
+			return 0
+		}
+		return countPreloaded(ctx, rel.Field.ReflectValueOf(ctx, value), rel.FieldSchema, path[1:])
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type PreloadTestOwner struct {
+	Name string
+	ID   uint
+}
+
+type PreloadTestComment struct {
+	Content  string
+	ID       uint
+	ParentID uint
+}
+
+type PreloadTestModel struct {
+	Owner    *PreloadTestOwner
+	Comments []*PreloadTestComment `gorm:"foreignKey:ParentID"`
+	ID       uint
+	OwnerID  uint
+}
+
+func openPreloadTestDB(t *testing.T, comments int) (*gorm.DB, *[]string) {
+	db := openDryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Query().After("gorm:query").Before("gorm:preload").Register("test:fill", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+		switch dest := tx.Statement.Dest.(type) {
+		case *[]*PreloadTestModel:
+			*dest = append(*dest, &PreloadTestModel{ID: 1, OwnerID: 1}, &PreloadTestModel{ID: 2, OwnerID: 1})
+		case *[]*PreloadTestComment:
+			for i := range comments {
+				*dest = append(*dest, &PreloadTestComment{ID: uint(i + 1), ParentID: uint(i%2 + 1)})
+			}
+		case *[]*PreloadTestOwner:
+			*dest = append(*dest, &PreloadTestOwner{ID: 1})
+		}
+	})
+	require.NoError(t, err)
+	return db, &queries
+}
+
+func TestScopeMaxPreloadRows(t *testing.T) {
+	request := &Request{Join: typeutil.NewUndefined([]*Join{{Relation: "Comments"}, {Relation: "Owner"}})}
+	settings := &Settings[*PreloadTestModel]{MaxPreloadRows: 3}
+
+	t.Run("within_limit", func(t *testing.T) {
+		db, queries := openPreloadTestDB(t, 3)
+		results := []*PreloadTestModel{}
+		db = settings.ScopeUnpaginated(db, request, &results)
+		require.NoError(t, db.Error)
+		require.Len(t, results, 2)
+		assert.Len(t, results[0].Comments, 2)
+		assert.Len(t, results[1].Comments, 1)
+		assert.Contains(t, *queries, "SELECT `preload_test_comments`.`content`,`preload_test_comments`.`id`,`preload_test_comments`.`parent_id` FROM `preload_test_comments` WHERE `preload_test_comments`.`parent_id` IN (?,?) LIMIT ?")
+		assert.Contains(t, *queries, "SELECT `preload_test_owners`.`name`,`preload_test_owners`.`id` FROM `preload_test_owners` WHERE `preload_test_owners`.`id` = ?")
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		db, _ := openPreloadTestDB(t, 4)
+		results := []*PreloadTestModel{}
+		db = settings.ScopeUnpaginated(db, request, &results)
+		require.ErrorIs(t, db.Error, ErrTooManyPreloadRows)
+		assert.Equal(t, `too many preloaded records: the relation "Comments" would load more than 3 records`, db.Error.Error())
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		db, _ := openPreloadTestDB(t, 4)
+		results := []*PreloadTestModel{}
+		_, err := settings.Scope(db, request, &results)
+		require.ErrorIs(t, err, ErrTooManyPreloadRows)
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		db, _ := openPreloadTestDB(t, 4)
+		for _, err := range settings.ScopeIterator(db, request) {
+			require.ErrorIs(t, err, ErrTooManyPreloadRows)
+			break
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		db, queries := openPreloadTestDB(t, 4)
+		results := []*PreloadTestModel{}
+		db = (&Settings[*PreloadTestModel]{}).ScopeUnpaginated(db, request, &results)
+		require.NoError(t, db.Error)
+		assert.Contains(t, *queries, "SELECT `preload_test_comments`.`content`,`preload_test_comments`.`id`,`preload_test_comments`.`parent_id` FROM `preload_test_comments` WHERE `preload_test_comments`.`parent_id` IN (?,?)")
+	})
+}
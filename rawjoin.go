@@ -0,0 +1,203 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// rawJoin the table and alias of a join found in a raw SQL join string.
+type rawJoin struct {
+	// Table the unquoted name of the joined table, including the schema if
+	// the table is schema-qualified (e.g. "public.users"). Empty if the joined
+	// expression is a subquery.
+	Table string
+	Alias string
+}
+
+// matches returns true if the given table and alias designate the joined table.
+// Schema-qualified raw joins also match the unqualified table name. If the raw join
+// doesn't define an alias, only the table name is compared.
+func (j rawJoin) matches(table, alias string) bool {
+	if j.Table == "" {
+		return false
+	}
+	tableMatch := j.Table == table
+	if !tableMatch && !strings.Contains(table, ".") {
+		if i := strings.LastIndex(j.Table, "."); i != -1 {
+			tableMatch = j.Table[i+1:] == table
+		}
+	}
+	return tableMatch && (j.Alias == "" || j.Alias == alias)
+}
+
+type sqlTokenKind int
+
+const (
+	sqlTokenWord sqlTokenKind = iota
+	sqlTokenQuoted
+	sqlTokenString
+	sqlTokenSymbol
+)
+
+type sqlToken struct {
+	value string
+	kind  sqlTokenKind
+}
+
+// joinKeywords the keywords that cannot be used as an unquoted alias after a joined table.
+var joinKeywords = map[string]struct{}{
+	"ON": {}, "USING": {}, "JOIN": {}, "LEFT": {}, "RIGHT": {}, "FULL": {}, "INNER": {}, "OUTER": {},
+	"CROSS": {}, "NATURAL": {}, "LATERAL": {}, "WHERE": {}, "AS": {},
+}
+
+// parseRawJoins returns the tables joined by the given raw SQL join string
+// (e.g. the query given to `db.Joins("LEFT JOIN users u ON ...")`). The string can
+// contain several joins, span multiple lines and contain subqueries. The joins inside
+// subqueries are ignored. Identifiers can be quoted using double quotes, backticks or brackets.
+func parseRawJoins(sql string) []rawJoin {
+	tokens := tokenizeSQL(sql)
+	var joins []rawJoin
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.kind == sqlTokenSymbol && t.value == "(":
+			depth++
+		case t.kind == sqlTokenSymbol && t.value == ")":
+			depth--
+		case depth == 0 && isKeyword(t, "JOIN"):
+			var j rawJoin
+			j, i = parseJoinTarget(tokens, i+1)
+			joins = append(joins, j)
+			i--
+		}
+	}
+	return joins
+}
+
+// parseJoinTarget parses the table and alias of a join starting at the given token index
+// (right after the `JOIN` keyword). Returns the join and the index of the next token.
+func parseJoinTarget(tokens []sqlToken, i int) (rawJoin, int) {
+	j := rawJoin{}
+	if i < len(tokens) && isKeyword(tokens[i], "LATERAL") {
+		i++
+	}
+	if i >= len(tokens) {
+		return j, i
+	}
+
+	if tokens[i].kind == sqlTokenSymbol && tokens[i].value == "(" {
+		depth := 0
+		for ; i < len(tokens); i++ {
+			if tokens[i].kind != sqlTokenSymbol {
+				continue
+			}
+			if tokens[i].value == "(" {
+				depth++
+			} else if tokens[i].value == ")" {
+				depth--
+				if depth == 0 {
+					i++
+					break
+				}
+			}
+		}
+	} else if isIdentifier(tokens[i]) {
+		parts := []string{tokens[i].value}
+		i++
+		for i+1 < len(tokens) && tokens[i].kind == sqlTokenSymbol && tokens[i].value == "." && isIdentifier(tokens[i+1]) {
+			parts = append(parts, tokens[i+1].value)
+			i += 2
+		}
+		j.Table = strings.Join(parts, ".")
+	} else {
+		return j, i
+	}
+
+	if i < len(tokens) && isKeyword(tokens[i], "AS") {
+		i++
+	}
+	if i < len(tokens) && isIdentifier(tokens[i]) {
+		j.Alias = tokens[i].value
+		i++
+	}
+	return j, i
+}
+
+func isKeyword(t sqlToken, keyword string) bool {
+	return t.kind == sqlTokenWord && strings.EqualFold(t.value, keyword)
+}
+
+func isIdentifier(t sqlToken) bool {
+	if t.kind == sqlTokenQuoted {
+		return true
+	}
+	if t.kind != sqlTokenWord {
+		return false
+	}
+	_, reserved := joinKeywords[strings.ToUpper(t.value)]
+	return !reserved
+}
+
+// tokenizeSQL splits the given SQL string into words, quoted identifiers (unquoted),
+// string literals and symbols. Whitespace and comments are skipped.
+func tokenizeSQL(sql string) []sqlToken {
+	tokens := make([]sqlToken, 0, 16)
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && (runes[i] != '*' || i+1 >= len(runes) || runes[i+1] != '/') {
+				i++
+			}
+			i += 2
+		case r == '"' || r == '`' || r == '[' || r == '\'':
+			closing := r
+			kind := sqlTokenQuoted
+			if r == '[' {
+				closing = ']'
+			} else if r == '\'' {
+				kind = sqlTokenString
+			}
+			var value strings.Builder
+			i++
+			for i < len(runes) {
+				if runes[i] == closing {
+					if i+1 < len(runes) && runes[i+1] == closing && closing != ']' {
+						// Escaped quote
+						value.WriteRune(closing)
+						i += 2
+						continue
+					}
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			i++
+			tokens = append(tokens, sqlToken{kind: kind, value: value.String()})
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenWord, value: string(runes[start:i])})
+		default:
+			tokens = append(tokens, sqlToken{kind: sqlTokenSymbol, value: string(r)})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+func TestParseRawJoins(t *testing.T) {
+	cases := []struct {
+		desc string
+		sql  string
+		want []rawJoin
+	}{
+		{desc: "not_a_join", sql: "Relation", want: nil},
+		{desc: "simple", sql: "JOIN users ON users.id = articles.user_id", want: []rawJoin{{Table: "users"}}},
+		{desc: "alias", sql: "LEFT JOIN users u ON u.id = articles.user_id", want: []rawJoin{{Table: "users", Alias: "u"}}},
+		{desc: "as_alias", sql: "left outer join users as u on u.id = articles.user_id", want: []rawJoin{{Table: "users", Alias: "u"}}},
+		{desc: "double_quotes", sql: `INNER JOIN "users" AS "Author" ON "Author"."id" = "articles"."user_id"`, want: []rawJoin{{Table: "users", Alias: "Author"}}},
+		{desc: "backticks", sql: "RIGHT JOIN `users` `Author` ON `Author`.`id` = `articles`.`user_id`", want: []rawJoin{{Table: "users", Alias: "Author"}}},
+		{desc: "brackets", sql: "FULL JOIN [users] [Author] ON [Author].[id] = [articles].[user_id]", want: []rawJoin{{Table: "users", Alias: "Author"}}},
+		{desc: "quoted_keyword", sql: `JOIN "order" "on" ON "on"."id" = 1`, want: []rawJoin{{Table: "order", Alias: "on"}}},
+		{desc: "schema_qualified", sql: `LEFT JOIN "public"."users" "Author" ON 1 = 1`, want: []rawJoin{{Table: "public.users", Alias: "Author"}}},
+		{desc: "using", sql: "JOIN users USING (id)", want: []rawJoin{{Table: "users"}}},
+		{desc: "cross_join", sql: "CROSS JOIN users", want: []rawJoin{{Table: "users"}}},
+		{
+			desc: "multi_line",
+			sql:  "LEFT JOIN users u\n\tON u.id = articles.user_id\nLEFT JOIN\n  profiles AS p\n  ON p.user_id = u.id",
+			want: []rawJoin{{Table: "users", Alias: "u"}, {Table: "profiles", Alias: "p"}},
+		},
+		{
+			desc: "subquery",
+			sql:  "LEFT JOIN (SELECT user_id, COUNT(*) AS total FROM comments JOIN posts p ON p.id = comments.post_id GROUP BY user_id) c ON c.user_id = users.id",
+			want: []rawJoin{{Alias: "c"}},
+		},
+		{
+			desc: "lateral",
+			sql:  "LEFT JOIN LATERAL (SELECT * FROM comments WHERE comments.user_id = users.id LIMIT 1) AS last_comment ON true JOIN LATERAL unnest(users.tags) t ON true",
+			want: []rawJoin{{Alias: "last_comment"}, {Table: "unnest"}},
+		},
+		{
+			desc: "literals_and_comments",
+			sql:  "JOIN users u /* JOIN fake f */ ON u.name = 'JOIN x ON' -- JOIN other o\nJOIN profiles p ON p.id = u.id",
+			want: []rawJoin{{Table: "users", Alias: "u"}, {Table: "profiles", Alias: "p"}},
+		},
+		{desc: "incomplete", sql: "LEFT JOIN", want: []rawJoin{{}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.want, parseRawJoins(c.sql))
+		})
+	}
+}
+
+func TestRawJoinMatches(t *testing.T) {
+	assert.True(t, rawJoin{Table: "users"}.matches("users", "Author"))
+	assert.True(t, rawJoin{Table: "users", Alias: "Author"}.matches("users", "Author"))
+	assert.False(t, rawJoin{Table: "users", Alias: "u"}.matches("users", "Author"))
+	assert.False(t, rawJoin{Table: "profiles"}.matches("users", "Author"))
+	assert.True(t, rawJoin{Table: "public.users", Alias: "Author"}.matches("users", "Author"))
+	assert.True(t, rawJoin{Table: "public.users", Alias: "Author"}.matches("public.users", "Author"))
+	assert.False(t, rawJoin{Table: "users", Alias: "Author"}.matches("other.users", "Author"))
+	assert.False(t, rawJoin{Alias: "Author"}.matches("users", "Author"))
+}
+
+func TestJoinExistsRawJoin(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "Relation.name", Operator: Operators["$eq"], Args: []string{"a"}}}),
+	}
+
+	db := openDryRunDB(t)
+	db = db.Joins("LEFT JOIN\n\t\"main\".\"filter_test_relations\" AS \"Relation\"\n\tON \"Relation\".\"parent_id\" = \"filter_test_models\".\"id\"")
+	results := []*FilterTestModel{}
+	db = (&Settings[*FilterTestModel]{}).ScopeUnpaginated(db, request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` LEFT JOIN\n\t\"main\".\"filter_test_relations\" AS \"Relation\"\n\tON \"Relation\".\"parent_id\" = \"filter_test_models\".\"id\" WHERE `Relation`.`name` = ?",
+		db.Statement.SQL.String(),
+	)
+}
+
+func BenchmarkParseRawJoins(b *testing.B) {
+	sql := "LEFT JOIN `users` AS `Author` ON `Author`.`id` = `articles`.`author_id` LEFT JOIN profiles p ON p.user_id = `Author`.`id`"
+	for i := 0; i < b.N; i++ {
+		parseRawJoins(sql)
+	}
+}
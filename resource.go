@@ -0,0 +1,97 @@
+package filter
+
+import (
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/database"
+	"goyave.dev/goyave/v5/util/session"
+	v "goyave.dev/goyave/v5/validation"
+)
+
+// Resource is a Goyave controller component serving a standard paginated list endpoint
+// for the model `T`. It can be embedded in a controller or used as is:
+//
+//	func (ctrl *Controller) RegisterRoutes(router *goyave.Router) {
+//		router.Get("/users", ctrl.Users.Index).ValidateQuery(ctrl.Users.Validation)
+//	}
+//
+// The hooks are optional and can be used to customize each step of the request.
+type Resource[T any] struct {
+	goyave.Component
+
+	// Settings the settings used to apply the filter request. If nil, the default settings are used.
+	Settings *Settings[T]
+
+	// Query is called with the database session before the filter request is applied.
+	// Use it to add the conditions that should always apply, such as tenant restrictions.
+	Query func(request *goyave.Request, tx *gorm.DB) *gorm.DB
+
+	// PrepareRequest is called with the parsed filter request before it is applied.
+	// Use it to enforce default values or override parameters.
+	PrepareRequest func(request *goyave.Request, filterRequest *Request)
+
+	// Transform converts the paginator into the response body, for example to
+	// convert the records to DTOs. If nil, the paginator is returned as is.
+	Transform func(request *goyave.Request, paginator *database.Paginator[T]) any
+
+	// ParamNames the names of the query parameters. Empty names fall back to `DefaultParamNames`.
+	ParamNames ParamNames
+}
+
+// Validation returns the query validation rules for the resource's list endpoint.
+// `per_page=all` is only accepted if `Settings.AllowAll` is enabled.
+func (r *Resource[T]) Validation(_ *goyave.Request) v.RuleSet {
+	return r.ParamNames.rules(r.Settings != nil && r.Settings.AllowAll)
+}
+
+// Index handler returning the filtered and paginated records as JSON.
+// The use of deprecated operators is reported with a "Warning" header per message.
+// Database errors are written using `response.WriteDBError()`. If the request was handed off
+// to `Settings.ExportEnqueuer`, the response status is "202 Accepted".
+func (r *Resource[T]) Index(response *goyave.Response, request *goyave.Request) {
+	paginator, err := r.paginate(session.DB(request.Context(), r.DB()), request)
+	if response.WriteDBError(err) {
+		return
+	}
+	if enqueued, _ := paginator.DB.Get(ExportEnqueuedSetting); enqueued == true {
+		response.Status(http.StatusAccepted)
+		return
+	}
+	writeDeprecationWarnings(response.Header(), paginator.DB)
+	var body any = paginator
+	if r.Transform != nil {
+		body = r.Transform(request, paginator)
+	}
+	response.JSON(http.StatusOK, body)
+}
+
+func (r *Resource[T]) paginate(db *gorm.DB, request *goyave.Request) (*database.Paginator[T], error) {
+	filterRequest := r.ParamNames.NewRequest(request.Query)
+	if r.PrepareRequest != nil {
+		r.PrepareRequest(request, filterRequest)
+	}
+	if r.Query != nil {
+		db = r.Query(request, db)
+	}
+	settings := r.Settings
+	if settings == nil {
+		settings = &Settings[T]{}
+	}
+	records := []T{}
+	return settings.Scope(db, filterRequest, &records)
+}
+
+// writeDeprecationWarnings adds a "Warning" header to the given header for each message
+// stored in the `DeprecationsSetting` statement setting of the given DB.
+func writeDeprecationWarnings(header http.Header, db *gorm.DB) {
+	deprecations, ok := db.Get(DeprecationsSetting)
+	if !ok {
+		return
+	}
+	for _, message := range deprecations.([]string) {
+		header.Add("Warning", fmt.Sprintf("299 - %q", message))
+	}
+}
@@ -0,0 +1,94 @@
+package filter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/database"
+	"goyave.dev/goyave/v5/validation"
+)
+
+type ResourceTestModel struct {
+	Name     string
+	TenantID uint
+	ID       uint
+}
+
+func TestResourcePaginate(t *testing.T) {
+	db := openDryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Query().After("gorm:query").Register("test:queries", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	})
+	require.NoError(t, err)
+
+	request := &goyave.Request{Query: map[string]any{"q": "jack", "per_page": 10}}
+	resource := &Resource[*ResourceTestModel]{
+		Settings:   &Settings[*ResourceTestModel]{FieldsSearch: []string{"name"}},
+		ParamNames: ParamNames{Search: "q"},
+		Query: func(r *goyave.Request, tx *gorm.DB) *gorm.DB {
+			assert.Same(t, request, r)
+			return tx.Where("tenant_id = ?", 1)
+		},
+		PrepareRequest: func(r *goyave.Request, filterRequest *Request) {
+			assert.Same(t, request, r)
+			assert.Equal(t, "jack", filterRequest.Search.Val)
+			filterRequest.PerPage.Val = 5
+		},
+	}
+
+	paginator, err := resource.paginate(db, request)
+	require.NoError(t, err)
+	require.NotNil(t, paginator)
+	assert.Equal(t, 5, paginator.PageSize)
+	assert.Equal(t, []string{
+		"SELECT count(*) FROM `resource_test_models` WHERE tenant_id = ? AND `resource_test_models`.`name` LIKE ?",
+		"SELECT `resource_test_models`.`name`,`resource_test_models`.`tenant_id`,`resource_test_models`.`id` FROM `resource_test_models` WHERE tenant_id = ? AND `resource_test_models`.`name` LIKE ? LIMIT ?",
+	}, queries)
+	assert.IsType(t, &database.Paginator[*ResourceTestModel]{}, paginator)
+}
+
+func TestResourceDeprecationWarnings(t *testing.T) {
+	DeprecatedOperators["$ne"] = "use $not instead"
+	t.Cleanup(func() {
+		delete(DeprecatedOperators, "$ne")
+	})
+
+	request := &goyave.Request{Query: map[string]any{
+		"filter": []*Filter{{Field: "name", Operator: Operators["$ne"], Args: []string{"jack"}}},
+	}}
+	resource := &Resource[*ResourceTestModel]{}
+	paginator, err := resource.paginate(openDryRunDB(t), request)
+	require.NoError(t, err)
+
+	header := http.Header{}
+	writeDeprecationWarnings(header, paginator.DB)
+	assert.Equal(t, []string{`299 - "operator \"$ne\" is deprecated: use $not instead"`}, header.Values("Warning"))
+
+	header = http.Header{}
+	paginator, err = resource.paginate(openDryRunDB(t), &goyave.Request{Query: map[string]any{}})
+	require.NoError(t, err)
+	writeDeprecationWarnings(header, paginator.DB)
+	assert.Empty(t, header.Values("Warning"))
+}
+
+func TestResourceValidation(t *testing.T) {
+	resource := &Resource[*ResourceTestModel]{ParamNames: ParamNames{Search: "q"}}
+	paths := lo.Map(resource.Validation(nil), func(f *validation.FieldRules, _ int) string {
+		return f.Path
+	})
+	assert.Contains(t, paths, "q")
+	assert.NotContains(t, paths, "search")
+
+	allowAll := func(resource *Resource[*ResourceTestModel]) bool {
+		rules, _ := lo.Find(resource.Validation(nil), func(f *validation.FieldRules) bool { return f.Path == "per_page" })
+		return any(rules.Rules).(validation.List)[0].(*PerPageValidator).AllowAll
+	}
+	assert.False(t, allowAll(resource))
+	assert.True(t, allowAll(&Resource[*ResourceTestModel]{Settings: &Settings[*ResourceTestModel]{AllowAll: true}}))
+}
@@ -1,11 +1,8 @@
 package filter
 
 import (
-	"fmt"
-	"strings"
-
+	"github.com/samber/lo"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
 )
 
@@ -13,56 +10,132 @@ import (
 type Search struct {
 	Query    string
 	Operator *Operator
-	Fields   []string
+	// Operators the operators used for the fields of a specific data type.
+	// Fields of a data type not in this map use `Operator`.
+	Operators map[DataType]*Operator
+	Fields    []string
+}
+
+// operator returns the operator to use for a field of the given data type.
+func (s *Search) operator(dataType DataType) *Operator {
+	if op, ok := s.Operators[dataType]; ok && op != nil {
+		return op
+	}
+	return s.Operator
 }
 
 // Scope returns the GORM scopes with the search query.
 func (s *Search) Scope(schema *schema.Schema) func(*gorm.DB) *gorm.DB {
-	if len(s.Fields) == 0 {
+	joinScope, conditionScope := s.scopes(schema)
+	if conditionScope == nil {
 		return nil
 	}
 
 	return func(tx *gorm.DB) *gorm.DB {
-		searchQuery := tx.Session(&gorm.Session{NewDB: true})
+		tx = joinScope(tx)
+		if tx.Error != nil {
+			return tx
+		}
+		return conditionScope(tx)
+	}
+}
 
-		for _, field := range s.Fields {
-			f, sch, joinName := getField(field, schema, nil)
-			if f == nil {
-				continue
-			}
-			dataType := getDataType(f)
-			if dataType == DataTypeUnsupported {
-				continue
-			}
+// scopes returns the scope joining the relations referenced by the search fields and
+// the scope adding the search condition. Both are nil if there are no search fields.
+func (s *Search) scopes(sch *schema.Schema) (func(*gorm.DB) *gorm.DB, func(*gorm.DB) *gorm.DB) {
+	if len(s.Fields) == 0 {
+		return nil, nil
+	}
 
-			if joinName != "" {
+	fields := s.fields(sch)
+
+	joinScope := func(tx *gorm.DB) *gorm.DB {
+		for _, f := range fields {
+			if f.joinName != "" {
 				if err := tx.Statement.Parse(tx.Statement.Model); err != nil {
 					tx.AddError(err)
 					return tx
 				}
-				tx = join(tx, joinName, schema)
+				tx = join(tx, f.joinName, sch)
 			}
+		}
+		return tx
+	}
 
+	conditionScope := func(tx *gorm.DB) *gorm.DB {
+		searchQuery := tx.Session(&gorm.Session{NewDB: true})
+		for _, f := range fields {
+			operator := s.operator(f.dataType)
+			table := tableFromJoinName(f.schema.Table, f.joinName)
 			filter := &Filter{
-				Field:    f.DBName,
-				Operator: s.Operator,
+				Field:    f.field.DBName,
+				Operator: operator,
+				table:    table,
 				Args:     []string{s.Query},
 				Or:       true,
 			}
 
-			table := tx.Statement.Quote(tableFromJoinName(sch.Table, joinName))
+			fieldExpr := columnExpression(tx.Statement, table, f.field)
+			searchQuery = operator.Function(searchQuery, filter, fieldExpr, f.dataType)
+		}
+		return tx.Where(searchQuery)
+	}
 
-			computed := f.StructField.Tag.Get("computed")
-			var fieldExpr string
-			if computed != "" {
-				fieldExpr = fmt.Sprintf("(%s)", strings.ReplaceAll(computed, clause.CurrentTable, table))
-			} else {
-				fieldExpr = table + "." + tx.Statement.Quote(f.DBName)
-			}
+	return joinScope, conditionScope
+}
+
+type searchField struct {
+	field    *schema.Field
+	schema   *schema.Schema
+	joinName string
+	dataType DataType
+}
 
-			searchQuery = s.Operator.Function(searchQuery, filter, fieldExpr, dataType)
+// fields returns the search fields existing in the given schema, excluding
+// the fields of an unsupported type.
+func (s *Search) fields(sch *schema.Schema) []searchField {
+	fields := make([]searchField, 0, len(s.Fields))
+	for _, field := range s.Fields {
+		f, fieldSchema, joinName := getField(field, sch, nil)
+		if f == nil {
+			continue
+		}
+		dataType := getDataType(f)
+		if dataType == DataTypeUnsupported {
+			continue
 		}
+		fields = append(fields, searchField{field: f, schema: fieldSchema, joinName: joinName, dataType: dataType})
+	}
+	return fields
+}
 
-		return tx.Where(searchQuery)
+// joinNames returns the names of the relations the search fields belong to.
+func (s *Search) joinNames(sch *schema.Schema) []string {
+	return lo.FilterMap(s.fields(sch), func(f searchField, _ int) (string, bool) {
+		return f.joinName, f.joinName != ""
+	})
+}
+
+// searchFilterOperator returns an operator applying the search operator to
+// a single field in a filter. The search operator is applied in its own group so
+// the conditions it adds with `OR` don't affect the other filters.
+func searchFilterOperator(s *Search) *Operator {
+	return &Operator{
+		Function: func(tx *gorm.DB, filter *Filter, column string, dataType DataType) *gorm.DB {
+			search := s.operator(dataType)
+			searchFilter := &Filter{
+				Field:    filter.Field,
+				Operator: search,
+				table:    filter.table,
+				Args:     filter.Args,
+				Or:       true,
+			}
+			searchQuery := search.Function(tx.Session(&gorm.Session{NewDB: true}), searchFilter, column, dataType)
+			if filter.Or {
+				return tx.Or(searchQuery)
+			}
+			return tx.Where(searchQuery)
+		},
+		RequiredArguments: 1,
 	}
 }
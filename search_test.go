@@ -3,8 +3,10 @@ package filter
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
@@ -502,3 +504,83 @@ func TestSearchScopeWithUnsupportedDataType(t *testing.T) {
 	}
 	assert.Equal(t, expected, db.Statement.Clauses)
 }
+
+type SearchTestModelReservedKeyword struct {
+	Order string
+	Group string
+	User  string
+	ID    uint
+}
+
+func TestSearchScopeReservedKeyword(t *testing.T) {
+	db := openDryRunDB(t)
+	search := &Search{
+		Fields:   []string{"order", "group", "user"},
+		Query:    "My Query",
+		Operator: Operators["$eq"],
+	}
+
+	results := []*SearchTestModelReservedKeyword{}
+	schema, err := parseModel(db, &results)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	db = db.Model(&results).Scopes(search.Scope(schema)).Find(&results)
+	expected := clause.Where{
+		Exprs: []clause.Expression{
+			clause.AndConditions{
+				Exprs: []clause.Expression{
+					clause.OrConditions{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`search_test_model_reserved_keywords`.`order` = ?", Vars: []any{"My Query"}},
+						},
+					},
+					clause.OrConditions{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`search_test_model_reserved_keywords`.`group` = ?", Vars: []any{"My Query"}},
+						},
+					},
+					clause.OrConditions{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`search_test_model_reserved_keywords`.`user` = ?", Vars: []any{"My Query"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, db.Statement.Clauses["WHERE"].Expression)
+}
+
+type SearchTestTypedModel struct {
+	CreatedAt time.Time
+	Name      string
+	Status    string `filterType:"enum"`
+	ID        uint
+}
+
+func TestSearchScopeOperatorsPerDataType(t *testing.T) {
+	db := openDryRunDB(t)
+	search := &Search{
+		Fields:   []string{"created_at", "name", "status", "id"},
+		Query:    "2023-04-30",
+		Operator: Operators["$cont"],
+		Operators: map[DataType]*Operator{
+			DataTypeEnum: Operators["$eq"],
+			DataTypeTime: Operators["$day"],
+		},
+	}
+
+	results := []*SearchTestTypedModel{}
+	schema, err := parseModel(db, &results)
+	require.NoError(t, err)
+
+	db = db.Model(&results).Scopes(search.Scope(schema)).Find(&results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT * FROM `search_test_typed_models` WHERE (`search_test_typed_models`.`created_at` >= ? AND `search_test_typed_models`.`created_at` < ?) OR `search_test_typed_models`.`name` LIKE ? OR CAST(`search_test_typed_models`.`status` AS TEXT) = ? OR FALSE",
+		db.Statement.SQL.String(),
+	)
+	assert.Equal(t, []any{"2023-04-30", "2023-05-01", "%2023-04-30%", "2023-04-30"}, db.Statement.Vars)
+}
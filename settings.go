@@ -1,14 +1,24 @@
 package filter
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"math"
 	"slices"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/samber/lo"
+	"golang.org/x/text/unicode/norm"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
 	"goyave.dev/goyave/v5/database"
 	"goyave.dev/goyave/v5/util/errors"
@@ -17,58 +27,307 @@ import (
 
 // Request DTO for a filter query. Any non-present option will be ignored.
 type Request struct {
-	Search  typeutil.Undefined[string]
-	Filter  typeutil.Undefined[[]*Filter]
-	Or      typeutil.Undefined[[]*Filter]
-	Sort    typeutil.Undefined[[]*Sort]
-	Join    typeutil.Undefined[[]*Join]
-	Fields  typeutil.Undefined[[]string]
-	Page    typeutil.Undefined[int]
-	PerPage typeutil.Undefined[int]
+	Search typeutil.Undefined[string]
+	Filter typeutil.Undefined[[]*Filter]
+	Or     typeutil.Undefined[[]*Filter]
+	// FilterGroups the filters of each group are ORed together and
+	// the groups are ANDed with the other filters.
+	FilterGroups typeutil.Undefined[[][]*Filter]
+	// Condition a condition built programmatically, ANDed with the other filters.
+	// Ignored if nil. Conditions are not encoded in JSON.
+	Condition Condition
+	Sort      typeutil.Undefined[[]*Sort]
+	Join      typeutil.Undefined[[]*Join]
+	Fields    typeutil.Undefined[[]string]
+	// DistinctOn the columns used to select a single record per group of identical
+	// values with `SELECT DISTINCT ON` (PostgreSQL only). Ignored unless allowed by `Settings.DistinctOn`.
+	DistinctOn typeutil.Undefined[[]string]
+	// UpdatedSince only the records changed after this time are listed.
+	// Ignored unless enabled by `Settings.UpdatedAtField`.
+	UpdatedSince typeutil.Undefined[time.Time]
+	// Snapshot the token of the database snapshot used to list the records, so all the pages
+	// of a listing are consistent, or `SnapshotNew` to create a snapshot.
+	// Ignored unless enabled by `Settings.Snapshots`.
+	Snapshot typeutil.Undefined[string]
+	Page     typeutil.Undefined[int]
+	PerPage  typeutil.Undefined[int]
+}
+
+// ParamNames the names of the query parameters used by the filter request.
+// Empty names fall back to the names defined in `DefaultParamNames`.
+type ParamNames struct {
+	Search       string
+	Filter       string
+	Or           string
+	Sort         string
+	Join         string
+	Fields       string
+	DistinctOn   string
+	UpdatedSince string
+	Snapshot     string
+	Page         string
+	PerPage      string
+}
+
+// DefaultParamNames the query parameter names used by `NewRequest()` and `Validation()`.
+var DefaultParamNames = ParamNames{
+	Search:       "search",
+	Filter:       "filter",
+	Or:           "or",
+	Sort:         "sort",
+	Join:         "join",
+	Fields:       "fields",
+	DistinctOn:   "distinct_on",
+	UpdatedSince: "updated_since",
+	Snapshot:     "snapshot",
+	Page:         "page",
+	PerPage:      "per_page",
+}
+
+func (p ParamNames) withDefaults() ParamNames {
+	p.Search = lo.CoalesceOrEmpty(p.Search, DefaultParamNames.Search)
+	p.Filter = lo.CoalesceOrEmpty(p.Filter, DefaultParamNames.Filter)
+	p.Or = lo.CoalesceOrEmpty(p.Or, DefaultParamNames.Or)
+	p.Sort = lo.CoalesceOrEmpty(p.Sort, DefaultParamNames.Sort)
+	p.Join = lo.CoalesceOrEmpty(p.Join, DefaultParamNames.Join)
+	p.Fields = lo.CoalesceOrEmpty(p.Fields, DefaultParamNames.Fields)
+	p.DistinctOn = lo.CoalesceOrEmpty(p.DistinctOn, DefaultParamNames.DistinctOn)
+	p.UpdatedSince = lo.CoalesceOrEmpty(p.UpdatedSince, DefaultParamNames.UpdatedSince)
+	p.Snapshot = lo.CoalesceOrEmpty(p.Snapshot, DefaultParamNames.Snapshot)
+	p.Page = lo.CoalesceOrEmpty(p.Page, DefaultParamNames.Page)
+	p.PerPage = lo.CoalesceOrEmpty(p.PerPage, DefaultParamNames.PerPage)
+	return p
 }
 
 // NewRequest creates a filter request from an HTTP request's query.
 // Uses the following entries in the query, expected to be validated:
 //   - search
 //   - filter
+//   - filter[0], filter[1], ... (filter groups, sorted by index)
 //   - or
 //   - sort
 //   - join
 //   - fields
+//   - distinct_on
+//   - updated_since
+//   - snapshot
 //   - page
 //   - per_page
 //
+// The names of these entries can be changed using `DefaultParamNames`.
+//
 // If a field in the query doesn't match the expected type (non-validated) for the
 // filtering option, it will be ignored without an error.
 func NewRequest(query map[string]any) *Request {
+	return ParamNames{}.NewRequest(query)
+}
+
+// NewRequest creates a filter request from an HTTP request's query using these
+// parameter names. See `NewRequest()` for more details.
+func (p ParamNames) NewRequest(query map[string]any) *Request {
+	p = p.withDefaults()
 	r := &Request{}
-	if search, ok := query["search"].(string); ok {
+	if search, ok := query[p.Search].(string); ok {
 		r.Search = typeutil.NewUndefined(search)
 	}
-	if filter, ok := query["filter"].([]*Filter); ok {
+	if filter, ok := query[p.Filter].([]*Filter); ok {
 		r.Filter = typeutil.NewUndefined(filter)
 	}
-	if or, ok := query["or"].([]*Filter); ok {
+	if or, ok := query[p.Or].([]*Filter); ok {
 		r.Or = typeutil.NewUndefined(or)
 	}
-	if sort, ok := query["sort"].([]*Sort); ok {
+	groups := map[int][]*Filter{}
+	for key, value := range query {
+		if index, ok := parseFilterGroupIndex(p.Filter, key); ok {
+			if filters, ok := value.([]*Filter); ok {
+				groups[index] = filters
+			}
+		}
+	}
+	if len(groups) > 0 {
+		r.FilterGroups = typeutil.NewUndefined(lo.Map(slices.Sorted(maps.Keys(groups)), func(index int, _ int) []*Filter {
+			return groups[index]
+		}))
+	}
+	if sort, ok := query[p.Sort].([]*Sort); ok {
 		r.Sort = typeutil.NewUndefined(sort)
 	}
-	if join, ok := query["join"].([]*Join); ok {
+	if join, ok := query[p.Join].([]*Join); ok {
 		r.Join = typeutil.NewUndefined(join)
 	}
-	if fields, ok := query["fields"].([]string); ok {
+	if fields, ok := query[p.Fields].([]string); ok {
 		r.Fields = typeutil.NewUndefined(fields)
 	}
-	if page, ok := query["page"].(int); ok {
+	if distinctOn, ok := query[p.DistinctOn].([]string); ok {
+		r.DistinctOn = typeutil.NewUndefined(distinctOn)
+	}
+	if updatedSince, ok := query[p.UpdatedSince].(time.Time); ok {
+		r.UpdatedSince = typeutil.NewUndefined(updatedSince)
+	}
+	if snapshot, ok := query[p.Snapshot].(string); ok {
+		r.Snapshot = typeutil.NewUndefined(snapshot)
+	}
+	if page, ok := query[p.Page].(int); ok {
 		r.Page = typeutil.NewUndefined(page)
 	}
-	if perPage, ok := query["per_page"].(int); ok {
+	if perPage, ok := query[p.PerPage].(int); ok {
 		r.PerPage = typeutil.NewUndefined(perPage)
 	}
 	return r
 }
 
+// NormalizedHash returns a hash identifying the shape of this request: the filtered fields
+// and operators, sorts, joins, selected fields and whether there is a search. The filter arguments,
+// search query and pagination are not taken into account, so requests of the same shape
+// share the same hash. Useful to label queries in metrics with a low cardinality.
+func (r *Request) NormalizedHash() string {
+	h := sha256.New()
+	writeFilters := func(name string, filters []*Filter) {
+		fmt.Fprintf(h, "%s:", name)
+		for _, f := range filters {
+			opName, _ := operatorName(f.Operator)
+			fmt.Fprintf(h, "%q%s,", f.Field, opName)
+		}
+		h.Write([]byte{'\n'})
+	}
+	fmt.Fprintf(h, "search:%t\n", r.Search.Present)
+	writeFilters("filter", r.Filter.Val)
+	writeFilters("or", r.Or.Val)
+	for _, group := range r.FilterGroups.Val {
+		writeFilters("group", group)
+	}
+	if r.Condition != nil {
+		fmt.Fprint(h, "condition:")
+		writeConditionShape(h, r.Condition.Node())
+		h.Write([]byte{'\n'})
+	}
+	fmt.Fprint(h, "sort:")
+	for _, sort := range r.Sort.Val {
+		fmt.Fprintf(h, "%q%s,", sort.Field, sort.Order)
+	}
+	fmt.Fprint(h, "\njoin:")
+	for _, join := range r.Join.Val {
+		fmt.Fprintf(h, "%q%q,", join.Relation, join.Fields)
+		if join.WithTrashed {
+			fmt.Fprint(h, JoinWithTrashed+",")
+		}
+	}
+	fmt.Fprintf(h, "\nfields:%q", r.Fields.Val)
+	if r.DistinctOn.Present {
+		fmt.Fprintf(h, "\ndistinct_on:%q", r.DistinctOn.Val)
+	}
+	if r.UpdatedSince.Present {
+		fmt.Fprint(h, "\nupdated_since")
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// SimpleRequest plain variant of `Request` using pointers and nilable slices instead
+// of `typeutil.Undefined`, easier to construct and marshal outside of Goyave.
+// A nil value means the option is not present. An empty non-nil slice is considered present.
+type SimpleRequest struct {
+	Search       *string     `json:"search"`
+	Page         *int        `json:"page"`
+	PerPage      *int        `json:"per_page"`
+	Filter       []*Filter   `json:"filter"`
+	Or           []*Filter   `json:"or"`
+	FilterGroups [][]*Filter `json:"filter_groups"`
+	Condition    Condition   `json:"-"`
+	Sort         []*Sort     `json:"sort"`
+	Join         []*Join     `json:"join"`
+	Fields       []string    `json:"fields"`
+	DistinctOn   []string    `json:"distinct_on"`
+	UpdatedSince *time.Time  `json:"updated_since"`
+	Snapshot     *string     `json:"snapshot"`
+}
+
+// ToRequest converts this simple request to a `Request`. The slices and
+// pointed values are not copied.
+func (r *SimpleRequest) ToRequest() *Request {
+	return &Request{
+		Search:       undefinedFromPtr(r.Search),
+		Filter:       undefinedFromSlice(r.Filter),
+		Or:           undefinedFromSlice(r.Or),
+		FilterGroups: undefinedFromSlice(r.FilterGroups),
+		Condition:    r.Condition,
+		Sort:         undefinedFromSlice(r.Sort),
+		Join:         undefinedFromSlice(r.Join),
+		Fields:       undefinedFromSlice(r.Fields),
+		DistinctOn:   undefinedFromSlice(r.DistinctOn),
+		UpdatedSince: undefinedFromPtr(r.UpdatedSince),
+		Snapshot:     undefinedFromPtr(r.Snapshot),
+		Page:         undefinedFromPtr(r.Page),
+		PerPage:      undefinedFromPtr(r.PerPage),
+	}
+}
+
+// ToSimpleRequest converts this request to a `SimpleRequest`. The slices are not copied.
+func (r *Request) ToSimpleRequest() *SimpleRequest {
+	return &SimpleRequest{
+		Search:       ptrFromUndefined(r.Search),
+		Filter:       sliceFromUndefined(r.Filter),
+		Or:           sliceFromUndefined(r.Or),
+		FilterGroups: sliceFromUndefined(r.FilterGroups),
+		Condition:    r.Condition,
+		Sort:         sliceFromUndefined(r.Sort),
+		Join:         sliceFromUndefined(r.Join),
+		Fields:       sliceFromUndefined(r.Fields),
+		DistinctOn:   sliceFromUndefined(r.DistinctOn),
+		UpdatedSince: ptrFromUndefined(r.UpdatedSince),
+		Snapshot:     ptrFromUndefined(r.Snapshot),
+		Page:         ptrFromUndefined(r.Page),
+		PerPage:      ptrFromUndefined(r.PerPage),
+	}
+}
+
+// MarshalJSON encodes the request as a JSON object using the same keys as the query.
+// Non-present options are encoded as `null`.
+func (r *Request) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.ToSimpleRequest())
+}
+
+// UnmarshalJSON decodes a request encoded with `Request.MarshalJSON()`.
+// Missing and `null` options are considered not present.
+func (r *Request) UnmarshalJSON(data []byte) error {
+	simple := &SimpleRequest{}
+	if err := json.Unmarshal(data, simple); err != nil {
+		return err
+	}
+	*r = *simple.ToRequest()
+	return nil
+}
+
+func undefinedFromPtr[T any](value *T) typeutil.Undefined[T] {
+	if value == nil {
+		return typeutil.Undefined[T]{}
+	}
+	return typeutil.NewUndefined(*value)
+}
+
+func undefinedFromSlice[T any](value []T) typeutil.Undefined[[]T] {
+	if value == nil {
+		return typeutil.Undefined[[]T]{}
+	}
+	return typeutil.NewUndefined(value)
+}
+
+func ptrFromUndefined[T any](value typeutil.Undefined[T]) *T {
+	if !value.Present {
+		return nil
+	}
+	return &value.Val
+}
+
+func sliceFromUndefined[T any](value typeutil.Undefined[[]T]) []T {
+	if !value.Present {
+		return nil
+	}
+	if value.Val == nil {
+		return []T{}
+	}
+	return value.Val
+}
+
 // Settings settings to disable certain features and/or blacklist fields
 // and relations.
 // The generic type is the pointer type of the model.
@@ -81,39 +340,322 @@ type Settings[T any] struct {
 
 	// FieldsSearch allows search for these fields
 	FieldsSearch []string
-	// SearchOperator is used by the search scope, by default it use the $cont operator
+	// SearchOperator is used by the search scope, by default it use the $cont operator.
+	// It is also used by filters using the "$search" operator.
 	SearchOperator *Operator
+	// SearchOperators if not nil, the operator used by the search scope and the "$search"
+	// operator for fields of a specific data type (e.g. "$eq" for enums, "$day" for times).
+	// Fields of a data type not in this map use `SearchOperator`.
+	SearchOperators map[DataType]*Operator
 
 	Blacklist
 
+	// ParamNames the names of the query parameters used by `NewValidatedRequest()` to create
+	// the request and by `Check()` to identify the parameters of the issues. Empty names fall
+	// back to `DefaultParamNames`.
+	ParamNames ParamNames
+
 	// DisableFields ignore the "fields" query if true.
 	DisableFields bool
+	// AutoPruneSelects if true, the columns tagged with `filterSelect:"lazy"` (e.g. blobs or long texts)
+	// are not selected by default when the "fields" query is absent or ignored. They are still selected
+	// if they are explicitly requested in the "fields" query. Primary keys are never pruned.
+	AutoPruneSelects bool
+	// KeyFields defines what happens when the "fields" query excludes the primary key or the
+	// foreign keys of the model, which are needed to associate the joined relations with the records.
+	// Defaults to `KeyFieldsAdd`.
+	KeyFields KeyFieldsMode
+	// DistinctOn the columns allowed in the "distinct_on" query, used to list a single record
+	// per group of identical values (e.g. the latest order of each customer) with
+	// `SELECT DISTINCT ON (columns)`. The `ORDER BY` clause starts with these columns, followed by
+	// the other sorts. The total of paginated results is the number of groups.
+	// Only supported by PostgreSQL. If empty, the "distinct_on" query is ignored.
+	DistinctOn []string
+	// UpdatedAtField the column of the model (e.g. "updated_at") storing the time of the last
+	// update of each record. If not empty, the "updated_since" query only lists the records
+	// changed after the given time (`updated_at > ?`), combined with the other filters, to
+	// support client-side synchronization. If the request has no sort, the records are sorted
+	// by this column. If empty, the "updated_since" query is ignored.
+	UpdatedAtField string
+	// DeletionFeed if not nil, provides the records deleted since the time of the "updated_since"
+	// query. Only used by `Scope()` if `UpdatedAtField` is enabled. See `DeletionFeed`.
+	DeletionFeed DeletionFeed
 	// DisableFilter ignore the "filter" query if true.
 	DisableFilter bool
 	// DisableSort ignore the "sort" query if true.
 	DisableSort bool
+	// InvalidSort defines what happens when a requested sort cannot be applied because
+	// its field doesn't exist or is blacklisted. Defaults to `InvalidSortIgnore`.
+	InvalidSort InvalidSortMode
 	// DisableJoin ignore the "join" query if true.
 	DisableJoin bool
 	// DisableSearch ignore the "search" query if true.
 	DisableSearch bool
+	// DisableSearchJoins if true, the search fields referencing a relation (e.g. "Relation.name")
+	// are ignored instead of joining the relation. The search on the fields of the root model
+	// stays enabled.
+	DisableSearchJoins bool
 
 	// CaseInsensitiveSort if true, the sort will wrap the value in `LOWER()` if it's a string,
 	// resulting in `ORDER BY LOWER(column)`.
 	CaseInsensitiveSort bool
+
+	// CaseInsensitiveFilter if true, the "$eq", "$cont", "$starts", "$startsany" and "$ends" filters
+	// on text fields ignore the case. `ILIKE` is used if the dialect supports it (see `Dialect.ILike`),
+	// otherwise both sides of the comparison are wrapped in `LOWER()`.
+	CaseInsensitiveFilter bool
+
+	// OrStandaloneMode defines how the "or" filters are combined when the request doesn't
+	// contain any "filter". Defaults to `OrStandaloneGroup`.
+	OrStandaloneMode OrStandaloneMode
+
+	// UnicodeNormalization the Unicode normalization form applied to the filter and search
+	// arguments before they are bound to the query, so composed and decomposed characters
+	// (e.g. "é" as one code point or as "e" followed by a combining accent) match the same
+	// values. The data stored in the database should be normalized using the same form.
+	// Defaults to `UnicodeNormalizationNone`.
+	UnicodeNormalization UnicodeNormalization
+
+	// BoolLiterals if not nil, provides the literals accepted as arguments of the filters
+	// on boolean fields, replacing the default ones ("1", "on", "true", "yes" and "0", "off",
+	// "false", "no"). Use `StrictBoolLiterals` to only accept "true" and "false", or implement
+	// `BoolLiteralsProvider` to accept localized literals depending on the query's context.
+	BoolLiterals BoolLiteralsProvider
+
+	// NormalizeSearchWhitespace if true, the leading and trailing whitespace of the search query
+	// is removed and consecutive whitespace characters are collapsed into a single space before
+	// the query is given to the search operators. A search query containing only whitespace is ignored.
+	// The arguments of the filters using the "$search" operator are normalized in the same way.
+	NormalizeSearchWhitespace bool
+	// LowercaseSearch if true, the search query and the arguments of the filters using the
+	// "$search" operator are converted to lower case before they are given to the search operators.
+	// Only use it if the search operators ignore the case, otherwise values containing upper case
+	// characters won't match.
+	LowercaseSearch bool
+
+	// KeysetIteration if true, `ScopeIterator()` fetches the batches using keyset (seek)
+	// pagination instead of `OFFSET`: each batch starts right after the last record of the
+	// previous one, so the iteration stays stable if records are inserted or deleted meanwhile.
+	// The records are sorted by the requested sorts followed by the primary key. Nullable
+	// sort columns are ordered by `(col IS NULL, col)`: `NULL` values come last in ascending
+	// order and first in descending order. Falls back to `OFFSET` if a sort references a relation
+	// or is case-insensitive, or if a sort column or the primary key is not selected.
+	KeysetIteration bool
+
+	// MaxJoins if greater than 0, limits the number of relations a single request
+	// can join. Nested relations are expanded and counted once: "Relation.Parent" and
+	// "Relation" joins two relations in total. If the limit is exceeded, the request
+	// results in an error wrapping `ErrTooManyJoins`.
+	MaxJoins int
+
+	// AutoJoinBelongsTo if true, preloads all the "belongs to" relations of the model
+	// that are not blacklisted when the request doesn't join any relation, or when
+	// `DisableJoin` is true. All the selectable fields of the relations are selected.
+	AutoJoinBelongsTo bool
+
+	// RelationSubqueries if true, the filters on relation fields are applied using a subquery
+	// (`pk IN (SELECT pk FROM table LEFT JOIN relation ... WHERE condition)`) instead of joining
+	// the relation in the main query. The main query then cannot contain duplicated rows or
+	// ambiguous columns because of these filters. Filters using an operator with a `Scope`
+	// and filters on models without exactly one primary key still use joins.
+	RelationSubqueries bool
+
+	// SubqueryRelations overrides `RelationSubqueries` for specific relations, identified
+	// by the first relation of the filtered field's path (e.g. "Author" for "Author.Company.name").
+	SubqueryRelations map[string]bool
+
+	// MaxPreloadRows if greater than 0, limits the number of records each "has many" or
+	// "many to many" preload can load, for all the records of the page. The preload queries are
+	// limited so they never load more than `MaxPreloadRows + 1` records. If the limit is exceeded,
+	// the request results in an error wrapping `ErrTooManyPreloadRows`.
+	MaxPreloadRows int
+
+	// MaxArgLength if greater than 0, the maximum length in characters of the filter
+	// arguments and of the search query. Requests containing a longer value result
+	// in an error wrapping `ErrArgTooLong`, before the value is bound to the query.
+	// The conditions built in code (`Request.Condition`) are not checked. The values exceeding
+	// the limit are also reported as issues by `Check()`. See `filter.MaxArgLength` to enforce
+	// a limit during validation.
+	MaxArgLength int
+
+	// FieldMaxArgLength the maximum length in characters of the arguments of the filters
+	// on specific fields (e.g. "name" or "Author.name"), overriding `MaxArgLength`.
+	// 0 means no limit for this field.
+	FieldMaxArgLength map[string]int
+
+	// ComputedFilters fields that are not defined on the model, identified by their name,
+	// that can be used in filters only. They take precedence over the fields of the model
+	// having the same name. See `ComputedFilter` for more details.
+	ComputedFilters map[string]*ComputedFilter
+
+	// VirtualRelations relations that are not defined on the model, identified by their name,
+	// that can be used in filters, sorts and search. See `VirtualRelation` for more details.
+	VirtualRelations map[string]*VirtualRelation
+
+	// TableSchema if not empty, the table of the model is qualified with this database schema
+	// (PostgreSQL) or database (MySQL): `tenant_1.users`. Overrides the schema of models whose
+	// table name is already qualified. The tables of the relations are not affected: qualify
+	// them in their `TableName()` method if needed.
+	TableSchema string
+
+	// QueryLogger if not nil, the SQL queries executed by the scopes are logged at debug
+	// level using this logger (e.g. the Goyave server's logger). The parameters are
+	// redacted so no sensitive data ends up in the logs. The queries forwarded to the
+	// GORM logger are redacted too.
+	QueryLogger *slog.Logger
+	// QueryLogHashKey if not empty, the parameters of the logged queries are replaced
+	// by their HMAC-SHA256 keyed with this secret instead of being redacted, so identical
+	// values can be correlated without being guessable from the logs. Keep the key secret.
+	QueryLogHashKey []byte
+
+	// SensitiveFields the fields (e.g. "email" or "Author.email") whose filter arguments
+	// must never be echoed back. The issues returned by `Check()` for these fields don't
+	// include the invalid argument values.
+	SensitiveFields []string
+
+	// CountUnfiltered if true, `Scope()` executes a second `COUNT` query ignoring the filters
+	// and search of the request, so responses can show "32 of 1,204 records match". The
+	// conditions already present on the given `*gorm.DB` (e.g. tenant scopes) are kept.
+	// The result is stored in the `UnfilteredTotalSetting` setting of the paginator's DB.
+	CountUnfiltered bool
+
+	// DiagnoseEmpty if true and the filters of the request don't match any record, `Scope()`
+	// executes one more `COUNT` query per filter, applying each filter alone, to report which
+	// conditions eliminate all the records. This helps users of admin UIs debug their own filter
+	// combinations. The result is stored in the `DiagnosticsSetting` setting of the paginator's DB.
+	DiagnoseEmpty bool
+
+	// ShortCircuitFalse if true, `Scope()` doesn't query the database and returns an empty page
+	// if the conditions of the query are always false, for example because a filter argument
+	// cannot be converted to the type of the field (`id||$eq||abc`). Saves the `COUNT` and
+	// `SELECT` round trips.
+	ShortCircuitFalse bool
+
+	// AllowAll if true, clients can request all the matching records in a single page using
+	// `per_page=all`. Otherwise, `per_page=all` is rejected by `Settings.Validation()` and the
+	// scopes return an error wrapping `ErrAllNotAllowed`. Only enable it for trusted consumers
+	// (e.g. internal exports).
+	AllowAll bool
+	// MaxExportRows if greater than 0, limits the number of records returned when all the
+	// records are requested using `per_page=all`. 0 means no limit.
+	MaxExportRows int
+	// ExportThreshold if greater than 0 and `ExportEnqueuer` is not nil, the requests fetching
+	// all the records using `per_page=all` and matching more than this number of records are
+	// not executed inline by `Scope()`: the request is handed off to `ExportEnqueuer` instead,
+	// for example to generate the export in a background job, and `ExportEnqueuedSetting` is set.
+	ExportThreshold int64
+	// ExportEnqueuer receives the exports exceeding `ExportThreshold`.
+	ExportEnqueuer ExportEnqueuer[T]
+
+	// Snapshots if not nil, the pages of the same listing are read from the same database
+	// snapshot (PostgreSQL only), so records inserted, updated or deleted while the client
+	// navigates the pages don't cause duplicated or skipped records. The "snapshot" query
+	// identifies the snapshot, or creates one if its value is `SnapshotNew`. The requests without
+	// "snapshot" query don't use a snapshot. The given `*gorm.DB` must not be in a transaction.
+	// See `Snapshots` for more details.
+	Snapshots *Snapshots
+
+	// SelectivityHints if not nil, the filters of the "filter" query (combined using `AND`)
+	// are sorted so the most selective ones are applied first. This can help some query planners
+	// and makes the queries easier to review. Use `SelectivityHints` for fixed hints per field.
+	SelectivityHints SelectivityHinter
+
+	// OptimizeFilters if true, the groups of filters are rewritten into equivalent smaller
+	// groups before the query is built: identical filters are removed and the ORed "$eq" and
+	// "$in" filters on the same field are merged into a single "$in" filter. This is useful
+	// with machine-generated queries. Groups mixing AND and OR are not modified.
+	OptimizeFilters bool
 }
 
-// Blacklist definition of blacklisted relations and fields.
-type Blacklist struct {
-	Relations map[string]*Blacklist
+// OrStandaloneMode defines how the "or" filters are combined when the request
+// doesn't contain any "filter".
+type OrStandaloneMode int
+
+const (
+	// OrStandaloneGroup the "or" filters are combined with `OR` in a group, which is then
+	// combined with the search and the other conditions using `AND`:
+	// `(a OR b) AND (search)`.
+	OrStandaloneGroup OrStandaloneMode = iota
 
-	// FieldsBlacklist prevent the fields in this list to be selected or to
-	// be used in filters and sorts.
-	FieldsBlacklist []string
-	// RelationsBlacklist prevent joining the relations in this list.
-	RelationsBlacklist []string
+	// OrStandaloneWithSearch the search is one more alternative of the "or" filters:
+	// `(a OR b OR (search))`. The other conditions (filter groups) are still combined using `AND`.
+	// Has no effect if the request doesn't contain a search.
+	OrStandaloneWithSearch
 
-	// IsFinal if true, prevent joining any relation
-	IsFinal bool
+	// OrStandaloneAnd the "or" filters are considered as regular filters and are combined
+	// with `AND`: `(a AND b) AND (search)`.
+	OrStandaloneAnd
+)
+
+// UnicodeNormalization the Unicode normalization form applied to the filter and search arguments.
+type UnicodeNormalization int
+
+const (
+	// UnicodeNormalizationNone the arguments are not normalized.
+	UnicodeNormalizationNone UnicodeNormalization = iota
+
+	// UnicodeNormalizationNFC canonical composition: "e" followed by a combining
+	// acute accent becomes "é".
+	UnicodeNormalizationNFC
+
+	// UnicodeNormalizationNFKC compatibility composition: in addition to the canonical
+	// composition, compatibility characters are replaced by their equivalent (e.g. the
+	// ligature "ﬁ" becomes "fi" and the full-width "Ａ" becomes "A").
+	UnicodeNormalizationNFKC
+)
+
+// KeyFieldsMode defines what happens when the "fields" query excludes the primary key
+// or the foreign keys of the model.
+type KeyFieldsMode int
+
+const (
+	// KeyFieldsAdd the missing keys are silently added to the selected fields if the query
+	// joins relations. Without joins, the selected fields are left as is.
+	KeyFieldsAdd KeyFieldsMode = iota
+
+	// KeyFieldsReject the request results in an error wrapping `ErrKeyFieldsExcluded` if
+	// it joins relations and the "fields" query excludes keys.
+	KeyFieldsReject
+
+	// KeyFieldsOmit the keys are never added: the selected fields are exactly the requested
+	// ones. If the "fields" query excludes keys, the joins are ignored (including the joins
+	// added by `AutoJoinBelongsTo`).
+	KeyFieldsOmit
+)
+
+// ExportEnqueuer hands off the exports that are too large to be executed inline
+// (see `Settings.ExportThreshold`) to a background job system.
+type ExportEnqueuer[T any] interface {
+	// Enqueue schedules the export of all the records matching the given request, using the
+	// given settings (e.g. `settings.ScopeIterator()` in the job). The context is the context
+	// of the `*gorm.DB` given to the scope. If an error is returned, the scope returns it.
+	Enqueue(ctx context.Context, request *Request, settings *Settings[T]) error
+}
+
+// InvalidSortMode defines what happens when a requested sort cannot be applied
+// because its field doesn't exist or is blacklisted.
+type InvalidSortMode int
+
+const (
+	// InvalidSortIgnore the invalid sorts are silently ignored. The other sorts are applied.
+	InvalidSortIgnore InvalidSortMode = iota
+
+	// InvalidSortReject the request results in an error wrapping `ErrInvalidSort`.
+	InvalidSortReject
+
+	// InvalidSortDefault all the requested sorts are replaced by `DefaultSort`. The fields
+	// of the invalid sorts are stored in the `SortFallbackSetting` statement setting.
+	InvalidSortDefault
+)
+
+func (n UnicodeNormalization) normalize(arg string) string {
+	switch n {
+	case UnicodeNormalizationNFC:
+		return norm.NFC.String(arg)
+	case UnicodeNormalizationNFKC:
+		return norm.NFKC.String(arg)
+	}
+	return arg
 }
 
 var (
@@ -121,9 +663,65 @@ var (
 	// isn't provided.
 	DefaultPageSize = 10
 
+	// DefaultBatchSize the number of records fetched at once by the iterator
+	// returned by `Settings.ScopeIterator()`.
+	DefaultBatchSize = 100
+
 	modelCache = &sync.Map{}
 )
 
+const (
+	// PerPageAll the value of `Request.PerPage` when the client requests all the records
+	// in a single page using `per_page=all`. See `Settings.AllowAll`.
+	PerPageAll = -1
+
+	// RequestHashSetting the key of the GORM statement setting containing the
+	// normalized hash of the filter request (see `Request.NormalizedHash()`).
+	// GORM plugins (metrics, tracing) can read it using `db.Get(filter.RequestHashSetting)`.
+	RequestHashSetting = "goyave-filter:request_hash"
+
+	// ModelSetting the key of the GORM statement setting containing the name of the
+	// model the filter request is applied to.
+	ModelSetting = "goyave-filter:model"
+
+	// UnfilteredTotalSetting the key of the GORM statement setting containing the number
+	// of records (`int64`) before the filters and search of the request are applied.
+	// Only set by `Settings.Scope()` if `Settings.CountUnfiltered` is enabled.
+	UnfilteredTotalSetting = "goyave-filter:unfiltered_total"
+
+	// SortFallbackSetting the key of the GORM statement setting containing the fields
+	// (`[]string`) of the requested sorts that could not be applied and caused the sorts
+	// to be replaced by `DefaultSort`. Only set if `Settings.InvalidSort` is `InvalidSortDefault`.
+	SortFallbackSetting = "goyave-filter:sort_fallback"
+
+	// DeletedSetting the key of the GORM statement setting containing the primary keys
+	// (`[]any`) of the records deleted since the time of the "updated_since" query.
+	// Only set by `Settings.Scope()` if `Settings.DeletionFeed` is not nil.
+	DeletedSetting = "goyave-filter:deleted"
+
+	// SnapshotSetting the key of the GORM statement setting containing the token (`string`)
+	// of the database snapshot used by the query, to be given to the client so it can request
+	// the next pages with the "snapshot" query. Only set by `Settings.Scope()` if
+	// `Settings.Snapshots` is not nil and the request uses a snapshot.
+	SnapshotSetting = "goyave-filter:snapshot"
+
+	// DeprecationsSetting the key of the GORM statement setting containing the messages
+	// (`[]string`) describing the deprecated operators used by the filters of the request
+	// (see `DeprecatedOperators`). Only set if the request uses a deprecated operator.
+	DeprecationsSetting = "goyave-filter:deprecations"
+
+	// DiagnosticsSetting the key of the GORM statement setting containing the number of
+	// records matching each filter alone (`[]*FilterDiagnostic`), in the order of the "filter",
+	// "or" and filter groups queries. Only set by `Settings.Scope()` if `Settings.DiagnoseEmpty`
+	// is enabled and the request doesn't match any record.
+	DiagnosticsSetting = "goyave-filter:diagnostics"
+
+	// ExportEnqueuedSetting the key of the GORM statement setting set to true if the request
+	// fetching all the records exceeded `Settings.ExportThreshold` and was handed off to
+	// `Settings.ExportEnqueuer` instead of being executed. Only set by `Settings.Scope()`.
+	ExportEnqueuedSetting = "goyave-filter:export_enqueued"
+)
+
 func parseModel(db *gorm.DB, model any) (*schema.Schema, error) {
 	return schema.Parse(model, modelCache, db.NamingStrategy)
 }
@@ -138,79 +736,396 @@ func ScopeUnpaginated[T any](db *gorm.DB, request *Request, dest *[]T) *gorm.DB
 	return (&Settings[T]{}).ScopeUnpaginated(db, request, dest)
 }
 
+// Build using the default FilterSettings. See `FilterSettings.Build()` for more details.
+func Build[T any](db *gorm.DB, request *Request, dest *[]T) (*gorm.DB, error) {
+	return (&Settings[T]{}).Build(db, request, dest)
+}
+
+// ScopeIterator using the default FilterSettings. See `FilterSettings.ScopeIterator()` for more details.
+func ScopeIterator[T any](db *gorm.DB, request *Request) iter.Seq2[T, error] {
+	return (&Settings[T]{}).ScopeIterator(db, request)
+}
+
+// ScopeInto apply all filters, sorts and joins defined in the request's data to the given `*gorm.DB`
+// and process pagination in the same way as `Settings.Scope()`. The filters, blacklists and joins
+// are resolved against the model `M`, but the records are scanned by GORM directly into the given
+// `dest` slice of `D`, matching the columns with the fields of `D` (and the joined columns with the
+// relation fields of `D` having the same name). This lets you use DTO types distinct from your
+// models in the upper layers of your application.
+// The "has many" and "many to many" relations cannot be joined if `D` is not `M`, because they
+// are preloaded using the model's relations: the error wraps `ErrUnsupportedPreload`.
+// If settings is nil, the default settings are used.
+// The given request is expected to be validated using `ApplyValidation`.
+func ScopeInto[M any, D any](db *gorm.DB, request *Request, settings *Settings[M], dest *[]D) (*database.Paginator[D], error) {
+	if settings == nil {
+		settings = &Settings[M]{}
+	}
+	return scope(settings, db, request, &[]M{}, dest)
+}
+
 // Scope apply all filters, sorts and joins defined in the request's data to the given `*gorm.DB`
 // and process pagination. Returns the resulting `*database.Paginator`.
+// If the request uses `per_page=all` and `AllowAll` is enabled, all the records are returned in
+// a single page, limited to `MaxExportRows` if defined. If `AllowAll` is disabled, the error
+// wraps `ErrAllNotAllowed`. If the number of records exceeds
+// `ExportThreshold`, the request is handed off to `ExportEnqueuer`: no record is returned and
+// the `ExportEnqueuedSetting` statement setting is set. The paginator still contains the total.
+// If `Snapshots` is not nil, the queries read the snapshot identified by the "snapshot" query,
+// or a new snapshot if its value is `SnapshotNew`. If the snapshot expired, the error wraps
+// `ErrSnapshotExpired`. If too many snapshots are kept, the error wraps `ErrTooManySnapshots`.
 // The given request is expected to be validated using `ApplyValidation`.
 func (s *Settings[T]) Scope(db *gorm.DB, request *Request, dest *[]T) (*database.Paginator[T], error) {
-	page := request.Page.Default(1)
-	pageSize := request.PerPage.Default(DefaultPageSize)
+	return scope(s, db, request, dest, dest)
+}
+
+// scope implements `Settings.Scope()` and `ScopeInto()`. The request is resolved against the
+// model of the given settings, identified by model, and the records are scanned into dest.
+func scope[T, D any](s *Settings[T], db *gorm.DB, request *Request, model *[]T, dest *[]D) (*database.Paginator[D], error) {
+	page, pageSize, all, err := s.pageParams(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshotToken, snapshotID string
+	imported := func() {}
+	if s.Snapshots != nil {
+		snapshotToken, snapshotID, imported, err = s.Snapshots.acquire(db, request.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		defer imported()
+	}
 
-	var paginator *database.Paginator[T]
-	err := db.Transaction(func(tx *gorm.DB) error {
-		tx, schema, hasJoins := s.scopeCommon(tx, request, dest)
+	var paginator *database.Paginator[D]
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if snapshotID != "" {
+			err := useSnapshot(tx, snapshotID)
+			imported()
+			if err != nil {
+				return errors.New(err)
+			}
+			tx = tx.Set(SnapshotSetting, snapshotToken)
+		}
+		unfiltered := tx
+		tx, schema, hasJoins := s.scopeCommon(tx, request, model)
+		if schema == nil {
+			return errors.New(tx.Error)
+		}
+		if s.CountUnfiltered {
+			total, err := s.countUnfiltered(unfiltered, schema, model)
+			if err != nil {
+				return errors.New(err)
+			}
+			tx = tx.Set(UnfilteredTotalSetting, total)
+		}
+		if s.DeletionFeed != nil && s.updatedSinceField(request, schema) != nil {
+			deleted, err := s.DeletionFeed.Deleted(tx.Statement.Context, request.UpdatedSince.Val)
+			if err != nil {
+				return errors.New(err)
+			}
+			tx = tx.Set(DeletedSetting, deleted)
+		}
 
 		paginator = database.NewPaginator(tx, page, pageSize, dest)
-		err := paginator.UpdatePageInfo()
+		if s.ShortCircuitFalse && isAlwaysFalse(tx) {
+			*dest = []D{}
+			paginator.PageSize, paginator.MaxPage = shortCircuitPage(pageSize, all, s.MaxExportRows)
+			return nil
+		}
+		// The records are counted on the model, even if they are scanned into another type
+		total, size, maxPage, err := s.countPages(tx, request, schema, pageSize, all)
 		if err != nil {
 			return errors.New(err)
 		}
+		paginator.Total = total
+		paginator.MaxPage = maxPage
+		if s.DiagnoseEmpty && paginator.Total == 0 {
+			diagnostics, err := s.diagnoseEmpty(unfiltered, request, model)
+			if err != nil {
+				return errors.New(err)
+			}
+			if len(diagnostics) > 0 {
+				tx = tx.Set(DiagnosticsSetting, diagnostics)
+				paginator.DB = tx
+			}
+		}
+		if all && s.ExportEnqueuer != nil && s.ExportThreshold > 0 && paginator.Total > s.ExportThreshold {
+			if err := s.ExportEnqueuer.Enqueue(tx.Statement.Context, request, s); err != nil {
+				return errors.New(err)
+			}
+			*dest = []D{}
+			paginator.DB = tx.Set(ExportEnqueuedSetting, true)
+			return nil
+		}
+		paginator.PageSize = size
 		paginator.DB = s.scopeSort(paginator.DB, request, schema)
 		if fieldsDB := s.scopeFields(paginator.DB, request, schema, hasJoins); fieldsDB != nil {
 			paginator.DB = fieldsDB
 		} else {
 			return errors.New(paginator.DB.Error)
 		}
+		if _, sameType := any(dest).(*[]T); !sameType {
+			paginator.DB = paginator.DB.Scopes(unsupportedPreloadScope)
+		}
 
-		return paginator.Find()
+		// The records are already counted: `paginator.Find()` would count them again
+		paginator.DB = findPage(paginator.DB, paginator.CurrentPage, paginator.PageSize, dest)
+		if paginator.DB.Error != nil {
+			return errors.New(paginator.DB.Error)
+		}
+		return s.checkPreloads(paginator.DB, dest)
 	})
-
 	return paginator, err
 }
 
+// countPages counts the records matching the given query, without its preloads, on the model
+// of the query. Returns the total, the page size, which is the total if all the records are
+// requested and `MaxExportRows` is not set, and the number of pages. Used by `Scope()` and
+// `Batch.Scope()`.
+func (s *Settings[T]) countPages(tx *gorm.DB, request *Request, sch *schema.Schema, pageSize int, all bool) (total int64, size int, maxPage int64, err error) {
+	countDB := markCountQuery(tx).Scopes(func(tx *gorm.DB) *gorm.DB {
+		tx.Statement.Preloads = nil
+		return tx
+	})
+	if distinct := s.distinctOnFields(request, sch); len(distinct) > 0 {
+		// Count the groups instead of the rows
+		countDB = countDB.Scopes(distinctOnGroupScope(sch.Table, distinct))
+	}
+	if err := countDB.Count(&total).Error; err != nil {
+		return 0, 0, 0, err
+	}
+	if all && s.MaxExportRows <= 0 {
+		pageSize = max(int(total), 1)
+	}
+	maxPage = max(int64(math.Ceil(float64(total)/float64(pageSize))), 1)
+	return total, pageSize, maxPage, nil
+}
+
+// shortCircuitPage returns the page size and the number of pages of a query that cannot
+// match any record (see `ShortCircuitFalse`).
+func shortCircuitPage(pageSize int, all bool, maxExportRows int) (size int, maxPage int64) {
+	if all && maxExportRows <= 0 {
+		return 1, 1
+	}
+	return pageSize, 1
+}
+
+// findPage fetches the records of the given page into dest.
+func findPage(tx *gorm.DB, page, pageSize int, dest any) *gorm.DB {
+	return tx.Offset((page - 1) * pageSize).Limit(pageSize).Find(dest)
+}
+
+// unsupportedPreloadScope adds an error wrapping `ErrUnsupportedPreload` to the statement if it
+// preloads relations. Used by `ScopeInto()` if the records are not scanned into the model type,
+// because GORM assigns the preloaded records using the fields of the model.
+func unsupportedPreloadScope(tx *gorm.DB) *gorm.DB {
+	if len(tx.Statement.Preloads) > 0 {
+		names := slices.Sorted(maps.Keys(tx.Statement.Preloads))
+		tx.AddError(errors.Errorf("%w: %s", ErrUnsupportedPreload, strings.Join(names, ", ")))
+	}
+	return tx
+}
+
+// pageParams returns the page and page size of the given request. If the request fetches all
+// the records with `per_page=all`, all is true and the page size is `MaxExportRows`, or
+// `math.MaxInt32` if there is no limit. Returns an error wrapping `ErrAllNotAllowed` if
+// `AllowAll` is disabled.
+func (s *Settings[T]) pageParams(request *Request) (page int, pageSize int, all bool, err error) {
+	page = request.Page.Default(1)
+	pageSize = request.PerPage.Default(DefaultPageSize)
+	if pageSize != PerPageAll {
+		return page, pageSize, false, nil
+	}
+	if !s.AllowAll {
+		return 0, 0, false, errors.New(ErrAllNotAllowed)
+	}
+	return 1, lo.Ternary(s.MaxExportRows > 0, s.MaxExportRows, math.MaxInt32), true, nil
+}
+
 // ScopeUnpaginated apply all filters, sorts and joins defined in the request's data to the given `*gorm.DB`
 // without any pagination.
 // Returns the `*gorm.DB` result, which can be used to check for database errors.
 // The records will be added in the given `dest` slice.
 // The given request is expected to be validated using `ApplyValidation`.
 func (s *Settings[T]) ScopeUnpaginated(db *gorm.DB, request *Request, dest *[]T) *gorm.DB {
+	db, err := s.Build(db, request, dest)
+	if err != nil {
+		return db
+	}
+	db = db.Find(dest)
+	if db.Error == nil {
+		if err := s.checkPreloads(db, dest); err != nil {
+			db.AddError(err)
+		}
+	}
+	return db
+}
+
+// Build apply all filters, sorts, joins and selected fields defined in the request's data to the
+// given `*gorm.DB` and returns the prepared `*gorm.DB` without executing it and without pagination.
+// Additional clauses can then be chained before executing the query (e.g. with `Find(dest)`).
+// The returned `*gorm.DB` is a new session, so it can be executed several times.
+//
+// Returns an error if the model cannot be parsed or if the fields cannot be selected. In this case,
+// the error is also added to the returned `*gorm.DB`. Errors occurring while applying the scopes
+// are only reported when the query is executed.
+// The given request is expected to be validated using `ApplyValidation`.
+func (s *Settings[T]) Build(db *gorm.DB, request *Request, dest *[]T) (*gorm.DB, error) {
 	db, schema, hasJoins := s.scopeCommon(db, request, dest)
+	if schema == nil {
+		return db, errors.New(db.Error)
+	}
 	db = s.scopeSort(db, request, schema)
-	if fieldsDB := s.scopeFields(db, request, schema, hasJoins); fieldsDB != nil {
-		db = fieldsDB
-	} else {
-		return db
+	fieldsDB := s.scopeFields(db, request, schema, hasJoins)
+	if fieldsDB == nil {
+		return db, errors.New(db.Error)
+	}
+	return fieldsDB.Session(&gorm.Session{}), nil
+}
+
+// ScopeIterator apply all filters, sorts and joins defined in the request's data to the given `*gorm.DB`
+// and returns an iterator lazily yielding the resulting records. The records are fetched from the
+// database in batches of `DefaultBatchSize` records so the entire result set is never loaded in memory.
+// The "page" and "per_page" options are ignored.
+//
+// Batches are fetched using LIMIT and OFFSET: make sure the records are sorted in a deterministic
+// order (using `DefaultSort` for example) so no record is skipped or yielded twice, or enable
+// `KeysetIteration`.
+//
+// If an error occurs, it is yielded with the zero value of `T` and the iteration stops.
+// The given request is expected to be validated using `ApplyValidation`.
+func (s *Settings[T]) ScopeIterator(db *gorm.DB, request *Request) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		db, schema, hasJoins := s.scopeCommon(db, request, &[]T{})
+		if schema == nil {
+			yield(zero, errors.New(db.Error))
+			return
+		}
+		var keys *keyset
+		if s.KeysetIteration {
+			keys = s.newKeyset(request, schema)
+		}
+		if keys != nil {
+			db = db.Scopes(keys.orderScope())
+		} else {
+			db = s.scopeSort(db, request, schema)
+		}
+		if fieldsDB := s.scopeFields(db, request, schema, hasJoins); fieldsDB != nil {
+			db = fieldsDB.Session(&gorm.Session{})
+		} else {
+			yield(zero, errors.New(db.Error))
+			return
+		}
+
+		var seek func(*gorm.DB) *gorm.DB
+		for offset := 0; ; offset += DefaultBatchSize {
+			batch := make([]T, 0, DefaultBatchSize)
+			tx := db
+			if keys == nil {
+				tx = tx.Offset(offset)
+			} else if seek != nil {
+				tx = tx.Scopes(seek)
+			}
+			tx = tx.Limit(DefaultBatchSize).Find(&batch)
+			if tx.Error != nil {
+				yield(zero, errors.New(tx.Error))
+				return
+			}
+			if err := s.checkPreloads(tx, &batch); err != nil {
+				yield(zero, err)
+				return
+			}
+			if keys != nil && len(batch) > 0 {
+				seek = keys.seekScope(db.Statement.Context, batch[len(batch)-1])
+			}
+			for _, record := range batch {
+				if !yield(record, nil) {
+					return
+				}
+			}
+			if len(batch) < DefaultBatchSize {
+				return
+			}
+		}
 	}
-	return db.Find(dest)
 }
 
 // scopeCommon applies all scopes common to both the paginated and non-paginated requests.
 // The third returned valued indicates if the query contains joins.
+// If the model cannot be parsed, the returned schema is nil and the error
+// is added to the returned `*gorm.DB`.
 func (s *Settings[T]) scopeCommon(db *gorm.DB, request *Request, dest any) (*gorm.DB, *schema.Schema, bool) {
+	if err := s.checkArgLength(request); err != nil {
+		db = db.Session(&gorm.Session{})
+		db.AddError(err)
+		return db, nil, false
+	}
 	schema, err := parseModel(db, dest)
 	if err != nil {
-		panic(errors.New(err))
+		db = db.Session(&gorm.Session{})
+		db.AddError(errors.Errorf("%w: %w", ErrUnsupportedModel, err))
+		return db, nil, false
+	}
+	if s.TableSchema != "" {
+		schema = withTableSchema(schema, s.TableSchema)
+		db = db.Table(schema.Table)
 	}
 
-	db = db.Model(dest)
-	db = s.applyFilters(db, request, schema)
+	db = s.applyQueryLogger(db)
+	db = db.Model(dest).
+		Set(RequestHashSetting, request.NormalizedHash()).
+		Set(ModelSetting, schema.Name)
+	if deprecations := deprecationMessages(request); len(deprecations) > 0 && !s.DisableFilter {
+		db = db.Set(DeprecationsSetting, deprecations)
+	}
+	modelSchema := schema
+	schema, err = withVirtualRelations(db, schema, s.VirtualRelations)
+	if err != nil {
+		db.AddError(err)
+		return db, nil, false
+	}
+	if s.InvalidSort == InvalidSortReject {
+		if invalid := s.invalidSorts(request, schema); len(invalid) > 0 {
+			db.AddError(errors.Errorf("%w: %s", ErrInvalidSort, strings.Join(invalid, ", ")))
+			return db, nil, false
+		}
+	}
+	joins := &joinPaths{}
+	db = db.Scopes(joins.scope(schema))
+	db = s.applyFilters(db, request, schema, joins)
+	if field := s.updatedSinceField(request, modelSchema); field != nil {
+		db = db.Scopes(updatedSinceScope(modelSchema.Table, field, request.UpdatedSince.Val))
+	}
 
 	hasJoins := false
-	if !s.DisableJoin && request.Join.Present {
-		joins := request.Join.Val
+	if joins := s.requestJoins(request, modelSchema); len(joins) > 0 && !s.omitJoins(request, schema) {
+		if count := countJoins(joins); s.MaxJoins > 0 && count > s.MaxJoins {
+			db.AddError(errors.Errorf("%w: the request would join %d relations, the maximum is %d", ErrTooManyJoins, count, s.MaxJoins))
+			return db, schema, false
+		}
 		selectCache := map[string][]string{}
+		trashedCache := map[string]bool{}
+		paths := lo.Map(joins, func(j *Join, _ int) string { return j.Relation })
 		for _, j := range joins {
 			hasJoins = true
 			j.selectCache = selectCache
-			if s := j.Scopes(s.Blacklist, schema); s != nil {
+			j.trashedCache = trashedCache
+			j.pathsCache = paths
+			if s := j.Scopes(s.Blacklist, modelSchema); s != nil {
 				db = db.Scopes(s...)
 			}
 		}
 	}
+	if s.MaxPreloadRows > 0 {
+		db = db.Scopes(preloadLimitScope(s.MaxPreloadRows, modelSchema))
+	}
 
-	if !s.DisableSearch && request.Search.Present {
+	if !s.DisableSearch && request.Search.Present && !s.searchInOrGroup(request) {
 		if search := s.applySearch(request.Search.Val, schema); search != nil {
-			if scope := search.Scope(schema); scope != nil {
-				db = db.Scopes(scope)
+			if _, conditionScope := search.scopes(schema); conditionScope != nil {
+				joins.add(search.joinNames(schema)...)
+				db = db.Scopes(conditionScope)
 			}
 		}
 	}
@@ -221,37 +1136,191 @@ func (s *Settings[T]) scopeCommon(db *gorm.DB, request *Request, dest any) (*gor
 		processJoinsComputedColumns(tx.Statement, schema)
 		return tx
 	})
+	if len(s.distinctOnFields(request, schema)) == 0 {
+		db = db.Scopes(duplicateJoinsScope(schema))
+	}
 
 	return db, schema, hasJoins
 }
 
+// checkArgLength returns an error wrapping `ErrArgTooLong` if an argument of the filters
+// or the search query of the given request exceeds `MaxArgLength` or `FieldMaxArgLength`.
+// The values are not included in the error so they are not logged.
+func (s *Settings[T]) checkArgLength(request *Request) error {
+	if s.MaxArgLength <= 0 && len(s.FieldMaxArgLength) == 0 {
+		return nil
+	}
+	filters := slices.Concat(request.Filter.Val, request.Or.Val, lo.Flatten(request.FilterGroups.Val))
+	for _, f := range filters {
+		if length, limit, ok := s.filterArgTooLong(f); ok {
+			return errors.Errorf("%w: an argument of the filter on %q is %d characters long, the maximum is %d", ErrArgTooLong, f.Field, length, limit)
+		}
+	}
+	if length, ok := s.searchTooLong(request); ok {
+		return errors.Errorf("%w: the search query is %d characters long, the maximum is %d", ErrArgTooLong, length, s.MaxArgLength)
+	}
+	return nil
+}
+
+// filterArgTooLong returns the length of the first argument of the given filter exceeding
+// `MaxArgLength` or `FieldMaxArgLength`, the limit it exceeds, and true if there is one.
+func (s *Settings[T]) filterArgTooLong(f *Filter) (length int, limit int, tooLong bool) {
+	limit, ok := s.FieldMaxArgLength[f.Field]
+	if !ok {
+		limit = s.MaxArgLength
+	}
+	if limit <= 0 {
+		return 0, 0, false
+	}
+	for _, arg := range f.Args {
+		if length := utf8.RuneCountInString(arg); length > limit {
+			return length, limit, true
+		}
+	}
+	return 0, 0, false
+}
+
+// searchTooLong returns the length of the search query of the given request and true
+// if it exceeds `MaxArgLength`.
+func (s *Settings[T]) searchTooLong(request *Request) (int, bool) {
+	if s.MaxArgLength <= 0 || !request.Search.Present {
+		return 0, false
+	}
+	length := utf8.RuneCountInString(request.Search.Val)
+	return length, length > s.MaxArgLength
+}
+
+// checkPreloads returns an error wrapping `ErrTooManyPreloadRows` if a preload of the
+// given executed statement exceeded `MaxPreloadRows`.
+func (s *Settings[T]) checkPreloads(db *gorm.DB, dest any) error {
+	if s.MaxPreloadRows <= 0 || db.Statement.Schema == nil {
+		return nil
+	}
+	return checkPreloadRows(db, s.MaxPreloadRows, db.Statement.Schema, dest)
+}
+
+// requestJoins returns the joins of the request. If the request doesn't join any relation
+// and `AutoJoinBelongsTo` is enabled, returns a join for each allowed "belongs to" relation.
+func (s *Settings[T]) requestJoins(request *Request, sch *schema.Schema) []*Join {
+	if !s.DisableJoin && request.Join.Present && len(request.Join.Val) > 0 {
+		return request.Join.Val
+	}
+	if !s.AutoJoinBelongsTo {
+		return nil
+	}
+	blacklist := newBlacklistPath(&s.Blacklist)
+	joins := []*Join{}
+	for _, r := range sch.Relationships.BelongsTo {
+		if !blacklist.isDenied(r) {
+			joins = append(joins, &Join{Relation: r.Name})
+		}
+	}
+	return joins
+}
+
+// omitJoins returns true if the joins must be ignored because the "fields" query
+// excludes keys and `KeyFields` is `KeyFieldsOmit`.
+func (s *Settings[T]) omitJoins(request *Request, sch *schema.Schema) bool {
+	return s.KeyFields == KeyFieldsOmit && !s.DisableFields && request.Fields.Present &&
+		len(missingKeys(sch, request.Fields.Val, s.FieldsBlacklist)) > 0
+}
+
+// applyQueryLogger returns a new session logging the queries using `QueryLogger`.
+// Returns the given DB as is if `QueryLogger` is nil.
+func (s *Settings[T]) applyQueryLogger(db *gorm.DB) *gorm.DB {
+	if s.QueryLogger == nil {
+		return db
+	}
+	return db.Session(&gorm.Session{Logger: &queryLogger{Interface: db.Logger, logger: s.QueryLogger, hashKey: s.QueryLogHashKey}})
+}
+
+// countUnfiltered counts the records of the model matching the conditions already
+// present in the given DB (e.g. tenant scopes), ignoring the filters and search of the request.
+func (s *Settings[T]) countUnfiltered(db *gorm.DB, sch *schema.Schema, dest any) (int64, error) {
+	db = s.applyQueryLogger(db.Session(&gorm.Session{}))
+	if s.TableSchema != "" {
+		db = db.Table(sch.Table)
+	}
+	var total int64
+	err := db.Model(dest).Count(&total).Error
+	return total, err
+}
+
+// countJoins returns the number of distinct relations joined by the given joins,
+// including the intermediate relations of nested joins.
+func countJoins(joins []*Join) int {
+	paths := map[string]struct{}{}
+	for _, j := range joins {
+		for i, c := range j.Relation {
+			if c == '.' {
+				paths[j.Relation[:i]] = struct{}{}
+			}
+		}
+		paths[j.Relation] = struct{}{}
+	}
+	return len(paths)
+}
+
 func (s *Settings[T]) scopeFields(db *gorm.DB, request *Request, schema *schema.Schema, hasJoins bool) *gorm.DB {
 	if !s.DisableFields && request.Fields.Present {
 		fields := slices.Clone(request.Fields.Val)
 		if hasJoins {
 			if len(schema.PrimaryFieldDBNames) == 0 {
-				db.AddError(errors.New("could not find primary key. Add `gorm:\"primaryKey\"` to your model"))
+				db.AddError(errors.New(ErrNoPrimaryKey))
 				return nil
 			}
+			if s.KeyFields == KeyFieldsReject {
+				if missing := missingKeys(schema, fields, s.FieldsBlacklist); len(missing) > 0 {
+					db.AddError(errors.Errorf("%w: %s", ErrKeyFieldsExcluded, strings.Join(missing, ", ")))
+					return nil
+				}
+			}
 			fields = addPrimaryKeys(schema, fields)
 			fields = addForeignKeys(schema, fields)
 		}
 		return db.Scopes(selectScope(schema.Table, cleanColumns(schema, fields, s.FieldsBlacklist), false))
 	}
-	return db.Scopes(selectScope(schema.Table, getSelectableFields(&s.Blacklist, schema), false))
+	fields := getSelectableFields(&s.Blacklist, schema)
+	if s.AutoPruneSelects {
+		fields = pruneLazyFields(fields)
+	}
+	return db.Scopes(selectScope(schema.Table, fields, false))
 }
 
 func (s *Settings[T]) scopeSort(db *gorm.DB, request *Request, schema *schema.Schema) *gorm.DB {
 	var sorts []*Sort
-	if !request.Sort.Present {
-		sorts = s.DefaultSort
-	} else {
-		sorts = request.Sort.Val
+	if !s.DisableSort {
+		sorts = request.Sort.Default(s.DefaultSort)
+		if field := s.updatedSinceField(request, schema); field != nil && !request.Sort.Present {
+			sorts = []*Sort{{Field: field.DBName, Order: SortAscending}}
+		}
+		if s.InvalidSort == InvalidSortDefault {
+			if invalid := s.invalidSorts(request, schema); len(invalid) > 0 {
+				sorts = s.DefaultSort
+				db = db.Set(SortFallbackSetting, invalid)
+			}
+		}
 	}
 
-	if !s.DisableSort {
+	if distinct := s.distinctOnFields(request, schema); len(distinct) > 0 {
+		var leading []*Sort
+		leading, sorts = s.distinctOnSorts(distinct, sorts, schema)
+		db = db.Scopes(distinctOnScope(schema.Table, distinct))
+		for _, sort := range leading {
+			// Never case-insensitive: the expressions must match the DISTINCT ON columns.
+			db = db.Scopes(sort.scope(s.Blacklist, schema, false, false))
+		}
+	}
+
+	if len(sorts) > 0 {
+		// The relations already joined by the filters or the search are not joined again.
+		// The sorts are joined separately so their joins don't affect the count query.
+		joins := &joinPaths{}
+		db = db.Scopes(joins.scope(schema))
 		for _, sort := range sorts {
-			if scope := sort.Scope(s.Blacklist, schema, s.CaseInsensitiveSort); scope != nil {
+			if scope := sort.scope(s.Blacklist, schema, s.CaseInsensitiveSort, false); scope != nil {
+				_, _, joinName := getField(sort.Field, schema, &s.Blacklist)
+				joins.add(joinName)
 				db = db.Scopes(scope)
 			}
 		}
@@ -259,49 +1328,217 @@ func (s *Settings[T]) scopeSort(db *gorm.DB, request *Request, schema *schema.Sc
 	return db
 }
 
-func (s *Settings[T]) applyFilters(db *gorm.DB, request *Request, schema *schema.Schema) *gorm.DB {
+// invalidSorts returns the fields of the requested sorts that cannot be applied because
+// they don't exist or are blacklisted. Returns nil if sorts are disabled.
+func (s *Settings[T]) invalidSorts(request *Request, sch *schema.Schema) []string {
+	if s.DisableSort {
+		return nil
+	}
+	var invalid []string
+	for _, sort := range request.Sort.Default(nil) {
+		if field, _, _ := getField(sort.Field, sch, &s.Blacklist); field == nil {
+			invalid = append(invalid, sort.Field)
+		}
+	}
+	return invalid
+}
+
+// applyFilters applies the filters of the request. The relations referenced by the filters
+// are added to the given join paths. If joins is nil, the relations are joined by applyFilters itself.
+func (s *Settings[T]) applyFilters(db *gorm.DB, request *Request, schema *schema.Schema, joins *joinPaths) *gorm.DB {
 	if s.DisableFilter {
 		return db
 	}
+	if joins == nil {
+		joins = &joinPaths{}
+		db = db.Scopes(joins.scope(schema))
+	}
 	filterScopes := make([]func(*gorm.DB) *gorm.DB, 0, 2)
-	joinScopes := make([]func(*gorm.DB) *gorm.DB, 0, 2)
+	var operatorScopes []func(*gorm.DB) *gorm.DB
+	var boolLiterals *BoolLiterals
+	if s.BoolLiterals != nil {
+		boolLiterals = s.BoolLiterals.BoolLiterals(db.Statement.Context)
+	}
 
 	andLen := len(request.Filter.Default([]*Filter{}))
 	orLen := len(request.Or.Default([]*Filter{}))
 	mixed := orLen > 1 && andLen > 0
+	orStandalone := orLen > 0 && andLen == 0
 
-	for _, filters := range []typeutil.Undefined[[]*Filter]{request.Filter, request.Or} {
-		if filters.Present {
-			group := make([]func(*gorm.DB) *gorm.DB, 0, 4)
-			for _, f := range filters.Val {
-				if mixed {
+	groupScopes := func(filters []*Filter, mixed bool) []func(*gorm.DB) *gorm.DB {
+		adjusted := make([]*Filter, 0, len(filters))
+		for _, f := range filters {
+			if mixed {
+				f = &Filter{
+					Field:    f.Field,
+					Operator: f.Operator,
+					Args:     f.Args,
+					Or:       false,
+				}
+			}
+			if s.CaseInsensitiveFilter {
+				if operator := caseInsensitiveOperator(f.Operator); operator != f.Operator {
 					f = &Filter{
 						Field:    f.Field,
-						Operator: f.Operator,
+						Operator: operator,
 						Args:     f.Args,
-						Or:       false,
+						Or:       f.Or,
 					}
 				}
-				joinScope, conditionScope := f.Scope(s.Blacklist, schema)
-				if conditionScope != nil {
-					group = append(group, conditionScope)
+			}
+			if s.UnicodeNormalization != UnicodeNormalizationNone {
+				f = &Filter{
+					Field:    f.Field,
+					Operator: f.Operator,
+					Args:     lo.Map(f.Args, func(arg string, _ int) string { return s.UnicodeNormalization.normalize(arg) }),
+					Or:       f.Or,
+				}
+			}
+			if boolLiterals != nil {
+				f = boolLiterals.normalize(f, schema, &s.Blacklist)
+			}
+			if f.Operator == Operators["$search"] {
+				f = &Filter{
+					Field:    f.Field,
+					Operator: f.Operator,
+					Args:     lo.Map(f.Args, func(arg string, _ int) string { return s.normalizeSearch(arg) }),
+					Or:       f.Or,
+				}
+				if s.NormalizeSearchWhitespace && lo.Every([]string{""}, f.Args) {
+					// Ignored like a search query containing only whitespace
+					continue
+				}
+			}
+			if (s.SearchOperator != nil || s.SearchOperators != nil) && f.Operator == Operators["$search"] {
+				search := &Search{
+					Operator:  lo.CoalesceOrEmpty(s.SearchOperator, Operators["$cont"]),
+					Operators: s.SearchOperators,
 				}
-				if joinScope != nil {
-					joinScopes = append(joinScopes, joinScope)
+				f = &Filter{
+					Field:    f.Field,
+					Operator: searchFilterOperator(search),
+					Args:     f.Args,
+					Or:       f.Or,
 				}
 			}
-			filterScopes = append(filterScopes, groupFilters(group, false))
+			adjusted = append(adjusted, f)
 		}
+		if s.OptimizeFilters {
+			adjusted = optimizeFilters(adjusted, schema, &s.Blacklist)
+		}
+
+		group := make([]func(*gorm.DB) *gorm.DB, 0, 4)
+		for _, f := range adjusted {
+			if computed, ok := s.ComputedFilters[f.Field]; ok {
+				expr, err := computed.expression(db.Statement, schema.Table)
+				if err != nil {
+					db.AddError(errors.Errorf("computed filter %q: %w", f.Field, err))
+					continue
+				}
+				group = append(group, computed.scope(expr, f, schema.Table))
+				continue
+			}
+			if s.useSubquery(f) {
+				if subqueryScope := f.subqueryScope(s.Blacklist, schema); subqueryScope != nil {
+					group = append(group, subqueryScope)
+					continue
+				}
+			}
+			_, conditionScope := f.Scope(s.Blacklist, schema)
+			if conditionScope != nil {
+				group = append(group, conditionScope)
+				joinName, operatorScope := f.mainQuery(s.Blacklist, schema)
+				joins.add(joinName)
+				if operatorScope != nil {
+					operatorScopes = append(operatorScopes, operatorScope)
+				}
+			}
+		}
+		return group
+	}
+
+	andFilters := request.Filter
+	if s.SelectivityHints != nil && andFilters.Present {
+		andFilters = typeutil.NewUndefined(sortBySelectivity(andFilters.Val, s.SelectivityHints))
+	}
+	if andFilters.Present {
+		filterScopes = append(filterScopes, groupFilters(groupScopes(andFilters.Val, mixed), false))
+	}
+	if request.Or.Present {
+		orScopes := groupScopes(request.Or.Val, mixed || (orStandalone && s.OrStandaloneMode == OrStandaloneAnd))
+		if s.searchInOrGroup(request) {
+			if search := s.applySearch(request.Search.Val, schema); search != nil {
+				_, searchCondition := search.scopes(schema)
+				if searchCondition != nil {
+					joins.add(search.joinNames(schema)...)
+					orScopes = append(orScopes, func(tx *gorm.DB) *gorm.DB {
+						return tx.Or(searchCondition(tx.Session(&gorm.Session{NewDB: true})))
+					})
+				}
+			}
+		}
+		filterScopes = append(filterScopes, groupFilters(orScopes, false))
+	}
+
+	// Filter groups are ANDed with the other filters. The filters inside a group are ORed.
+	indexedGroups := make([]func(*gorm.DB) *gorm.DB, 0, len(request.FilterGroups.Val))
+	for _, filters := range request.FilterGroups.Default(nil) {
+		filters = lo.Map(filters, func(f *Filter, _ int) *Filter {
+			if f.Or {
+				return f
+			}
+			return &Filter{Field: f.Field, Operator: f.Operator, Args: f.Args, Or: true}
+		})
+		indexedGroups = append(indexedGroups, groupFilters(groupScopes(filters, false), true))
+	}
+
+	var condition func(*gorm.DB) *gorm.DB
+	if request.Condition != nil {
+		condition = conditionScope(request.Condition.Node(), func(f *Filter) func(*gorm.DB) *gorm.DB {
+			if scopes := groupScopes([]*Filter{f}, false); len(scopes) > 0 {
+				return scopes[0]
+			}
+			return nil
+		})
 	}
-	if len(joinScopes) > 0 {
-		db = db.Scopes(joinScopes...)
+
+	if len(operatorScopes) > 0 {
+		db = db.Scopes(operatorScopes...)
 	}
 	if len(filterScopes) > 0 {
 		db = db.Scopes(groupFilters(filterScopes, true))
 	}
+	if len(indexedGroups) > 0 {
+		db = db.Scopes(indexedGroups...)
+	}
+	if condition != nil {
+		db = db.Scopes(condition)
+	}
 	return db
 }
 
+// useSubquery returns true if the given filter should be applied using a subquery instead
+// of a join (see `RelationSubqueries` and `SubqueryRelations`).
+func (s *Settings[T]) useSubquery(f *Filter) bool {
+	relation, _, ok := strings.Cut(f.Field, ".")
+	if !ok {
+		return false
+	}
+	if subquery, ok := s.SubqueryRelations[relation]; ok {
+		return subquery
+	}
+	return s.RelationSubqueries
+}
+
+// searchInOrGroup returns true if the search of the given request is one of the
+// alternatives of the "or" filters (see `OrStandaloneWithSearch`) instead of being
+// applied separately.
+func (s *Settings[T]) searchInOrGroup(request *Request) bool {
+	return s.OrStandaloneMode == OrStandaloneWithSearch &&
+		!s.DisableFilter && !s.DisableSearch && request.Search.Present &&
+		len(request.Or.Default(nil)) > 0 && len(request.Filter.Default(nil)) == 0
+}
+
 func groupFilters(scopes []func(*gorm.DB) *gorm.DB, and bool) func(*gorm.DB) *gorm.DB {
 	return func(tx *gorm.DB) *gorm.DB {
 		processedFilters := tx.Session(&gorm.Session{NewDB: true})
@@ -323,21 +1560,45 @@ func (s *Settings[T]) applySearch(query string, schema *schema.Schema) *Search {
 			fields = append(fields, f.DBName)
 		}
 	}
+	if s.DisableSearchJoins {
+		fields = lo.Reject(fields, func(f string, _ int) bool {
+			return strings.Contains(f, ".")
+		})
+	}
 
 	operator := s.SearchOperator
 	if operator == nil {
 		operator = Operators["$cont"]
 	}
 
+	query = s.normalizeSearch(query)
+	if query == "" && s.NormalizeSearchWhitespace {
+		return nil
+	}
+
 	search := &Search{
-		Query:    query,
-		Operator: operator,
-		Fields:   fields,
+		Query:     query,
+		Operator:  operator,
+		Operators: s.SearchOperators,
+		Fields:    fields,
 	}
 
 	return search
 }
 
+// normalizeSearch applies the Unicode, whitespace and case normalizations
+// enabled in the settings to the given search query.
+func (s *Settings[T]) normalizeSearch(query string) string {
+	query = s.UnicodeNormalization.normalize(query)
+	if s.NormalizeSearchWhitespace {
+		query = strings.Join(strings.Fields(query), " ")
+	}
+	if s.LowercaseSearch {
+		query = strings.ToLower(query)
+	}
+	return query
+}
+
 func getSelectableFields(blacklist *Blacklist, sch *schema.Schema) []*schema.Field {
 	b := []string{}
 	if blacklist != nil && blacklist.FieldsBlacklist != nil {
@@ -365,14 +1626,10 @@ func selectScope(table string, fields []*schema.Field, override bool) func(*gorm
 			fieldsWithTableName = []string{"1", "2"}
 		} else {
 			fieldsWithTableName = make([]string, 0, len(fields))
-			tableName := tx.Statement.Quote(table)
 			for _, f := range fields {
-				computed := f.StructField.Tag.Get("computed")
-				var fieldExpr string
-				if computed != "" {
-					fieldExpr = fmt.Sprintf("(%s) %s", strings.ReplaceAll(computed, clause.CurrentTable, tableName), tx.Statement.Quote(f.DBName))
-				} else {
-					fieldExpr = tableName + "." + tx.Statement.Quote(f.DBName)
+				fieldExpr := columnExpression(tx.Statement, table, f)
+				if f.StructField.Tag.Get("computed") != "" {
+					fieldExpr += " " + tx.Statement.Quote(f.DBName)
 				}
 
 				fieldsWithTableName = append(fieldsWithTableName, fieldExpr)
@@ -393,19 +1650,16 @@ func getField(field string, sch *schema.Schema, blacklist *Blacklist) (*schema.F
 	if i := strings.LastIndex(field, "."); i != -1 && i+1 < len(field) {
 		rel := field[:i]
 		field = field[i+1:]
+		path := newBlacklistPath(blacklist)
 		for _, v := range strings.Split(rel, ".") {
-			if blacklist != nil && (lo.Contains(blacklist.RelationsBlacklist, v) || blacklist.IsFinal) {
-				return nil, nil, ""
-			}
 			relation, ok := s.Relationships.Relations[v]
-			if !ok || (relation.Type != schema.HasOne && relation.Type != schema.BelongsTo) {
+			if !ok || (relation.Type != schema.HasOne && relation.Type != schema.BelongsTo) || path.isDenied(relation) {
 				return nil, nil, ""
 			}
 			s = relation.FieldSchema
-			if blacklist != nil {
-				blacklist = blacklist.Relations[v]
-			}
+			path = path.next(relation)
 		}
+		blacklist = path.current()
 		joinName = rel
 	}
 	if blacklist != nil && lo.Contains(blacklist.FieldsBlacklist, field) {
@@ -418,6 +1672,18 @@ func getField(field string, sch *schema.Schema, blacklist *Blacklist) (*schema.F
 	return col, s, joinName
 }
 
+// withTableSchema returns a copy of the given schema whose table is qualified
+// with the given database schema. If the table is already qualified, its schema is replaced.
+func withTableSchema(sch *schema.Schema, tableSchema string) *schema.Schema {
+	s := *sch
+	table := s.Table
+	if i := strings.LastIndex(table, "."); i != -1 {
+		table = table[i+1:]
+	}
+	s.Table = tableSchema + "." + table
+	return &s
+}
+
 func tableFromJoinName(table string, joinName string) string {
 	if joinName != "" {
 		i := strings.LastIndex(joinName, ".")
@@ -1,10 +1,15 @@
 package filter
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
@@ -1018,6 +1023,7 @@ func TestScopeNoPrimaryKey(t *testing.T) {
 	results := []*TestScopeModelNoPrimaryKey{}
 	paginator, err := Scope(db, request, &results)
 	assert.Equal(t, "could not find primary key. Add `gorm:\"primaryKey\"` to your model", err.Error())
+	assert.ErrorIs(t, err, ErrNoPrimaryKey)
 	assert.Equal(t, err, paginator.DB.Error)
 }
 
@@ -1032,6 +1038,197 @@ func TestScopeUnpaginatedNoPrimaryKey(t *testing.T) {
 	db = ScopeUnpaginated(db, request, &results)
 	assert.Nil(t, results)
 	assert.Equal(t, "could not find primary key. Add `gorm:\"primaryKey\"` to your model", db.Error.Error())
+	assert.ErrorIs(t, db.Error, ErrNoPrimaryKey)
+}
+
+func TestScopeMaxJoins(t *testing.T) {
+	request := &Request{
+		Join: typeutil.NewUndefined([]*Join{
+			{Relation: "Relation", Fields: []string{"a", "b"}},
+			{Relation: "Relation.Parent"},
+		}),
+	}
+
+	t.Run("exceeded", func(t *testing.T) {
+		db := openDryRunDB(t)
+		results := []*TestScopeModel{}
+		_, err := (&Settings[*TestScopeModel]{MaxJoins: 1}).Scope(db, request, &results)
+		require.Error(t, err)
+		assert.Equal(t, "too many joins: the request would join 2 relations, the maximum is 1", err.Error())
+		assert.ErrorIs(t, err, ErrTooManyJoins)
+
+		db = openDryRunDB(t)
+		db = (&Settings[*TestScopeModel]{MaxJoins: 1}).ScopeUnpaginated(db, request, &results)
+		require.Error(t, db.Error)
+		assert.Equal(t, "too many joins: the request would join 2 relations, the maximum is 1", db.Error.Error())
+		assert.ErrorIs(t, db.Error, ErrTooManyJoins)
+	})
+
+	t.Run("not_exceeded", func(t *testing.T) {
+		db := openDryRunDB(t)
+		results := []*TestScopeModel{}
+		_, err := (&Settings[*TestScopeModel]{MaxJoins: 2}).Scope(db, request, &results)
+		require.NoError(t, err)
+	})
+}
+
+func TestCountJoins(t *testing.T) {
+	assert.Equal(t, 0, countJoins(nil))
+	assert.Equal(t, 1, countJoins([]*Join{{Relation: "Relation"}, {Relation: "Relation"}}))
+	assert.Equal(t, 3, countJoins([]*Join{{Relation: "Relation.Parent.Child"}}))
+	assert.Equal(t, 4, countJoins([]*Join{{Relation: "Relation.Parent"}, {Relation: "Relation"}, {Relation: "Other.Parent"}}))
+}
+
+type TestScopeDTO struct {
+	Relation   *TestScopeRelationDTO
+	Name       string `json:"-"`
+	ID         uint
+	RelationID uint
+}
+
+type TestScopeRelationDTO struct {
+	A  string
+	ID uint
+}
+
+func TestScopeInto(t *testing.T) {
+	db := openDryRunDB(t)
+	models := []any{}
+	queries := []string{}
+	err := db.Callback().Query().After("gorm:query").Register("test:fill", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+		if dest, ok := tx.Statement.Dest.(*[]*TestScopeDTO); ok {
+			// The records are scanned directly into the DTOs, using the model's schema
+			models = append(models, tx.Statement.Model)
+			*dest = append(*dest,
+				&TestScopeDTO{ID: 1, Name: "a", RelationID: 3, Relation: &TestScopeRelationDTO{ID: 3, A: "relation"}},
+				&TestScopeDTO{ID: 2, Name: "b"},
+			)
+		}
+	})
+	require.NoError(t, err)
+
+	request := &Request{
+		Filter:  typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"val1"}, Operator: Operators["$cont"]}}),
+		Page:    typeutil.NewUndefined(2),
+		PerPage: typeutil.NewUndefined(15),
+	}
+	settings := &Settings[*TestScopeModel]{
+		Blacklist: Blacklist{FieldsBlacklist: []string{"email"}},
+	}
+
+	results := []*TestScopeDTO{}
+	paginator, err := ScopeInto(db, request, settings, &results)
+	require.NoError(t, err)
+	require.NotNil(t, paginator)
+	assert.Equal(t, 2, paginator.CurrentPage)
+	assert.Equal(t, 15, paginator.PageSize)
+	assert.Equal(t, int64(1), paginator.MaxPage)
+	assert.Same(t, &results, paginator.Records)
+	assert.Equal(t, []*TestScopeDTO{
+		{ID: 1, Name: "a", RelationID: 3, Relation: &TestScopeRelationDTO{ID: 3, A: "relation"}},
+		{ID: 2, Name: "b"},
+	}, results)
+	assert.Equal(t, "test_scope_models", paginator.DB.Statement.Table)
+	assert.NotContains(t, paginator.DB.Statement.Selects, "`test_scope_models`.`email`")
+	assert.Equal(t, []any{&[]*TestScopeModel{}}, models)
+	require.Len(t, queries, 2)
+	assert.Equal(t, "SELECT count(*) FROM `test_scope_models` WHERE `test_scope_models`.`name` LIKE ?", queries[0])
+	assert.Contains(t, queries[1], "FROM `test_scope_models` WHERE `test_scope_models`.`name` LIKE ? LIMIT ? OFFSET ?")
+
+	t.Run("default_settings", func(t *testing.T) {
+		results := []*TestScopeDTO{}
+		paginator, err := ScopeInto[*TestScopeModel](db, &Request{}, nil, &results)
+		require.NoError(t, err)
+		require.NotNil(t, paginator)
+		assert.Len(t, results, 2)
+		assert.Contains(t, paginator.DB.Statement.Selects, "`test_scope_models`.`email`")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		request := &Request{
+			Fields: typeutil.NewUndefined([]string{"name"}),
+			Join:   typeutil.NewUndefined([]*Join{{Relation: "Relation", Fields: []string{"a", "b"}}}),
+		}
+		results := []*TestScopeDTO{}
+		paginator, err := ScopeInto[*TestScopeModelNoPrimaryKey](openDryRunDB(t), request, nil, &results)
+		assert.NotNil(t, paginator)
+		assert.Equal(t, "could not find primary key. Add `gorm:\"primaryKey\"` to your model", err.Error())
+		assert.Empty(t, results)
+	})
+
+	t.Run("unsupported_preload", func(t *testing.T) {
+		request := &Request{Join: typeutil.NewUndefined([]*Join{{Relation: "Articles"}})}
+		results := []*TestScopeDTO{}
+		_, err := ScopeInto[*IssueTestUser](openDryRunDB(t), request, nil, &results)
+		require.ErrorIs(t, err, ErrUnsupportedPreload)
+		assert.Equal(t, "relations cannot be preloaded into a type other than the model: Articles", err.Error())
+	})
+}
+
+func TestScopeIterator(t *testing.T) {
+	prevBatchSize := DefaultBatchSize
+	DefaultBatchSize = 10
+	t.Cleanup(func() {
+		DefaultBatchSize = prevBatchSize
+	})
+
+	db := openDryRunDB(t)
+	total := 25
+	offsets := []int{}
+	err := db.Callback().Query().After("gorm:query").Register("test:fill", func(tx *gorm.DB) {
+		dest, ok := tx.Statement.Dest.(*[]*TestScopeModel)
+		if !ok {
+			return
+		}
+		limit := tx.Statement.Clauses["LIMIT"].Expression.(clause.Limit)
+		offsets = append(offsets, limit.Offset)
+		for i := limit.Offset; i < min(total, limit.Offset+*limit.Limit); i++ {
+			*dest = append(*dest, &TestScopeModel{ID: uint(i + 1)})
+		}
+	})
+	require.NoError(t, err)
+
+	request := &Request{
+		Filter:  typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"val1"}, Operator: Operators["$cont"]}}),
+		Sort:    typeutil.NewUndefined([]*Sort{{Field: "id", Order: SortAscending}}),
+		PerPage: typeutil.NewUndefined(15), // Should be ignored
+	}
+
+	ids := []uint{}
+	for record, err := range ScopeIterator[*TestScopeModel](db, request) {
+		require.NoError(t, err)
+		ids = append(ids, record.ID)
+	}
+	assert.Equal(t, []int{0, 10, 20}, offsets)
+	assert.Equal(t, lo.RangeFrom[uint](1, total), ids)
+
+	t.Run("break", func(t *testing.T) {
+		offsets = []int{}
+		ids = []uint{}
+		for record := range ScopeIterator[*TestScopeModel](db, request) {
+			ids = append(ids, record.ID)
+			if len(ids) == 12 {
+				break
+			}
+		}
+		assert.Equal(t, []int{0, 10}, offsets)
+		assert.Equal(t, lo.RangeFrom[uint](1, 12), ids)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		request := &Request{
+			Fields: typeutil.NewUndefined([]string{"name"}),
+			Join:   typeutil.NewUndefined([]*Join{{Relation: "Relation", Fields: []string{"a", "b"}}}),
+		}
+		count := 0
+		for record, err := range ScopeIterator[*TestScopeModelNoPrimaryKey](openDryRunDB(t), request) {
+			count++
+			assert.Nil(t, record)
+			assert.Equal(t, "could not find primary key. Add `gorm:\"primaryKey\"` to your model", err.Error())
+		}
+		assert.Equal(t, 1, count)
+	})
 }
 
 func TestScopeWithFieldsBlacklist(t *testing.T) {
@@ -1063,21 +1260,258 @@ func TestScopeUnpaginatedWithFieldsBlacklist(t *testing.T) {
 	assert.ElementsMatch(t, []string{"`test_scope_models`.`id`", "`test_scope_models`.`relation_id`", "`test_scope_models`.`email`", "(UPPER(`test_scope_models`.name)) `computed`"}, db.Statement.Selects)
 }
 
+type TestScopeQualifiedRelation struct {
+	Computed string `gorm:"->;-:migration" computed:"UPPER(~~~ct~~~.name)"`
+	Name     string
+	ID       uint
+	ParentID uint
+}
+
+func (TestScopeQualifiedRelation) TableName() string {
+	return "third.relations"
+}
+
+type TestScopeQualifiedModel struct {
+	Relation *TestScopeQualifiedRelation `gorm:"foreignKey:ParentID"`
+	Computed string                      `gorm:"->;-:migration" computed:"UPPER(~~~ct~~~.name)"`
+	Name     string
+	ID       uint
+}
+
+func (TestScopeQualifiedModel) TableName() string {
+	return "other.models"
+}
+
+func TestScopeQualifiedTables(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "Relation.computed", Operator: Operators["$eq"], Args: []string{"a"}},
+			{Field: "computed", Operator: Operators["$eq"], Args: []string{"b"}},
+		}),
+		Sort:   typeutil.NewUndefined([]*Sort{{Field: "Relation.name", Order: SortAscending}}),
+		Search: typeutil.NewUndefined("c"),
+	}
+
+	t.Run("model", func(t *testing.T) {
+		db := openDryRunDB(t)
+		results := []*TestScopeQualifiedModel{}
+		settings := &Settings[*TestScopeQualifiedModel]{FieldsSearch: []string{"name", "Relation.name"}}
+		db = settings.ScopeUnpaginated(db, request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t,
+			"SELECT (UPPER(`other`.`models`.name)) `computed`,`other`.`models`.`name`,`other`.`models`.`id` FROM `other`.`models` LEFT JOIN `third`.`relations` `Relation` ON `other`.`models`.`id` = `Relation`.`parent_id` WHERE ((UPPER(`Relation`.name)) = ? AND (UPPER(`other`.`models`.name)) = ?) AND (`other`.`models`.`name` LIKE ? OR `Relation`.`name` LIKE ?) ORDER BY `Relation`.`name`",
+			db.Statement.SQL.String(),
+		)
+	})
+
+	t.Run("table_schema", func(t *testing.T) {
+		db := openDryRunDB(t)
+		results := []*TestScopeQualifiedModel{}
+		settings := &Settings[*TestScopeQualifiedModel]{FieldsSearch: []string{"name"}, TableSchema: "tenant"}
+		db = settings.ScopeUnpaginated(db, request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t,
+			"SELECT (UPPER(`tenant`.`models`.name)) `computed`,`tenant`.`models`.`name`,`tenant`.`models`.`id` FROM `tenant`.`models` LEFT JOIN `third`.`relations` `Relation` ON `tenant`.`models`.`id` = `Relation`.`parent_id` WHERE ((UPPER(`Relation`.name)) = ? AND (UPPER(`tenant`.`models`.name)) = ?) AND `tenant`.`models`.`name` LIKE ? ORDER BY `Relation`.`name`",
+			db.Statement.SQL.String(),
+		)
+
+		sch, err := parseModel(db, &results)
+		require.NoError(t, err)
+		assert.Equal(t, "other.models", sch.Table, "the cached schema must not be modified")
+	})
+
+	t.Run("table_schema_paginated", func(t *testing.T) {
+		db := openDryRunDB(t)
+		results := []*TestScopeModel{}
+		paginator, err := (&Settings[*TestScopeModel]{TableSchema: "tenant", DisableFields: true}).Scope(db, &Request{}, &results)
+		require.NoError(t, err)
+		assert.Equal(t,
+			"SELECT `tenant`.`test_scope_models`.`name`,`tenant`.`test_scope_models`.`email`,(UPPER(`tenant`.`test_scope_models`.name)) `computed`,`tenant`.`test_scope_models`.`id`,`tenant`.`test_scope_models`.`relation_id` FROM `tenant`.`test_scope_models` LIMIT ?",
+			paginator.DB.Statement.SQL.String(),
+		)
+	})
+}
+
+func TestScopePerPageAll(t *testing.T) {
+	cases := []struct {
+		settings     *Settings[*TestScopeModel]
+		desc         string
+		wantVars     []any
+		wantPageSize int
+		wantMaxPage  int64
+	}{
+		{desc: "unlimited", settings: &Settings[*TestScopeModel]{AllowAll: true}, wantVars: []any{42}, wantPageSize: 42, wantMaxPage: 1},
+		{desc: "max_export_rows", settings: &Settings[*TestScopeModel]{AllowAll: true, MaxExportRows: 20}, wantVars: []any{20}, wantPageSize: 20, wantMaxPage: 3},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			err := db.Callback().Query().After("gorm:query").Register("test:count", func(tx *gorm.DB) {
+				if count, ok := tx.Statement.Dest.(*int64); ok {
+					*count = 42
+					tx.RowsAffected = 1
+				}
+			})
+			require.NoError(t, err)
+
+			request := &Request{Page: typeutil.NewUndefined(2), PerPage: typeutil.NewUndefined(PerPageAll)}
+			results := []*TestScopeModel{}
+			paginator, err := c.settings.Scope(db, request, &results)
+			require.NoError(t, err)
+			assert.Equal(t, 1, paginator.CurrentPage)
+			assert.Equal(t, c.wantPageSize, paginator.PageSize)
+			assert.Equal(t, c.wantMaxPage, paginator.MaxPage)
+			assert.Equal(t, c.wantVars, paginator.DB.Statement.Vars)
+		})
+	}
+
+	t.Run("not_allowed", func(t *testing.T) {
+		db := openDryRunDB(t)
+		request := &Request{PerPage: typeutil.NewUndefined(PerPageAll)}
+		results := []*TestScopeModel{}
+		paginator, err := (&Settings[*TestScopeModel]{}).Scope(db, request, &results)
+		require.ErrorIs(t, err, ErrAllNotAllowed)
+		assert.Nil(t, paginator)
+	})
+}
+
+type testContextKey struct{}
+
+type testExportEnqueuer struct {
+	err      error
+	request  *Request
+	settings *Settings[*TestScopeModel]
+	ctx      context.Context
+}
+
+func (e *testExportEnqueuer) Enqueue(ctx context.Context, request *Request, settings *Settings[*TestScopeModel]) error {
+	e.ctx = ctx
+	e.request = request
+	e.settings = settings
+	return e.err
+}
+
+func TestScopeExportEnqueuer(t *testing.T) {
+	cases := []struct {
+		enqueuer     *testExportEnqueuer
+		request      *Request
+		desc         string
+		threshold    int64
+		wantEnqueued bool
+	}{
+		{desc: "above_threshold", enqueuer: &testExportEnqueuer{}, threshold: 41, request: &Request{PerPage: typeutil.NewUndefined(PerPageAll)}, wantEnqueued: true},
+		{desc: "below_threshold", enqueuer: &testExportEnqueuer{}, threshold: 42, request: &Request{PerPage: typeutil.NewUndefined(PerPageAll)}},
+		{desc: "not_all", enqueuer: &testExportEnqueuer{}, threshold: 41, request: &Request{PerPage: typeutil.NewUndefined(10)}},
+		{desc: "no_threshold", enqueuer: &testExportEnqueuer{}, request: &Request{PerPage: typeutil.NewUndefined(PerPageAll)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			queries := 0
+			err := db.Callback().Query().After("gorm:query").Register("test:count", func(tx *gorm.DB) {
+				queries++
+				if count, ok := tx.Statement.Dest.(*int64); ok {
+					*count = 42
+					tx.RowsAffected = 1
+				}
+			})
+			require.NoError(t, err)
+
+			ctx := context.WithValue(context.Background(), testContextKey{}, "value")
+			settings := &Settings[*TestScopeModel]{AllowAll: true, ExportThreshold: c.threshold, ExportEnqueuer: c.enqueuer}
+			results := []*TestScopeModel{}
+			paginator, err := settings.Scope(db.WithContext(ctx), c.request, &results)
+			if !c.wantEnqueued {
+				require.NoError(t, err)
+				assert.Nil(t, c.enqueuer.request)
+				assert.Equal(t, 2, queries)
+				_, ok := paginator.DB.Get(ExportEnqueuedSetting)
+				assert.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			enqueued, ok := paginator.DB.Get(ExportEnqueuedSetting)
+			assert.True(t, ok)
+			assert.Equal(t, true, enqueued)
+			assert.Equal(t, 1, queries, "only the count query is executed")
+			assert.Equal(t, int64(42), paginator.Total)
+			assert.Empty(t, results)
+			assert.Same(t, c.request, c.enqueuer.request)
+			assert.Same(t, settings, c.enqueuer.settings)
+			assert.Equal(t, "value", c.enqueuer.ctx.Value(testContextKey{}))
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		enqueuer := &testExportEnqueuer{err: fmt.Errorf("queue unavailable")}
+		settings := &Settings[*TestScopeModel]{AllowAll: true, ExportThreshold: 1, ExportEnqueuer: enqueuer}
+		db := openDryRunDB(t)
+		err := db.Callback().Query().After("gorm:query").Register("test:count", func(tx *gorm.DB) {
+			if count, ok := tx.Statement.Dest.(*int64); ok {
+				*count = 42
+				tx.RowsAffected = 1
+			}
+		})
+		require.NoError(t, err)
+		results := []*TestScopeModel{}
+		_, err = settings.Scope(db, &Request{PerPage: typeutil.NewUndefined(PerPageAll)}, &results)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "queue unavailable")
+	})
+}
+
 func TestScopeInvalidModel(t *testing.T) {
 	request := &Request{}
 	db := openDryRunDB(t)
 	model := []string{}
-	assert.Panics(t, func() {
-		_, _ = Scope(db, request, &model)
-	})
+	paginator, err := Scope(db, request, &model)
+	assert.Nil(t, paginator)
+	require.ErrorIs(t, err, ErrUnsupportedModel)
+	assert.ErrorIs(t, err, schema.ErrUnsupportedDataType)
+	assert.NoError(t, db.Error)
 }
 
 func TestScopeUnpaginatedInvalidModel(t *testing.T) {
 	request := &Request{}
 	db := openDryRunDB(t)
 	model := []string{}
-	assert.Panics(t, func() {
-		ScopeUnpaginated(db, request, &model)
+	result := ScopeUnpaginated(db, request, &model)
+	require.ErrorIs(t, result.Error, ErrUnsupportedModel)
+	assert.ErrorIs(t, result.Error, schema.ErrUnsupportedDataType)
+	assert.NoError(t, db.Error)
+
+	for _, err := range ScopeIterator[string](db, request) {
+		assert.ErrorIs(t, err, ErrUnsupportedModel)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"val"}, Operator: Operators["$eq"]}}),
+		Sort:   typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortDescending}}),
+		Fields: typeutil.NewUndefined([]string{"id", "name"}),
+	}
+	results := []*TestScopeModel{}
+	db, err := Build(openDryRunDB(t), request, &results)
+	require.NoError(t, err)
+
+	tx := db.Where("relation_id = ?", 1).Limit(3).Find(&results)
+	require.NoError(t, tx.Error)
+	assert.Equal(t, "SELECT `test_scope_models`.`id`,`test_scope_models`.`name` FROM `test_scope_models` WHERE relation_id = ? AND `test_scope_models`.`name` = ? ORDER BY `test_scope_models`.`name` DESC LIMIT ?", tx.Statement.SQL.String())
+	assert.Equal(t, []any{1, "val", 3}, tx.Statement.Vars)
+
+	// The prepared query can be executed again without the previous additional clauses
+	tx = db.Find(&results)
+	require.NoError(t, tx.Error)
+	assert.Equal(t, "SELECT `test_scope_models`.`id`,`test_scope_models`.`name` FROM `test_scope_models` WHERE `test_scope_models`.`name` = ? ORDER BY `test_scope_models`.`name` DESC", tx.Statement.SQL.String())
+
+	t.Run("invalid_model", func(t *testing.T) {
+		model := []string{}
+		db, err := Build(openDryRunDB(t), request, &model)
+		require.ErrorIs(t, err, ErrUnsupportedModel)
+		assert.ErrorIs(t, db.Error, ErrUnsupportedModel)
 	})
 }
 
@@ -1122,7 +1556,7 @@ func TestApplyFiltersAnd(t *testing.T) {
 		return
 	}
 
-	db = (&Settings[*TestScopeModel]{}).applyFilters(db, request, schema).Find(nil)
+	db = (&Settings[*TestScopeModel]{}).applyFilters(db, request, schema, nil).Find(nil)
 	expected := map[string]clause.Clause{
 		"WHERE": {
 			Name: "WHERE",
@@ -1167,7 +1601,7 @@ func TestApplyFiltersOr(t *testing.T) {
 		return
 	}
 
-	db = (&Settings[*TestScopeModel]{}).applyFilters(db, request, schema).Find(nil)
+	db = (&Settings[*TestScopeModel]{}).applyFilters(db, request, schema, nil).Find(nil)
 	expected := map[string]clause.Clause{
 		"WHERE": {
 			Name: "WHERE",
@@ -1224,7 +1658,7 @@ func TestApplyFiltersMixed(t *testing.T) {
 		return
 	}
 
-	db = (&Settings[*TestScopeModel]{}).applyFilters(db, request, schema).Find(nil)
+	db = (&Settings[*TestScopeModel]{}).applyFilters(db, request, schema, nil).Find(nil)
 	expected := map[string]clause.Clause{
 		"WHERE": {
 			Name: "WHERE",
@@ -1284,7 +1718,7 @@ func TestApplyFiltersWithJoin(t *testing.T) {
 
 	results := []*FilterTestModel{}
 	db = db.Model(&results)
-	db = (&Settings[*TestScopeModel]{}).applyFilters(db, request, schema).Find(&results)
+	db = (&Settings[*TestScopeModel]{}).applyFilters(db, request, schema, nil).Find(&results)
 	assert.Nil(t, db.Statement.Error)
 	expected := map[string]clause.Clause{
 		"WHERE": {
@@ -1341,6 +1775,254 @@ func TestApplyFiltersWithJoin(t *testing.T) {
 	assert.Equal(t, expected, db.Statement.Clauses)
 }
 
+func TestApplyFiltersSearchOperator(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Args: []string{"val1"}, Operator: Operators["$search"]},
+			{Field: "id", Args: []string{"1"}, Operator: Operators["$eq"]},
+		}),
+	}
+	db := openDryRunDB(t)
+	schema, err := parseModel(db, &FilterTestModel{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	settings := &Settings[*FilterTestModel]{
+		SearchOperator: &Operator{
+			Function: func(tx *gorm.DB, filter *Filter, column string, _ DataType) *gorm.DB {
+				return tx.Or(fmt.Sprintf("%s LIKE (?)", column), filter.Args[0]).Or(fmt.Sprintf("%s = ?", column), filter.Args[0])
+			},
+			RequiredArguments: 1,
+		},
+	}
+
+	results := []*FilterTestModel{}
+	db = db.Model(&results)
+	db = settings.applyFilters(db, request, schema, nil).Find(&results)
+	require.NoError(t, db.Error)
+	expected := clause.Where{
+		Exprs: []clause.Expression{
+			clause.AndConditions{
+				Exprs: []clause.Expression{
+					clause.AndConditions{
+						Exprs: []clause.Expression{
+							clause.AndConditions{
+								Exprs: []clause.Expression{
+									clause.OrConditions{
+										Exprs: []clause.Expression{
+											clause.Expr{SQL: "`filter_test_models`.`name` LIKE (?)", Vars: []any{"val1"}},
+										},
+									},
+									clause.OrConditions{
+										Exprs: []clause.Expression{
+											clause.Expr{SQL: "`filter_test_models`.`name` = ?", Vars: []any{"val1"}},
+										},
+									},
+								},
+							},
+							clause.Expr{SQL: "`filter_test_models`.`id` = ?", Vars: []any{uint64(1)}},
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, db.Statement.Clauses["WHERE"].Expression)
+
+	// The request is not modified
+	assert.Same(t, Operators["$search"], request.Filter.Val[0].Operator)
+}
+
+func TestApplyFiltersSearchOperatorsPerDataType(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "created_at", Args: []string{"2023-04-30"}, Operator: Operators["$search"]},
+			{Field: "name", Args: []string{"val1"}, Operator: Operators["$search"]},
+		}),
+	}
+	db := openDryRunDB(t)
+	schema, err := parseModel(db, &SearchTestTypedModel{})
+	require.NoError(t, err)
+
+	settings := &Settings[*SearchTestTypedModel]{
+		SearchOperators: map[DataType]*Operator{
+			DataTypeTime: Operators["$day"],
+		},
+	}
+
+	results := []*SearchTestTypedModel{}
+	db = db.Model(&results)
+	db = settings.applyFilters(db, request, schema, nil).Find(&results)
+	require.NoError(t, db.Error)
+	assert.Equal(t,
+		"SELECT * FROM `search_test_typed_models` WHERE ((`search_test_typed_models`.`created_at` >= ? AND `search_test_typed_models`.`created_at` < ?) AND `search_test_typed_models`.`name` LIKE ?)",
+		db.Statement.SQL.String(),
+	)
+	assert.Equal(t, []any{"2023-04-30", "2023-05-01", "%val1%"}, db.Statement.Vars)
+}
+
+func TestApplyFiltersCaseInsensitive(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Args: []string{"Val1"}, Operator: Operators["$eq"]},
+			{Field: "id", Args: []string{"1"}, Operator: Operators["$eq"]},
+		}),
+		Or: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Args: []string{"Val2"}, Operator: Operators["$cont"], Or: true},
+		}),
+	}
+	db := openDryRunDB(t)
+	schema, err := parseModel(db, &FilterTestModel{})
+	require.NoError(t, err)
+
+	settings := &Settings[*FilterTestModel]{CaseInsensitiveFilter: true}
+	results := []*FilterTestModel{}
+	db = db.Model(&results)
+	db = settings.applyFilters(db, request, schema, nil).Find(&results)
+	require.NoError(t, db.Error)
+	assert.Equal(t, "SELECT * FROM `filter_test_models` WHERE (LOWER(`filter_test_models`.`name`) = LOWER(?) AND `filter_test_models`.`id` = ?) OR LOWER(`filter_test_models`.`name`) LIKE LOWER(?)", db.Statement.SQL.String())
+	assert.Equal(t, []any{"Val1", uint64(1), "%Val2%"}, db.Statement.Vars)
+
+	// The request is not modified
+	assert.Same(t, Operators["$eq"], request.Filter.Val[0].Operator)
+}
+
+func TestUnicodeNormalization(t *testing.T) {
+	cases := []struct {
+		desc          string
+		want          []any
+		normalization UnicodeNormalization
+	}{
+		{desc: "none", normalization: UnicodeNormalizationNone, want: []any{"cafe\u0301 \ufb01", "%cafe\u0301 \ufb01%"}},
+		{desc: "nfc", normalization: UnicodeNormalizationNFC, want: []any{"caf\u00e9 \ufb01", "%caf\u00e9 \ufb01%"}},
+		{desc: "nfkc", normalization: UnicodeNormalizationNFKC, want: []any{"caf\u00e9 fi", "%caf\u00e9 fi%"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			request := &Request{
+				Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"cafe\u0301 \ufb01"}, Operator: Operators["$eq"]}}),
+				Search: typeutil.NewUndefined("cafe\u0301 \ufb01"),
+			}
+			settings := &Settings[*FilterTestModel]{UnicodeNormalization: c.normalization}
+			results := []*FilterTestModel{}
+			db := settings.ScopeUnpaginated(openDryRunDB(t), request, &results)
+			require.NoError(t, db.Error)
+			assert.Equal(t, c.want, db.Statement.Vars)
+
+			// The request is not modified
+			assert.Equal(t, "cafe\u0301 \ufb01", request.Filter.Val[0].Args[0])
+		})
+	}
+}
+
+func TestOrStandaloneMode(t *testing.T) {
+	newRequest := func() *Request {
+		return &Request{
+			Or: typeutil.NewUndefined([]*Filter{
+				{Field: "name", Args: []string{"a"}, Operator: Operators["$eq"], Or: true},
+				{Field: "id", Args: []string{"1"}, Operator: Operators["$eq"], Or: true},
+			}),
+			Search: typeutil.NewUndefined("b"),
+		}
+	}
+	const selectFrom = "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` "
+
+	cases := []struct {
+		request func() *Request
+		desc    string
+		want    string
+		mode    OrStandaloneMode
+	}{
+		{
+			desc:    "group",
+			mode:    OrStandaloneGroup,
+			request: newRequest,
+			want:    "WHERE (`filter_test_models`.`name` = ? OR `filter_test_models`.`id` = ?) AND (`filter_test_models`.`name` LIKE ? OR `Relation`.`name` LIKE ?)",
+		},
+		{
+			desc:    "with_search",
+			mode:    OrStandaloneWithSearch,
+			request: newRequest,
+			want:    "WHERE (`filter_test_models`.`name` = ? OR `filter_test_models`.`id` = ? OR (`filter_test_models`.`name` LIKE ? OR `Relation`.`name` LIKE ?))",
+		},
+		{
+			desc: "with_search_no_search",
+			mode: OrStandaloneWithSearch,
+			request: func() *Request {
+				r := newRequest()
+				r.Search = typeutil.Undefined[string]{}
+				return r
+			},
+			want: "WHERE (`filter_test_models`.`name` = ? OR `filter_test_models`.`id` = ?)",
+		},
+		{
+			desc: "with_search_not_standalone",
+			mode: OrStandaloneWithSearch,
+			request: func() *Request {
+				r := newRequest()
+				r.Filter = typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"c"}, Operator: Operators["$ne"]}})
+				return r
+			},
+			want: "WHERE (`filter_test_models`.`name` <> ? OR (`filter_test_models`.`name` = ? AND `filter_test_models`.`id` = ?)) AND (`filter_test_models`.`name` LIKE ? OR `Relation`.`name` LIKE ?)",
+		},
+		{
+			desc:    "and",
+			mode:    OrStandaloneAnd,
+			request: newRequest,
+			want:    "WHERE (`filter_test_models`.`name` = ? AND `filter_test_models`.`id` = ?) AND (`filter_test_models`.`name` LIKE ? OR `Relation`.`name` LIKE ?)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			settings := &Settings[*FilterTestModel]{
+				FieldsSearch:     []string{"name", "Relation.name"},
+				OrStandaloneMode: c.mode,
+			}
+			db := openDryRunDB(t)
+			results := []*FilterTestModel{}
+			db = settings.ScopeUnpaginated(db, c.request(), &results)
+			require.NoError(t, db.Error)
+			sql := db.Statement.SQL.String()
+			if strings.Contains(c.want, "`Relation`") {
+				assert.Equal(t, selectFrom+"LEFT JOIN `filter_test_relations` `Relation` ON `filter_test_models`.`id` = `Relation`.`parent_id` "+c.want, sql)
+			} else {
+				assert.Equal(t, selectFrom+c.want, sql)
+			}
+		})
+	}
+}
+
+func TestApplyFiltersSelectivityHints(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Args: []string{"val1"}, Operator: Operators["$cont"]},
+			{Field: "id", Args: []string{"1"}, Operator: Operators["$eq"]},
+		}),
+		Or: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Args: []string{"val2"}, Operator: Operators["$cont"], Or: true},
+			{Field: "id", Args: []string{"2"}, Operator: Operators["$eq"], Or: true},
+		}),
+	}
+	db := openDryRunDB(t)
+	schema, err := parseModel(db, &FilterTestModel{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	settings := &Settings[*FilterTestModel]{SelectivityHints: SelectivityHints{"id": 0.01}}
+	results := []*FilterTestModel{}
+	db = db.Model(&results)
+	db = settings.applyFilters(db, request, schema, nil).Find(&results)
+	require.NoError(t, db.Error)
+	assert.Equal(t, "SELECT * FROM `filter_test_models` WHERE (`filter_test_models`.`id` = ? AND `filter_test_models`.`name` LIKE ?) OR (`filter_test_models`.`name` LIKE ? AND `filter_test_models`.`id` = ?)", db.Statement.SQL.String())
+
+	// The request is not modified
+	assert.Equal(t, "name", request.Filter.Val[0].Field)
+}
+
 func TestApplySearch(t *testing.T) {
 	db := openDryRunDB(t)
 	schema, err := parseModel(db, &TestFilterScopeModel{})
@@ -1355,6 +2037,87 @@ func TestApplySearch(t *testing.T) {
 	assert.Equal(t, Operators["$cont"], search.Operator)
 }
 
+func TestApplySearchDisableSearchJoins(t *testing.T) {
+	db := openDryRunDB(t)
+	schema, err := parseModel(db, &FilterTestModel{})
+	require.NoError(t, err)
+
+	settings := &Settings[*FilterTestModel]{
+		FieldsSearch:       []string{"name", "Relation.name", "Relation.Parent.name", "email"},
+		DisableSearchJoins: true,
+	}
+	search := settings.applySearch("val", schema)
+	require.NotNil(t, search)
+	assert.Equal(t, []string{"name", "email"}, search.Fields)
+
+	settings.DisableSearchJoins = false
+	search = settings.applySearch("val", schema)
+	require.NotNil(t, search)
+	assert.Equal(t, []string{"name", "Relation.name", "Relation.Parent.name", "email"}, search.Fields)
+}
+
+func TestApplySearchNormalization(t *testing.T) {
+	db := openDryRunDB(t)
+	schema, err := parseModel(db, &FilterTestModel{})
+	require.NoError(t, err)
+
+	cases := []struct {
+		settings *Settings[*FilterTestModel]
+		desc     string
+		query    string
+		want     string
+		ignored  bool
+	}{
+		{desc: "disabled", settings: &Settings[*FilterTestModel]{}, query: "  Foo \t Bar ", want: "  Foo \t Bar "},
+		{desc: "disabled_blank", settings: &Settings[*FilterTestModel]{}, query: "  ", want: "  "},
+		{desc: "whitespace", settings: &Settings[*FilterTestModel]{NormalizeSearchWhitespace: true}, query: "  Foo \t\n Bar\u00a0 ", want: "Foo Bar"},
+		{desc: "whitespace_blank", settings: &Settings[*FilterTestModel]{NormalizeSearchWhitespace: true}, query: " \t ", ignored: true},
+		{desc: "lowercase", settings: &Settings[*FilterTestModel]{LowercaseSearch: true}, query: " Foo  BAR", want: " foo  bar"},
+		{
+			desc:     "all",
+			settings: &Settings[*FilterTestModel]{NormalizeSearchWhitespace: true, LowercaseSearch: true, UnicodeNormalization: UnicodeNormalizationNFC},
+			query:    " CAFE\u0301   Bar ",
+			want:     "caf\u00e9 bar",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			search := c.settings.applySearch(c.query, schema)
+			if c.ignored {
+				assert.Nil(t, search)
+				return
+			}
+			require.NotNil(t, search)
+			assert.Equal(t, c.want, search.Query)
+		})
+	}
+
+	request := &Request{Search: typeutil.NewUndefined("   ")}
+	results := []*FilterTestModel{}
+	db = (&Settings[*FilterTestModel]{NormalizeSearchWhitespace: true}).ScopeUnpaginated(openDryRunDB(t), request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models`", db.Statement.SQL.String())
+
+	t.Run("search_filter", func(t *testing.T) {
+		settings := &Settings[*FilterTestModel]{NormalizeSearchWhitespace: true, LowercaseSearch: true}
+		request := &Request{Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Operator: Operators["$search"], Args: []string{"  Foo \t BAR "}},
+			{Field: "name", Operator: Operators["$eq"], Args: []string{" Foo "}},
+		})}
+		results := []*FilterTestModel{}
+		db := settings.ScopeUnpaginated(openDryRunDB(t), request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` WHERE (`filter_test_models`.`name` LIKE ? AND `filter_test_models`.`name` = ?)", db.Statement.SQL.String())
+		assert.Equal(t, []any{"%foo bar%", " Foo "}, db.Statement.Vars)
+
+		request = &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$search"], Args: []string{" \t "}}})}
+		db = settings.ScopeUnpaginated(openDryRunDB(t), request, &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models`", db.Statement.SQL.String())
+	})
+}
+
 func TestSelectScope(t *testing.T) {
 	db := openDryRunDB(t)
 	db = db.Scopes(selectScope("", nil, false)).Find(nil)
@@ -1399,6 +2162,11 @@ func TestSelectScope(t *testing.T) {
 	db = db.Joins("relation").Scopes(selectScope(sch.Table, []*schema.Field{{DBName: "c", StructField: reflect.StructField{Tag: `computed:"a+b"`}}}, true)).Select("*, 1 + 1 AS count").Find(nil)
 	assert.Equal(t, []string{"(a+b) `c`"}, db.Statement.Selects)
 	assert.Equal(t, []clause.Column{{Raw: true, Name: "(a+b) `c`"}}, db.Statement.Clauses["SELECT"].Expression.(clause.Select).Columns)
+
+	// Reserved keywords
+	db = openDryRunDB(t)
+	db = db.Scopes(selectScope("user", []*schema.Field{{DBName: "order"}, {DBName: "group", StructField: reflect.StructField{Tag: `computed:"~~~ct~~~.a+~~~ct~~~.b"`}}}, true)).Find(nil)
+	assert.Equal(t, []string{"`user`.`order`", "(`user`.a+`user`.b) `group`"}, db.Statement.Selects)
 }
 
 func TestGetFieldFinalRelation(t *testing.T) {
@@ -2004,12 +2772,14 @@ func TestNewRequest(t *testing.T) {
 				"or": []*Filter{
 					{Field: "name", Args: []string{"val3"}, Or: true, Operator: Operators["$eq"]},
 				},
-				"sort":     []*Sort{{Field: "name", Order: SortDescending}},
-				"join":     []*Join{{Relation: "Relation", Fields: []string{"a", "b"}}},
-				"page":     2,
-				"per_page": 15,
-				"fields":   []string{"id", "name", "email", "computed"},
-				"search":   "val",
+				"sort":          []*Sort{{Field: "name", Order: SortDescending}},
+				"join":          []*Join{{Relation: "Relation", Fields: []string{"a", "b"}}},
+				"page":          2,
+				"per_page":      15,
+				"fields":        []string{"id", "name", "email", "computed"},
+				"search":        "val",
+				"updated_since": time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+				"snapshot":      "token",
 			},
 			want: &Request{
 				Filter: typeutil.NewUndefined([]*Filter{
@@ -2019,12 +2789,14 @@ func TestNewRequest(t *testing.T) {
 				Or: typeutil.NewUndefined([]*Filter{
 					{Field: "name", Args: []string{"val3"}, Or: true, Operator: Operators["$eq"]},
 				}),
-				Sort:    typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortDescending}}),
-				Join:    typeutil.NewUndefined([]*Join{{Relation: "Relation", Fields: []string{"a", "b"}}}),
-				Page:    typeutil.NewUndefined(2),
-				PerPage: typeutil.NewUndefined(15),
-				Fields:  typeutil.NewUndefined([]string{"id", "name", "email", "computed"}),
-				Search:  typeutil.NewUndefined("val"),
+				Sort:         typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortDescending}}),
+				Join:         typeutil.NewUndefined([]*Join{{Relation: "Relation", Fields: []string{"a", "b"}}}),
+				Page:         typeutil.NewUndefined(2),
+				PerPage:      typeutil.NewUndefined(15),
+				Fields:       typeutil.NewUndefined([]string{"id", "name", "email", "computed"}),
+				Search:       typeutil.NewUndefined("val"),
+				UpdatedSince: typeutil.NewUndefined(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)),
+				Snapshot:     typeutil.NewUndefined("token"),
 			},
 		},
 		{
@@ -2070,6 +2842,332 @@ func TestNewRequest(t *testing.T) {
 	}
 }
 
+func TestParamNamesNewRequest(t *testing.T) {
+	names := ParamNames{Search: "q", Sort: "order_by"}
+	query := map[string]any{
+		"q":        "val",
+		"search":   "ignored",
+		"order_by": []*Sort{{Field: "name", Order: SortDescending}},
+		"sort":     []*Sort{{Field: "email", Order: SortAscending}},
+		"per_page": 15,
+	}
+	want := &Request{
+		Search:  typeutil.NewUndefined("val"),
+		Sort:    typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortDescending}}),
+		PerPage: typeutil.NewUndefined(15),
+	}
+	assert.Equal(t, want, names.NewRequest(query))
+
+	t.Run("default_param_names", func(t *testing.T) {
+		prev := DefaultParamNames
+		DefaultParamNames.PerPage = "limit"
+		t.Cleanup(func() {
+			DefaultParamNames = prev
+		})
+
+		query := map[string]any{
+			"limit":    20,
+			"per_page": 15,
+			"search":   "val",
+		}
+		want := &Request{
+			Search:  typeutil.NewUndefined("val"),
+			PerPage: typeutil.NewUndefined(20),
+		}
+		assert.Equal(t, want, NewRequest(query))
+	})
+}
+
+func TestNewRequestFilterGroups(t *testing.T) {
+	group0 := []*Filter{{Field: "name", Operator: Operators["$cont"], Args: []string{"a"}, Or: true}}
+	group3 := []*Filter{{Field: "age", Operator: Operators["$gt"], Args: []string{"18"}, Or: true}}
+	query := map[string]any{
+		"filter[3]": group3,
+		"filter[0]": group0,
+		"filter[1]": "not validated",
+		"or[2]":     group0,
+	}
+	want := &Request{
+		FilterGroups: typeutil.NewUndefined([][]*Filter{group0, group3}),
+	}
+	assert.Equal(t, want, NewRequest(query))
+	assert.Equal(t, &Request{}, NewRequest(map[string]any{}))
+}
+
+func TestApplyFiltersGroups(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "id", Args: []string{"1"}, Operator: Operators["$gt"]},
+		}),
+		FilterGroups: typeutil.NewUndefined([][]*Filter{
+			{
+				{Field: "name", Args: []string{"a"}, Operator: Operators["$cont"], Or: true},
+				{Field: "name", Args: []string{"b"}, Operator: Operators["$cont"]},
+			},
+			{
+				{Field: "id", Args: []string{"10"}, Operator: Operators["$lt"], Or: true},
+			},
+		}),
+	}
+	db := openDryRunDB(t)
+	schema, err := parseModel(db, &FilterTestModel{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	results := []*FilterTestModel{}
+	db = db.Model(&results)
+	db = (&Settings[*FilterTestModel]{}).applyFilters(db, request, schema, nil).Find(&results)
+	require.NoError(t, db.Error)
+	expected := clause.Where{
+		Exprs: []clause.Expression{
+			clause.AndConditions{
+				Exprs: []clause.Expression{
+					clause.Expr{SQL: "`filter_test_models`.`id` > ?", Vars: []any{uint64(1)}},
+				},
+			},
+			clause.AndConditions{
+				Exprs: []clause.Expression{
+					clause.OrConditions{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`filter_test_models`.`name` LIKE ?", Vars: []any{"%a%"}},
+						},
+					},
+					clause.OrConditions{
+						Exprs: []clause.Expression{
+							clause.Expr{SQL: "`filter_test_models`.`name` LIKE ?", Vars: []any{"%b%"}},
+						},
+					},
+				},
+			},
+			clause.AndConditions{
+				Exprs: []clause.Expression{
+					clause.Expr{SQL: "`filter_test_models`.`id` < ?", Vars: []any{uint64(10)}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, db.Statement.Clauses["WHERE"].Expression)
+
+	// The request is not modified
+	assert.False(t, request.FilterGroups.Val[0][1].Or)
+}
+
+func TestRequestNormalizedHash(t *testing.T) {
+	newRequest := func(arg string, page int) *Request {
+		return &Request{
+			Search:  typeutil.NewUndefined(arg),
+			Filter:  typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{arg}, Operator: Operators["$cont"]}}),
+			Sort:    typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortAscending}}),
+			Join:    typeutil.NewUndefined([]*Join{{Relation: "Relation", Fields: []string{"a", "b"}}}),
+			Fields:  typeutil.NewUndefined([]string{"id", "name"}),
+			Page:    typeutil.NewUndefined(page),
+			PerPage: typeutil.NewUndefined(15),
+		}
+	}
+
+	hash := newRequest("val1", 1).NormalizedHash()
+	assert.Len(t, hash, 16)
+	assert.Equal(t, hash, newRequest("val2", 3).NormalizedHash())
+	assert.NotEqual(t, hash, (&Request{}).NormalizedHash())
+
+	different := newRequest("val1", 1)
+	different.Filter.Val[0].Operator = Operators["$eq"]
+	assert.NotEqual(t, hash, different.NormalizedHash())
+
+	different = newRequest("val1", 1)
+	different.Sort.Val[0].Order = SortDescending
+	assert.NotEqual(t, hash, different.NormalizedHash())
+
+	different = newRequest("val1", 1)
+	different.Or = different.Filter
+	different.Filter = typeutil.Undefined[[]*Filter]{}
+	assert.NotEqual(t, hash, different.NormalizedHash())
+
+	different = newRequest("val1", 1)
+	different.Search = typeutil.Undefined[string]{}
+	assert.NotEqual(t, hash, different.NormalizedHash())
+}
+
+func TestScopeStatementSettings(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"val"}, Operator: Operators["$cont"]}}),
+	}
+	db := openDryRunDB(t)
+
+	settings := map[string]any{}
+	err := db.Callback().Query().Before("gorm:query").Register("test:settings", func(tx *gorm.DB) {
+		for _, key := range []string{RequestHashSetting, ModelSetting} {
+			if v, ok := tx.Get(key); ok {
+				settings[key] = v
+			}
+		}
+	})
+	require.NoError(t, err)
+
+	results := []*TestScopeModel{}
+	_, err = Scope(db, request, &results)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		RequestHashSetting: request.NormalizedHash(),
+		ModelSetting:       "TestScopeModel",
+	}, settings)
+}
+
+func TestScopeCountUnfiltered(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"val"}, Operator: Operators["$cont"]}}),
+		Search: typeutil.NewUndefined("search"),
+	}
+	db := openDryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Query().After("gorm:query").Register("test:count", func(tx *gorm.DB) {
+		if count, ok := tx.Statement.Dest.(*int64); ok {
+			queries = append(queries, tx.Statement.SQL.String())
+			*count = int64(len(queries) * 10)
+			tx.RowsAffected = 1
+		}
+	})
+	require.NoError(t, err)
+
+	results := []*TestScopeModel{}
+	settings := &Settings[*TestScopeModel]{CountUnfiltered: true, FieldsSearch: []string{"email"}}
+	paginator, err := settings.Scope(db.Where("relation_id = ?", 1), request, &results)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"SELECT count(*) FROM `test_scope_models` WHERE relation_id = ?",
+		"SELECT count(*) FROM `test_scope_models` WHERE relation_id = ? AND `test_scope_models`.`name` LIKE ? AND `test_scope_models`.`email` LIKE ?",
+	}, queries)
+	assert.Equal(t, int64(20), paginator.Total)
+	total, ok := paginator.DB.Get(UnfilteredTotalSetting)
+	require.True(t, ok)
+	assert.Equal(t, int64(10), total)
+
+	t.Run("disabled", func(t *testing.T) {
+		queries = queries[:0]
+		paginator, err := (&Settings[*TestScopeModel]{}).Scope(db, request, &results)
+		require.NoError(t, err)
+		assert.Len(t, queries, 1)
+		_, ok := paginator.DB.Get(UnfilteredTotalSetting)
+		assert.False(t, ok)
+	})
+
+	t.Run("table_schema", func(t *testing.T) {
+		queries = queries[:0]
+		_, err := (&Settings[*TestScopeModel]{CountUnfiltered: true, TableSchema: "tenant"}).Scope(db, &Request{}, &results)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT count(*) FROM `tenant`.`test_scope_models`", queries[0])
+	})
+}
+
+func TestSimpleRequest(t *testing.T) {
+	search := "val"
+	page := 2
+	perPage := 15
+	filters := []*Filter{{Field: "name", Args: []string{"val1"}, Operator: Operators["$cont"]}}
+	or := []*Filter{{Field: "name", Args: []string{"val2"}, Operator: Operators["$eq"], Or: true}}
+	sorts := []*Sort{{Field: "name", Order: SortAscending}}
+	joins := []*Join{{Relation: "Relation", Fields: []string{"a", "b"}}}
+	fields := []string{"id", "name"}
+
+	simple := &SimpleRequest{
+		Search:  &search,
+		Filter:  filters,
+		Or:      or,
+		Sort:    sorts,
+		Join:    joins,
+		Fields:  fields,
+		Page:    &page,
+		PerPage: &perPage,
+	}
+	request := &Request{
+		Search:  typeutil.NewUndefined(search),
+		Filter:  typeutil.NewUndefined(filters),
+		Or:      typeutil.NewUndefined(or),
+		Sort:    typeutil.NewUndefined(sorts),
+		Join:    typeutil.NewUndefined(joins),
+		Fields:  typeutil.NewUndefined(fields),
+		Page:    typeutil.NewUndefined(page),
+		PerPage: typeutil.NewUndefined(perPage),
+	}
+
+	assert.Equal(t, request, simple.ToRequest())
+	assert.Equal(t, simple, request.ToSimpleRequest())
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, &Request{}, (&SimpleRequest{}).ToRequest())
+		assert.Equal(t, &SimpleRequest{}, (&Request{}).ToSimpleRequest())
+	})
+
+	t.Run("present_nil_slice", func(t *testing.T) {
+		request := &Request{Filter: typeutil.NewUndefined[[]*Filter](nil)}
+		simple := request.ToSimpleRequest()
+		assert.NotNil(t, simple.Filter)
+		assert.Empty(t, simple.Filter)
+		assert.True(t, simple.ToRequest().Filter.Present)
+	})
+}
+
+func TestRequestJSON(t *testing.T) {
+	request := &Request{
+		Search: typeutil.NewUndefined("val"),
+		Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"val1"}, Operator: Operators["$cont"]}}),
+		Or:     typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"val2"}, Operator: Operators["$eq"], Or: true}}),
+		FilterGroups: typeutil.NewUndefined([][]*Filter{
+			{{Field: "name", Args: []string{"val3"}, Operator: Operators["$eq"], Or: true}},
+		}),
+		Sort:         typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortAscending}}),
+		Join:         typeutil.NewUndefined([]*Join{{Relation: "Relation", Fields: []string{"a", "b"}}}),
+		Fields:       typeutil.NewUndefined([]string{"id", "name"}),
+		DistinctOn:   typeutil.NewUndefined([]string{"name"}),
+		UpdatedSince: typeutil.NewUndefined(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)),
+		Snapshot:     typeutil.NewUndefined("token"),
+		Page:         typeutil.NewUndefined(2),
+		PerPage:      typeutil.NewUndefined(15),
+	}
+
+	data, err := json.Marshal(request)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"search": "val",
+		"filter": [{"field": "name", "operator": "$cont", "args": ["val1"], "or": false}],
+		"or": [{"field": "name", "operator": "$eq", "args": ["val2"], "or": true}],
+		"filter_groups": [[{"field": "name", "operator": "$eq", "args": ["val3"], "or": true}]],
+		"sort": [{"field": "name", "order": "ASC"}],
+		"join": [{"relation": "Relation", "fields": ["a", "b"]}],
+		"fields": ["id", "name"],
+		"distinct_on": ["name"],
+		"updated_since": "2024-03-01T12:00:00Z",
+		"snapshot": "token",
+		"page": 2,
+		"per_page": 15
+	}`, string(data))
+
+	result := &Request{}
+	require.NoError(t, json.Unmarshal(data, result))
+	assert.Equal(t, request, result)
+
+	t.Run("not_present", func(t *testing.T) {
+		request := &Request{Filter: typeutil.NewUndefined([]*Filter{})}
+		data, err := json.Marshal(request)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"search":null,"filter":[],"or":null,"filter_groups":null,"sort":null,"join":null,"fields":null,"distinct_on":null,"updated_since":null,"snapshot":null,"page":null,"per_page":null}`, string(data))
+
+		result := &Request{}
+		require.NoError(t, json.Unmarshal(data, result))
+		assert.Equal(t, request, result)
+
+		result = &Request{}
+		require.NoError(t, json.Unmarshal([]byte(`{}`), result))
+		assert.Equal(t, &Request{}, result)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		require.Error(t, json.Unmarshal([]byte(`{"filter":[{"field":"name","operator":"$notanoperator"}]}`), &Request{}))
+		require.Error(t, json.Unmarshal([]byte(`[]`), &Request{}))
+	})
+}
+
 func TestScopeWithCaseInsensitiveSort(t *testing.T) {
 	request := &Request{
 		Sort: typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortAscending}}),
@@ -2123,3 +3221,234 @@ func TestScopeWithCaseInsensitiveSort(t *testing.T) {
 	}
 	assert.Equal(t, expected, paginator.DB.Statement.Clauses)
 }
+
+type AutoPruneTestModel struct {
+	Content string `filterSelect:"lazy"`
+	Title   string
+	ID      uint `filterSelect:"lazy"`
+}
+
+func TestScopeAutoPruneSelects(t *testing.T) {
+	cases := []struct {
+		request  *Request
+		settings *Settings[*AutoPruneTestModel]
+		desc     string
+		want     string
+	}{
+		{desc: "disabled", request: &Request{}, settings: &Settings[*AutoPruneTestModel]{}, want: "SELECT `auto_prune_test_models`.`content`,`auto_prune_test_models`.`title`,`auto_prune_test_models`.`id` FROM `auto_prune_test_models`"},
+		{desc: "pruned", request: &Request{}, settings: &Settings[*AutoPruneTestModel]{AutoPruneSelects: true}, want: "SELECT `auto_prune_test_models`.`title`,`auto_prune_test_models`.`id` FROM `auto_prune_test_models`"},
+		{
+			desc:     "requested",
+			request:  &Request{Fields: typeutil.NewUndefined([]string{"content", "title"})},
+			settings: &Settings[*AutoPruneTestModel]{AutoPruneSelects: true},
+			want:     "SELECT `auto_prune_test_models`.`content`,`auto_prune_test_models`.`title` FROM `auto_prune_test_models`",
+		},
+		{
+			desc:     "fields_disabled",
+			request:  &Request{Fields: typeutil.NewUndefined([]string{"content", "title"})},
+			settings: &Settings[*AutoPruneTestModel]{AutoPruneSelects: true, DisableFields: true},
+			want:     "SELECT `auto_prune_test_models`.`title`,`auto_prune_test_models`.`id` FROM `auto_prune_test_models`",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			results := []*AutoPruneTestModel{}
+			db := c.settings.ScopeUnpaginated(openDryRunDB(t), c.request, &results)
+			require.NoError(t, db.Error)
+			assert.Equal(t, c.want, db.Statement.SQL.String())
+		})
+	}
+}
+
+type AutoJoinTestOwner struct {
+	Name string
+	ID   uint
+}
+
+type AutoJoinTestComment struct {
+	Content  string
+	ID       uint
+	ParentID uint
+}
+
+type AutoJoinTestModel struct {
+	Owner      *AutoJoinTestOwner
+	Category   *AutoJoinTestOwner
+	Comments   []*AutoJoinTestComment `gorm:"foreignKey:ParentID"`
+	Name       string
+	ID         uint
+	OwnerID    uint
+	CategoryID uint
+}
+
+func TestScopeAutoJoinBelongsTo(t *testing.T) {
+	cases := []struct {
+		request  *Request
+		settings *Settings[*AutoJoinTestModel]
+		desc     string
+		want     []string
+	}{
+		{
+			desc:     "auto_join",
+			request:  &Request{},
+			settings: &Settings[*AutoJoinTestModel]{AutoJoinBelongsTo: true, Blacklist: Blacklist{RelationsBlacklist: []string{"Category"}}},
+			want:     []string{"Owner"},
+		},
+		{
+			desc:     "requested_join",
+			request:  &Request{Join: typeutil.NewUndefined([]*Join{{Relation: "Comments"}})},
+			settings: &Settings[*AutoJoinTestModel]{AutoJoinBelongsTo: true},
+			want:     []string{"Comments"},
+		},
+		{
+			desc:     "join_disabled",
+			request:  &Request{Join: typeutil.NewUndefined([]*Join{{Relation: "Comments"}})},
+			settings: &Settings[*AutoJoinTestModel]{AutoJoinBelongsTo: true, DisableJoin: true},
+			want:     []string{"Owner", "Category"},
+		},
+		{
+			desc:     "disabled",
+			request:  &Request{},
+			settings: &Settings[*AutoJoinTestModel]{},
+			want:     []string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			results := []*AutoJoinTestModel{}
+			db := c.settings.ScopeUnpaginated(openDryRunDB(t), c.request, &results)
+			require.NoError(t, db.Error)
+			assert.ElementsMatch(t, c.want, lo.Keys(db.Statement.Preloads))
+		})
+	}
+}
+
+type KeyFieldsTestModel struct {
+	Owner   *AutoJoinTestOwner
+	Name    string
+	ID      uint
+	OwnerID uint
+}
+
+func TestScopeKeyFields(t *testing.T) {
+	cases := []struct {
+		request     *Request
+		wantErr     error
+		desc        string
+		want        string
+		wantPreload []string
+		mode        KeyFieldsMode
+	}{
+		{
+			desc:        "add",
+			mode:        KeyFieldsAdd,
+			request:     &Request{Fields: typeutil.NewUndefined([]string{"name"}), Join: typeutil.NewUndefined([]*Join{{Relation: "Owner"}})},
+			want:        "SELECT `key_fields_test_models`.`name`,`key_fields_test_models`.`id`,`key_fields_test_models`.`owner_id` FROM `key_fields_test_models`",
+			wantPreload: []string{"Owner"},
+		},
+		{
+			desc:    "reject",
+			mode:    KeyFieldsReject,
+			request: &Request{Fields: typeutil.NewUndefined([]string{"name", "owner_id"}), Join: typeutil.NewUndefined([]*Join{{Relation: "Owner"}})},
+			wantErr: ErrKeyFieldsExcluded,
+		},
+		{
+			desc:        "reject_keys_selected",
+			mode:        KeyFieldsReject,
+			request:     &Request{Fields: typeutil.NewUndefined([]string{"name", "id", "owner_id"}), Join: typeutil.NewUndefined([]*Join{{Relation: "Owner"}})},
+			want:        "SELECT `key_fields_test_models`.`name`,`key_fields_test_models`.`id`,`key_fields_test_models`.`owner_id` FROM `key_fields_test_models`",
+			wantPreload: []string{"Owner"},
+		},
+		{
+			desc:    "reject_no_join",
+			mode:    KeyFieldsReject,
+			request: &Request{Fields: typeutil.NewUndefined([]string{"name"})},
+			want:    "SELECT `key_fields_test_models`.`name` FROM `key_fields_test_models`",
+		},
+		{
+			desc:    "omit",
+			mode:    KeyFieldsOmit,
+			request: &Request{Fields: typeutil.NewUndefined([]string{"name"}), Join: typeutil.NewUndefined([]*Join{{Relation: "Owner"}})},
+			want:    "SELECT `key_fields_test_models`.`name` FROM `key_fields_test_models`",
+		},
+		{
+			desc:        "omit_keys_selected",
+			mode:        KeyFieldsOmit,
+			request:     &Request{Fields: typeutil.NewUndefined([]string{"id", "owner_id"}), Join: typeutil.NewUndefined([]*Join{{Relation: "Owner"}})},
+			want:        "SELECT `key_fields_test_models`.`id`,`key_fields_test_models`.`owner_id` FROM `key_fields_test_models`",
+			wantPreload: []string{"Owner"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			results := []*KeyFieldsTestModel{}
+			db := (&Settings[*KeyFieldsTestModel]{KeyFields: c.mode}).ScopeUnpaginated(openDryRunDB(t), c.request, &results)
+			if c.wantErr != nil {
+				require.ErrorIs(t, db.Error, c.wantErr)
+				assert.ErrorContains(t, db.Error, ": id")
+				return
+			}
+			require.NoError(t, db.Error)
+			assert.Equal(t, c.want, db.Statement.SQL.String())
+			assert.ElementsMatch(t, c.wantPreload, lo.Keys(db.Statement.Preloads))
+		})
+	}
+}
+
+func TestScopeMaxArgLength(t *testing.T) {
+	settings := &Settings[*FilterTestModel]{
+		MaxArgLength:      3,
+		FieldMaxArgLength: map[string]int{"Relation.name": 5, "id": 0},
+	}
+	cases := []struct {
+		request *Request
+		desc    string
+		wantErr string
+	}{
+		{desc: "valid", request: &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$in"], Args: []string{"abc", "é€ß"}}})}},
+		{
+			desc:    "filter",
+			request: &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$in"], Args: []string{"abc", "abcd"}}})},
+			wantErr: "argument too long: an argument of the filter on \"name\" is 4 characters long, the maximum is 3",
+		},
+		{
+			desc:    "or",
+			request: &Request{Or: typeutil.NewUndefined([]*Filter{{Field: "name", Operator: Operators["$eq"], Args: []string{"abcd"}}})},
+			wantErr: "argument too long: an argument of the filter on \"name\" is 4 characters long, the maximum is 3",
+		},
+		{
+			desc:    "filter_group",
+			request: &Request{FilterGroups: typeutil.NewUndefined([][]*Filter{{{Field: "name", Operator: Operators["$eq"], Args: []string{"abcd"}}}})},
+			wantErr: "argument too long: an argument of the filter on \"name\" is 4 characters long, the maximum is 3",
+		},
+		{desc: "field_override", request: &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "Relation.name", Operator: Operators["$eq"], Args: []string{"abcde"}}})}},
+		{
+			desc:    "field_override_exceeded",
+			request: &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "Relation.name", Operator: Operators["$eq"], Args: []string{"abcdef"}}})},
+			wantErr: "argument too long: an argument of the filter on \"Relation.name\" is 6 characters long, the maximum is 5",
+		},
+		{desc: "field_no_limit", request: &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "id", Operator: Operators["$eq"], Args: []string{"123456"}}})}},
+		{
+			desc:    "search",
+			request: &Request{Search: typeutil.NewUndefined("abcd")},
+			wantErr: "argument too long: the search query is 4 characters long, the maximum is 3",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			results := []*FilterTestModel{}
+			db := settings.ScopeUnpaginated(openDryRunDB(t), c.request, &results)
+			if c.wantErr == "" {
+				require.NoError(t, db.Error)
+				return
+			}
+			require.ErrorIs(t, db.Error, ErrArgTooLong)
+			assert.Equal(t, c.wantErr, db.Error.Error())
+			assert.Empty(t, db.Statement.SQL.String())
+		})
+	}
+}
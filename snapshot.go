@@ -0,0 +1,212 @@
+package filter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/errors"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+var (
+	// DefaultSnapshotTTL the duration a snapshot is kept if `Snapshots.TTL` is not set.
+	DefaultSnapshotTTL = 5 * time.Minute
+
+	// DefaultMaxSnapshots the maximum number of snapshots kept at the same time
+	// if `Snapshots.MaxSnapshots` is not set.
+	DefaultMaxSnapshots = 10
+)
+
+// SnapshotNew the value of the "snapshot" query requesting the creation of a new snapshot.
+const SnapshotNew = "new"
+
+// Snapshots keeps the database snapshots used by `Settings.Scope()` so all the pages of the
+// same listing see the same data, even while writes continue (PostgreSQL only).
+//
+// Snapshots are only used if the client asks for them. When the "snapshot" query is `SnapshotNew`,
+// a `REPEATABLE READ` transaction is opened and its snapshot is exported with `pg_export_snapshot()`.
+// The transaction is kept open until the snapshot expires, so it holds a connection of the pool:
+// keep the `TTL` short and `MaxSnapshots` lower than the size of the pool. The requests without
+// "snapshot" query are executed normally.
+// The token identifying the snapshot is stored in the `SnapshotSetting` statement setting.
+// The requests containing this token are then executed in a transaction importing the
+// snapshot with `SET TRANSACTION SNAPSHOT`.
+//
+// The snapshots are kept in memory: the requests using a token must be handled by the
+// same instance of the application. A `Snapshots` must not be copied after first use.
+type Snapshots struct {
+	// TTL the duration a snapshot is kept after its creation. Once expired, the requests
+	// using its token result in an error wrapping `ErrSnapshotExpired`. A snapshot being
+	// imported by a request is only released once imported. Defaults to `DefaultSnapshotTTL`.
+	TTL time.Duration
+
+	// MaxSnapshots the maximum number of snapshots kept at the same time. Once reached, the
+	// requests asking for a new snapshot result in an error wrapping `ErrTooManySnapshots`
+	// until a snapshot expires. Defaults to `DefaultMaxSnapshots`. If negative, there is no limit.
+	MaxSnapshots int
+
+	// export returns the identifier of the snapshot of the given transaction.
+	// Defaults to `exportSnapshot()`.
+	export func(tx *gorm.DB) (string, error)
+
+	snapshots map[string]*snapshot
+	// pending the number of snapshots being created
+	pending int
+	mu      sync.Mutex
+}
+
+type snapshot struct {
+	tx    *gorm.DB
+	timer *time.Timer
+	id    string
+	// imports read-locked while the snapshot is being imported, so the transaction
+	// holding it is not rolled back before the import is complete.
+	imports sync.RWMutex
+}
+
+// Close releases all the snapshots and rolls back their transactions.
+// The snapshots created afterwards are kept as usual.
+func (s *Snapshots) Close() {
+	s.mu.Lock()
+	snapshots := s.snapshots
+	s.snapshots = nil
+	s.mu.Unlock()
+	for _, snap := range snapshots {
+		snap.timer.Stop()
+		snap.rollback()
+	}
+}
+
+// acquire returns the token and the identifier of the snapshot identified by the given token.
+// If the token is `SnapshotNew`, a new snapshot is created. If the token is not present,
+// empty strings are returned: the request doesn't use a snapshot.
+// The snapshot cannot expire until imported is called, so it can be imported safely.
+// imported is never nil and can be called more than once.
+func (s *Snapshots) acquire(db *gorm.DB, token typeutil.Undefined[string]) (tok string, id string, imported func(), err error) {
+	imported = func() {}
+	if !token.Present || token.Val == "" {
+		return "", "", imported, nil
+	}
+	var snap *snapshot
+	if token.Val == SnapshotNew {
+		tok, snap, err = s.create(db)
+		if err != nil {
+			return "", "", imported, err
+		}
+	} else {
+		tok = token.Val
+		s.mu.Lock()
+		snap = s.snapshots[tok]
+		if snap != nil {
+			snap.imports.RLock()
+		}
+		s.mu.Unlock()
+		if snap == nil {
+			return "", "", imported, errors.New(ErrSnapshotExpired)
+		}
+	}
+	return tok, snap.id, sync.OnceFunc(snap.imports.RUnlock), nil
+}
+
+// create opens the transaction holding a new snapshot and exports it.
+// Returns the token identifying the new snapshot, which is read-locked for its import.
+func (s *Snapshots) create(db *gorm.DB) (string, *snapshot, error) {
+	maxSnapshots := lo.Ternary(s.MaxSnapshots != 0, s.MaxSnapshots, DefaultMaxSnapshots)
+	s.mu.Lock()
+	if maxSnapshots > 0 && len(s.snapshots)+s.pending >= maxSnapshots {
+		s.mu.Unlock()
+		return "", nil, errors.Errorf("%w: the maximum is %d", ErrTooManySnapshots, maxSnapshots)
+	}
+	s.pending++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.pending--
+		s.mu.Unlock()
+	}()
+
+	// The transaction outlives the request: it must not be rolled back when the request's context is canceled.
+	tx := db.Session(&gorm.Session{NewDB: true, Context: context.WithoutCancel(db.Statement.Context)}).Begin()
+	if tx.Error != nil {
+		return "", nil, errors.New(tx.Error)
+	}
+	if err := tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ").Error; err != nil {
+		tx.Rollback()
+		return "", nil, errors.New(err)
+	}
+	export := lo.Ternary(s.export != nil, s.export, exportSnapshot)
+	id, err := export(tx)
+	if err != nil {
+		tx.Rollback()
+		return "", nil, errors.New(err)
+	}
+	token, err := newSnapshotToken()
+	if err != nil {
+		tx.Rollback()
+		return "", nil, errors.New(err)
+	}
+
+	ttl := lo.Ternary(s.TTL > 0, s.TTL, DefaultSnapshotTTL)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snapshots == nil {
+		s.snapshots = map[string]*snapshot{}
+	}
+	snap := &snapshot{tx: tx, id: id}
+	snap.imports.RLock()
+	snap.timer = time.AfterFunc(ttl, func() { s.release(token) })
+	s.snapshots[token] = snap
+	return token, snap, nil
+}
+
+// release removes the snapshot identified by the given token and rolls back its transaction.
+func (s *Snapshots) release(token string) {
+	s.mu.Lock()
+	snap, ok := s.snapshots[token]
+	delete(s.snapshots, token)
+	s.mu.Unlock()
+	if ok {
+		snap.rollback()
+	}
+}
+
+// rollback rolls back the transaction holding the snapshot once the imports in progress
+// are complete.
+func (snap *snapshot) rollback() {
+	snap.imports.Lock()
+	defer snap.imports.Unlock()
+	snap.tx.Rollback()
+}
+
+// exportSnapshot returns the identifier of the snapshot of the given transaction.
+func exportSnapshot(tx *gorm.DB) (string, error) {
+	var id string
+	err := tx.Raw("SELECT pg_export_snapshot()").Scan(&id).Error
+	return id, err
+}
+
+// useSnapshot makes the given transaction use the snapshot identified by the given id.
+// Must be executed before any other query of the transaction.
+func useSnapshot(tx *gorm.DB, id string) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	if err := tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ").Error; err != nil {
+		return err
+	}
+	// SET TRANSACTION doesn't support placeholders. The id is generated by the database.
+	return tx.Exec("SET TRANSACTION SNAPSHOT '" + strings.ReplaceAll(id, "'", "''") + "'").Error
+}
+
+// newSnapshotToken returns a random token identifying a snapshot.
+func newSnapshotToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,198 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+func TestScopeSnapshots(t *testing.T) {
+	db := openDryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Raw().After("gorm:raw").Register("test:snapshot", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	})
+	require.NoError(t, err)
+
+	exported := 0
+	snapshots := &Snapshots{
+		export: func(tx *gorm.DB) (string, error) {
+			exported++
+			return fmt.Sprintf("00000003-0000001B-%d", exported), tx.Error
+		},
+	}
+	t.Cleanup(snapshots.Close)
+	settings := &Settings[*TestScopeModel]{Snapshots: snapshots}
+
+	results := []*TestScopeModel{}
+	newSnapshot := &Request{Snapshot: typeutil.NewUndefined(SnapshotNew)}
+	paginator, err := settings.Scope(db, newSnapshot, &results)
+	require.NoError(t, err)
+	token, ok := paginator.DB.Get(SnapshotSetting)
+	require.True(t, ok)
+	require.Len(t, token, 32)
+	assert.Equal(t, []string{
+		"SET TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"SET TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"SET TRANSACTION SNAPSHOT '00000003-0000001B-1'",
+	}, queries)
+
+	t.Run("next_page", func(t *testing.T) {
+		queries = queries[:0]
+		request := &Request{Snapshot: typeutil.NewUndefined(token.(string)), Page: typeutil.NewUndefined(2)}
+		paginator, err := settings.Scope(db, request, &results)
+		require.NoError(t, err)
+		next, ok := paginator.DB.Get(SnapshotSetting)
+		require.True(t, ok)
+		assert.Equal(t, token, next)
+		assert.Equal(t, 1, exported)
+		assert.Equal(t, []string{
+			"SET TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+			"SET TRANSACTION SNAPSHOT '00000003-0000001B-1'",
+		}, queries)
+	})
+
+	t.Run("unknown_token", func(t *testing.T) {
+		request := &Request{Snapshot: typeutil.NewUndefined("unknown")}
+		paginator, err := settings.Scope(db, request, &results)
+		require.ErrorIs(t, err, ErrSnapshotExpired)
+		assert.Nil(t, paginator)
+	})
+
+	t.Run("no_token", func(t *testing.T) {
+		for _, request := range []*Request{{}, {Snapshot: typeutil.NewUndefined("")}} {
+			queries = queries[:0]
+			paginator, err := settings.Scope(db, request, &results)
+			require.NoError(t, err)
+			_, ok := paginator.DB.Get(SnapshotSetting)
+			assert.False(t, ok)
+			assert.Empty(t, queries)
+			assert.Equal(t, 1, exported)
+		}
+	})
+
+	t.Run("new_snapshot", func(t *testing.T) {
+		paginator, err := settings.Scope(db, newSnapshot, &results)
+		require.NoError(t, err)
+		next, ok := paginator.DB.Get(SnapshotSetting)
+		require.True(t, ok)
+		assert.NotEqual(t, token, next)
+		assert.Equal(t, 2, exported)
+	})
+
+	t.Run("max_snapshots", func(t *testing.T) {
+		snapshots := &Snapshots{
+			MaxSnapshots: 1,
+			export:       func(_ *gorm.DB) (string, error) { return "00000003-0000001E-1", nil },
+		}
+		t.Cleanup(snapshots.Close)
+		settings := &Settings[*TestScopeModel]{Snapshots: snapshots}
+		paginator, err := settings.Scope(db, newSnapshot, &results)
+		require.NoError(t, err)
+		token, _ := paginator.DB.Get(SnapshotSetting)
+
+		_, err = settings.Scope(db, newSnapshot, &results)
+		require.ErrorIs(t, err, ErrTooManySnapshots)
+
+		// The existing snapshot can still be used
+		_, err = settings.Scope(db, &Request{Snapshot: typeutil.NewUndefined(token.(string))}, &results)
+		require.NoError(t, err)
+
+		snapshots.release(token.(string))
+		_, err = settings.Scope(db, newSnapshot, &results)
+		require.NoError(t, err)
+	})
+
+	t.Run("export_error", func(t *testing.T) {
+		snapshots := &Snapshots{
+			export: func(_ *gorm.DB) (string, error) {
+				return "", fmt.Errorf("test error")
+			},
+		}
+		settings := &Settings[*TestScopeModel]{Snapshots: snapshots}
+		_, err := settings.Scope(db, newSnapshot, &results)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "test error")
+		assert.Empty(t, snapshots.snapshots)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		snapshots := &Snapshots{
+			TTL:    10 * time.Millisecond,
+			export: func(_ *gorm.DB) (string, error) { return "00000003-0000001C-1", nil },
+		}
+		t.Cleanup(snapshots.Close)
+		settings := &Settings[*TestScopeModel]{Snapshots: snapshots}
+		paginator, err := settings.Scope(db, newSnapshot, &results)
+		require.NoError(t, err)
+		token, _ := paginator.DB.Get(SnapshotSetting)
+
+		time.Sleep(50 * time.Millisecond)
+		snapshots.mu.Lock()
+		assert.Empty(t, snapshots.snapshots)
+		snapshots.mu.Unlock()
+
+		_, err = settings.Scope(db, &Request{Snapshot: typeutil.NewUndefined(token.(string))}, &results)
+		require.ErrorIs(t, err, ErrSnapshotExpired)
+	})
+
+	t.Run("expiry_during_import", func(t *testing.T) {
+		snapshots := &Snapshots{export: func(_ *gorm.DB) (string, error) { return "00000003-0000001F-1", nil }}
+		t.Cleanup(snapshots.Close)
+		settings := &Settings[*TestScopeModel]{Snapshots: snapshots}
+		paginator, err := settings.Scope(db, newSnapshot, &results)
+		require.NoError(t, err)
+		token, _ := paginator.DB.Get(SnapshotSetting)
+
+		db := openDryRunDB(t)
+		released := make(chan struct{})
+		err = db.Callback().Raw().After("gorm:raw").Register("test:expire", func(tx *gorm.DB) {
+			if !strings.HasPrefix(tx.Statement.SQL.String(), "SET TRANSACTION SNAPSHOT") {
+				return
+			}
+			go func() {
+				snapshots.release(token.(string))
+				close(released)
+			}()
+			select {
+			case <-released:
+				assert.Fail(t, "the snapshot was released while being imported")
+			case <-time.After(20 * time.Millisecond):
+			}
+		})
+		require.NoError(t, err)
+
+		_, err = settings.Scope(db, &Request{Snapshot: typeutil.NewUndefined(token.(string))}, &results)
+		require.NoError(t, err)
+		<-released
+	})
+
+	t.Run("close", func(t *testing.T) {
+		snapshots := &Snapshots{export: func(_ *gorm.DB) (string, error) { return "00000003-0000001D-1", nil }}
+		settings := &Settings[*TestScopeModel]{Snapshots: snapshots}
+		paginator, err := settings.Scope(db, newSnapshot, &results)
+		require.NoError(t, err)
+		token, _ := paginator.DB.Get(SnapshotSetting)
+		snapshots.Close()
+
+		_, err = settings.Scope(db, &Request{Snapshot: typeutil.NewUndefined(token.(string))}, &results)
+		require.ErrorIs(t, err, ErrSnapshotExpired)
+	})
+}
+
+func TestUseSnapshotQuote(t *testing.T) {
+	db := openDryRunDB(t)
+	queries := []string{}
+	err := db.Callback().Raw().After("gorm:raw").Register("test:snapshot", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	})
+	require.NoError(t, err)
+	require.NoError(t, useSnapshot(db, "a'b"))
+	assert.Equal(t, "SET TRANSACTION SNAPSHOT 'a''b'", queries[1])
+}
@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -12,8 +13,8 @@ import (
 // Sort structured representation of a sort query.
 // The generic parameter is the type pointer type of the model.
 type Sort struct {
-	Field string
-	Order SortOrder
+	Field string    `json:"field"`
+	Order SortOrder `json:"order"`
 }
 
 // SortOrder the allowed strings for SQL "ORDER BY" clause.
@@ -29,6 +30,12 @@ const (
 // Scope returns the GORM scope to use in order to apply sorting.
 // If caseInsensitive is true, the column is wrapped in a `LOWER()` function.
 func (s *Sort) Scope(blacklist Blacklist, schema *schema.Schema, caseInsensitive bool) func(*gorm.DB) *gorm.DB {
+	return s.scope(blacklist, schema, caseInsensitive, true)
+}
+
+// scope returns the GORM scope applying the sort. If withJoin is false, the relation
+// the field belongs to is not joined by the scope and must be joined separately (see `joinPaths`).
+func (s *Sort) scope(blacklist Blacklist, schema *schema.Schema, caseInsensitive, withJoin bool) func(*gorm.DB) *gorm.DB {
 	field, sch, joinName := getField(s.Field, schema, &blacklist)
 	if field == nil {
 		return nil
@@ -37,7 +44,7 @@ func (s *Sort) Scope(blacklist Blacklist, schema *schema.Schema, caseInsensitive
 	computed := field.StructField.Tag.Get("computed")
 
 	return func(tx *gorm.DB) *gorm.DB {
-		if joinName != "" {
+		if withJoin && joinName != "" {
 			if err := tx.Statement.Parse(tx.Statement.Model); err != nil {
 				tx.AddError(err)
 				return tx
@@ -50,12 +57,12 @@ func (s *Sort) Scope(blacklist Blacklist, schema *schema.Schema, caseInsensitive
 		if computed != "" {
 			column = clause.Column{
 				Raw:  true,
-				Name: fmt.Sprintf("(%s)", strings.ReplaceAll(computed, clause.CurrentTable, tx.Statement.Quote(table))),
+				Name: columnExpression(tx.Statement, table, field),
 			}
 		} else if caseInsensitive && getDataType(field) == DataTypeText {
 			column = clause.Column{
 				Raw:  true,
-				Name: fmt.Sprintf("LOWER(%s.%s)", tx.Statement.Quote(table), tx.Statement.Quote(field.DBName)),
+				Name: fmt.Sprintf("LOWER(%s)", columnExpression(tx.Statement, table, field)),
 			}
 		} else {
 			column = clause.Column{
@@ -70,3 +77,19 @@ func (s *Sort) Scope(blacklist Blacklist, schema *schema.Schema, caseInsensitive
 		return tx.Order(c)
 	}
 }
+
+// UnmarshalJSON decodes a sort encoded as a JSON object. The order is
+// case-insensitive. Like `ParseSort()`, returns an error if the order is invalid.
+func (s *Sort) UnmarshalJSON(data []byte) error {
+	type rawSort Sort
+	var raw rawSort
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	raw.Order = SortOrder(strings.ToUpper(string(raw.Order)))
+	if raw.Order != SortAscending && raw.Order != SortDescending {
+		return fmt.Errorf("invalid sort order %q", raw.Order)
+	}
+	*s = Sort(raw)
+	return nil
+}
@@ -1,12 +1,15 @@
 package filter
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
+	"goyave.dev/goyave/v5/util/typeutil"
 )
 
 func TestSortScope(t *testing.T) {
@@ -563,3 +566,114 @@ func TestSortScopeCaseInsensitiveComputed(t *testing.T) {
 	db = db.Scopes(sort.Scope(Blacklist{}, schema, true)).Table("table").Find(&results)
 	assert.Equal(t, expected, db.Statement.Clauses)
 }
+
+type SortTestModelReservedKeyword struct {
+	Order string
+	Group string
+	ID    uint
+}
+
+func TestSortScopeReservedKeyword(t *testing.T) {
+	db := openDryRunDB(t)
+	results := []*SortTestModelReservedKeyword{}
+	schema, err := parseModel(db, &results)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	sortOrder := &Sort{Field: "order", Order: SortAscending}
+	sortGroup := &Sort{Field: "group", Order: SortDescending}
+	db = db.Model(&results).Scopes(sortOrder.Scope(Blacklist{}, schema, true), sortGroup.Scope(Blacklist{}, schema, false)).Find(&results)
+	expected := clause.OrderBy{
+		Columns: []clause.OrderByColumn{
+			{
+				Column: clause.Column{
+					Raw:  true,
+					Name: "LOWER(`sort_test_model_reserved_keywords`.`order`)",
+				},
+			},
+			{
+				Column: clause.Column{
+					Table: "sort_test_model_reserved_keywords",
+					Name:  "group",
+				},
+				Desc: true,
+			},
+		},
+	}
+	assert.Equal(t, expected, db.Statement.Clauses["ORDER BY"].Expression)
+	assert.Equal(t, "SELECT * FROM `sort_test_model_reserved_keywords` ORDER BY LOWER(`sort_test_model_reserved_keywords`.`order`),`sort_test_model_reserved_keywords`.`group` DESC", db.Statement.SQL.String())
+}
+
+func TestSortJSON(t *testing.T) {
+	sort := &Sort{Field: "name", Order: SortDescending}
+	data, err := json.Marshal(sort)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"field":"name","order":"DESC"}`, string(data))
+
+	result := &Sort{}
+	require.NoError(t, json.Unmarshal(data, result))
+	assert.Equal(t, sort, result)
+
+	result = &Sort{}
+	require.NoError(t, json.Unmarshal([]byte(`{"field":"name","order":"asc"}`), result))
+	assert.Equal(t, &Sort{Field: "name", Order: SortAscending}, result)
+
+	err = json.Unmarshal([]byte(`{"field":"name","order":"notanorder"}`), &Sort{})
+	require.Error(t, err)
+	assert.Equal(t, "invalid sort order \"NOTANORDER\"", err.Error())
+
+	require.Error(t, json.Unmarshal([]byte(`"name,ASC"`), &Sort{}))
+}
+
+func TestScopeInvalidSort(t *testing.T) {
+	selectModel := "SELECT `sort_test_models`.`name` FROM `sort_test_models` "
+	request := func() *Request {
+		return &Request{Sort: typeutil.NewUndefined([]*Sort{
+			{Field: "name", Order: SortDescending},
+			{Field: "id", Order: SortAscending},
+		})}
+	}
+	blacklist := Blacklist{FieldsBlacklist: []string{"id"}}
+	defaultSort := []*Sort{{Field: "name", Order: SortAscending}}
+
+	t.Run("ignore", func(t *testing.T) {
+		results := []*SortTestModel{}
+		db := (&Settings[*SortTestModel]{Blacklist: blacklist, DefaultSort: defaultSort}).ScopeUnpaginated(openDryRunDB(t), request(), &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, selectModel+"ORDER BY `sort_test_models`.`name` DESC", db.Statement.SQL.String())
+		_, ok := db.Get(SortFallbackSetting)
+		assert.False(t, ok)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		results := []*SortTestModel{}
+		settings := &Settings[*SortTestModel]{Blacklist: blacklist, InvalidSort: InvalidSortReject}
+		db := settings.ScopeUnpaginated(openDryRunDB(t), request(), &results)
+		require.ErrorIs(t, db.Error, ErrInvalidSort)
+		assert.Equal(t, "invalid sort: id", db.Error.Error())
+
+		_, err := settings.Scope(openDryRunDB(t), request(), &results)
+		require.ErrorIs(t, err, ErrInvalidSort)
+
+		db = settings.ScopeUnpaginated(openDryRunDB(t), &Request{Sort: typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortAscending}})}, &results)
+		require.NoError(t, db.Error)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		results := []*SortTestModel{}
+		settings := &Settings[*SortTestModel]{Blacklist: blacklist, DefaultSort: defaultSort, InvalidSort: InvalidSortDefault}
+		db := settings.ScopeUnpaginated(openDryRunDB(t), request(), &results)
+		require.NoError(t, db.Error)
+		assert.Equal(t, selectModel+"ORDER BY `sort_test_models`.`name`", db.Statement.SQL.String())
+		fallback, ok := db.Get(SortFallbackSetting)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"id"}, fallback)
+
+		paginator, err := settings.Scope(openDryRunDB(t), request(), &results)
+		require.NoError(t, err)
+		fallback, ok = paginator.DB.Get(SortFallbackSetting)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"id"}, fallback)
+	})
+}
@@ -0,0 +1,145 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConditionType the type of a `ConditionNode`.
+type ConditionType string
+
+const (
+	// ConditionAnd all the children of the node must match.
+	ConditionAnd ConditionType = "AND"
+	// ConditionOr at least one of the children of the node must match.
+	ConditionOr ConditionType = "OR"
+	// ConditionNot the child of the node must not match.
+	ConditionNot ConditionType = "NOT"
+	// ConditionFilter a leaf node representing a single filter.
+	ConditionFilter ConditionType = "FILTER"
+	// ConditionSearch a leaf node representing the search query. The fields
+	// the search applies to depend on the settings.
+	ConditionSearch ConditionType = "SEARCH"
+)
+
+// ConditionNode a node of the boolean tree of conditions built from a request.
+// See `Request.Tree()`.
+type ConditionNode struct {
+	// Filter the filter represented by a `ConditionFilter` node.
+	Filter *Filter
+	// Search the search query represented by a `ConditionSearch` node.
+	Search string
+	Type   ConditionType
+	// Children the operands of a `ConditionAnd`, `ConditionOr` or `ConditionNot` node.
+	Children []*ConditionNode
+}
+
+// Tree returns the effective boolean tree of conditions the scopes build from the request.
+// Returns nil if the request doesn't contain any condition.
+//
+// The filters of the "filter" parameter are combined with `AND`, the filters of the "or"
+// parameter are combined with `OR`, and these two groups are then combined with `OR`.
+// If there are several "or" filters and at least one "filter", the "or" filters are
+// combined with `AND` instead: `?filter=a&filter=b&or=c&or=d` results in
+// `(a AND b) OR (c AND d)`. The filter groups (`filter[0]`, `filter[1]`, ...), the
+// programmatic `Condition` and the search are then combined with the other filters using `AND`.
+//
+// The settings are not taken into account: this tree doesn't reflect disabled features
+// or filters ignored because of the blacklist.
+func (r *Request) Tree() *ConditionNode {
+	andFilters := r.Filter.Default(nil)
+	orFilters := r.Or.Default(nil)
+	mixed := len(orFilters) > 1 && len(andFilters) > 0
+
+	filters := &ConditionNode{Type: ConditionOr}
+	filters.add(filtersNode(andFilters, false))
+	filters.add(filtersNode(orFilters, mixed))
+
+	root := &ConditionNode{Type: ConditionAnd}
+	root.add(filters.simplify())
+	for _, group := range r.FilterGroups.Default(nil) {
+		node := &ConditionNode{Type: ConditionOr}
+		for _, f := range group {
+			node.add(&ConditionNode{Type: ConditionFilter, Filter: f})
+		}
+		root.add(node.simplify())
+	}
+	if r.Condition != nil {
+		root.add(r.Condition.Node())
+	}
+	if r.Search.Present {
+		root.add(&ConditionNode{Type: ConditionSearch, Search: r.Search.Val})
+	}
+	return root.simplify()
+}
+
+// filtersNode returns the node representing the given filters combined in the same
+// way as a SQL expression: each filter having `Or` set to true starts a new `AND` group,
+// and these groups are combined with `OR`. If and is true, the `Or` value of the
+// filters is ignored and all filters are combined with `AND`.
+func filtersNode(filters []*Filter, and bool) *ConditionNode {
+	node := &ConditionNode{Type: ConditionOr}
+	group := &ConditionNode{Type: ConditionAnd}
+	for i, f := range filters {
+		if f.Or && !and && i > 0 {
+			node.add(group.simplify())
+			group = &ConditionNode{Type: ConditionAnd}
+		}
+		group.add(&ConditionNode{Type: ConditionFilter, Filter: f})
+	}
+	node.add(group.simplify())
+	return node.simplify()
+}
+
+func (n *ConditionNode) add(child *ConditionNode) {
+	if child != nil {
+		n.Children = append(n.Children, child)
+	}
+}
+
+// simplify returns nil if the node has no children, or the only child of the
+// node if it has exactly one.
+func (n *ConditionNode) simplify() *ConditionNode {
+	switch len(n.Children) {
+	case 0:
+		return nil
+	case 1:
+		return n.Children[0]
+	default:
+		return n
+	}
+}
+
+// String returns a human-readable representation of the tree. Filters are represented
+// using the query syntax (e.g. `name||$eq||John`) and the search query using `search(John)`.
+func (n *ConditionNode) String() string {
+	switch n.Type {
+	case ConditionFilter:
+		name, ok := operatorName(n.Filter.Operator)
+		if !ok {
+			name = "?"
+		}
+		if len(n.Filter.Args) == 0 {
+			return n.Filter.Field + "||" + name
+		}
+		return n.Filter.Field + "||" + name + "||" + strings.Join(n.Filter.Args, ",")
+	case ConditionSearch:
+		return fmt.Sprintf("search(%s)", n.Search)
+	case ConditionNot:
+		children := make([]string, 0, len(n.Children))
+		for _, c := range n.Children {
+			children = append(children, c.String())
+		}
+		return "NOT (" + strings.Join(children, " AND ") + ")"
+	}
+
+	children := make([]string, 0, len(n.Children))
+	for _, c := range n.Children {
+		if (c.Type == ConditionAnd || c.Type == ConditionOr) && len(c.Children) > 1 {
+			children = append(children, "("+c.String()+")")
+		} else {
+			children = append(children, c.String())
+		}
+	}
+	return strings.Join(children, " "+string(n.Type)+" ")
+}
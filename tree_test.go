@@ -0,0 +1,137 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+func TestRequestTree(t *testing.T) {
+	f := func(field string, or bool) *Filter {
+		return &Filter{Field: field, Operator: Operators["$eq"], Args: []string{"1"}, Or: or}
+	}
+
+	cases := []struct {
+		request *Request
+		want    string
+		desc    string
+	}{
+		{desc: "empty", request: &Request{}, want: ""},
+		{
+			desc:    "single_filter",
+			request: &Request{Filter: typeutil.NewUndefined([]*Filter{f("a", false)})},
+			want:    "a||$eq||1",
+		},
+		{
+			desc:    "and",
+			request: &Request{Filter: typeutil.NewUndefined([]*Filter{f("a", false), f("b", false)})},
+			want:    "a||$eq||1 AND b||$eq||1",
+		},
+		{
+			desc:    "or",
+			request: &Request{Or: typeutil.NewUndefined([]*Filter{f("a", true), f("b", true)})},
+			want:    "a||$eq||1 OR b||$eq||1",
+		},
+		{
+			desc: "and_single_or",
+			request: &Request{
+				Filter: typeutil.NewUndefined([]*Filter{f("a", false), f("b", false)}),
+				Or:     typeutil.NewUndefined([]*Filter{f("c", true)}),
+			},
+			want: "(a||$eq||1 AND b||$eq||1) OR c||$eq||1",
+		},
+		{
+			desc: "mixed",
+			request: &Request{
+				Filter: typeutil.NewUndefined([]*Filter{f("a", false), f("b", false)}),
+				Or:     typeutil.NewUndefined([]*Filter{f("c", true), f("d", true)}),
+			},
+			want: "(a||$eq||1 AND b||$eq||1) OR (c||$eq||1 AND d||$eq||1)",
+		},
+		{
+			desc:    "or_in_filter",
+			request: &Request{Filter: typeutil.NewUndefined([]*Filter{f("a", false), f("b", false), f("c", true), f("d", false)})},
+			want:    "(a||$eq||1 AND b||$eq||1) OR (c||$eq||1 AND d||$eq||1)",
+		},
+		{
+			desc: "groups_and_search",
+			request: &Request{
+				Filter:       typeutil.NewUndefined([]*Filter{f("a", false)}),
+				FilterGroups: typeutil.NewUndefined([][]*Filter{{f("b", true), f("c", true)}, {f("d", true)}}),
+				Search:       typeutil.NewUndefined("John"),
+			},
+			want: "a||$eq||1 AND (b||$eq||1 OR c||$eq||1) AND d||$eq||1 AND search(John)",
+		},
+		{
+			desc:    "no_args",
+			request: &Request{Filter: typeutil.NewUndefined([]*Filter{{Field: "a", Operator: Operators["$isnull"]}})},
+			want:    "a||$isnull",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tree := c.request.Tree()
+			if c.want == "" {
+				assert.Nil(t, tree)
+				return
+			}
+			require.NotNil(t, tree)
+			assert.Equal(t, c.want, tree.String())
+		})
+	}
+}
+
+func TestRequestTreeStructure(t *testing.T) {
+	a := &Filter{Field: "a", Operator: Operators["$eq"], Args: []string{"1"}}
+	b := &Filter{Field: "b", Operator: Operators["$eq"], Args: []string{"1"}, Or: true}
+	c := &Filter{Field: "c", Operator: Operators["$eq"], Args: []string{"1"}, Or: true}
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{a}),
+		Or:     typeutil.NewUndefined([]*Filter{b, c}),
+		Search: typeutil.NewUndefined("John"),
+	}
+
+	expected := &ConditionNode{
+		Type: ConditionAnd,
+		Children: []*ConditionNode{
+			{
+				Type: ConditionOr,
+				Children: []*ConditionNode{
+					{Type: ConditionFilter, Filter: a},
+					{
+						Type: ConditionAnd,
+						Children: []*ConditionNode{
+							{Type: ConditionFilter, Filter: b},
+							{Type: ConditionFilter, Filter: c},
+						},
+					},
+				},
+			},
+			{Type: ConditionSearch, Search: "John"},
+		},
+	}
+	assert.Equal(t, expected, request.Tree())
+}
+
+func TestRequestTreeMatchesScope(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Operator: Operators["$eq"], Args: []string{"a"}},
+			{Field: "id", Operator: Operators["$eq"], Args: []string{"1"}},
+		}),
+		Or: typeutil.NewUndefined([]*Filter{
+			{Field: "name", Operator: Operators["$eq"], Args: []string{"b"}, Or: true},
+			{Field: "id", Operator: Operators["$eq"], Args: []string{"2"}, Or: true},
+		}),
+	}
+	assert.Equal(t, "(name||$eq||a AND id||$eq||1) OR (name||$eq||b AND id||$eq||2)", request.Tree().String())
+
+	db := openDryRunDB(t)
+	results := []*FilterTestModel{}
+	db = (&Settings[*FilterTestModel]{}).ScopeUnpaginated(db, request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t, "SELECT `filter_test_models`.`name`,`filter_test_models`.`id` FROM `filter_test_models` WHERE (`filter_test_models`.`name` = ? AND `filter_test_models`.`id` = ?) OR (`filter_test_models`.`name` = ? AND `filter_test_models`.`id` = ?)", db.Statement.SQL.String())
+}
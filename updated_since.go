@@ -0,0 +1,37 @@
+package filter
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DeletionFeed provides the records deleted since a given time, so clients synchronizing
+// a local copy of the records with the "updated_since" query can remove them.
+// Hard-deleted records cannot be listed by the filter query itself: the feed usually
+// reads them from a tombstone table or from the soft-deleted records.
+type DeletionFeed interface {
+	// Deleted returns the primary keys of the records deleted after the given time.
+	// The context is the context of the `*gorm.DB` given to the scope.
+	// The result is stored in the `DeletedSetting` statement setting.
+	Deleted(ctx context.Context, since time.Time) ([]any, error)
+}
+
+// updatedSinceField returns the field identified by `UpdatedAtField`, or nil if the
+// "updated_since" query is absent, or if `UpdatedAtField` is empty or doesn't exist.
+func (s *Settings[T]) updatedSinceField(request *Request, sch *schema.Schema) *schema.Field {
+	if s.UpdatedAtField == "" || !request.UpdatedSince.Present {
+		return nil
+	}
+	return sch.LookUpField(s.UpdatedAtField)
+}
+
+// updatedSinceScope returns the scope listing the records changed after the given time.
+func updatedSinceScope(table string, field *schema.Field, since time.Time) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(clause.Gt{Column: clause.Column{Table: table, Name: field.DBName}, Value: since})
+	}
+}
@@ -0,0 +1,127 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type UpdatedSinceTestModel struct {
+	UpdatedAt time.Time
+	Name      string
+	ID        uint
+}
+
+type testDeletionFeed struct {
+	err   error
+	since time.Time
+}
+
+func (f *testDeletionFeed) Deleted(ctx context.Context, since time.Time) ([]any, error) {
+	f.since = since
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []any{uint(3), uint(7)}, ctx.Err()
+}
+
+func TestUpdatedSince(t *testing.T) {
+	since := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	request := &Request{
+		Filter:       typeutil.NewUndefined([]*Filter{{Field: "name", Args: []string{"val"}, Operator: Operators["$cont"]}}),
+		UpdatedSince: typeutil.NewUndefined(since),
+	}
+
+	cases := []struct {
+		settings *Settings[*UpdatedSinceTestModel]
+		request  *Request
+		want     string
+		desc     string
+	}{
+		{
+			desc:     "updated_since",
+			settings: &Settings[*UpdatedSinceTestModel]{UpdatedAtField: "updated_at"},
+			request:  request,
+			want:     "SELECT `updated_since_test_models`.`updated_at`,`updated_since_test_models`.`name`,`updated_since_test_models`.`id` FROM `updated_since_test_models` WHERE `updated_since_test_models`.`name` LIKE \"%val%\" AND `updated_since_test_models`.`updated_at` > \"2024-03-01 12:00:00\" ORDER BY `updated_since_test_models`.`updated_at`",
+		},
+		{
+			desc:     "field_name",
+			settings: &Settings[*UpdatedSinceTestModel]{UpdatedAtField: "UpdatedAt"},
+			request:  &Request{UpdatedSince: typeutil.NewUndefined(since)},
+			want:     "SELECT `updated_since_test_models`.`updated_at`,`updated_since_test_models`.`name`,`updated_since_test_models`.`id` FROM `updated_since_test_models` WHERE `updated_since_test_models`.`updated_at` > \"2024-03-01 12:00:00\" ORDER BY `updated_since_test_models`.`updated_at`",
+		},
+		{
+			desc:     "requested_sort",
+			settings: &Settings[*UpdatedSinceTestModel]{UpdatedAtField: "updated_at"},
+			request: &Request{
+				UpdatedSince: typeutil.NewUndefined(since),
+				Sort:         typeutil.NewUndefined([]*Sort{{Field: "name", Order: SortDescending}}),
+			},
+			want: "SELECT `updated_since_test_models`.`updated_at`,`updated_since_test_models`.`name`,`updated_since_test_models`.`id` FROM `updated_since_test_models` WHERE `updated_since_test_models`.`updated_at` > \"2024-03-01 12:00:00\" ORDER BY `updated_since_test_models`.`name` DESC",
+		},
+		{
+			desc:     "disabled",
+			settings: &Settings[*UpdatedSinceTestModel]{},
+			request:  request,
+			want:     "SELECT `updated_since_test_models`.`updated_at`,`updated_since_test_models`.`name`,`updated_since_test_models`.`id` FROM `updated_since_test_models` WHERE `updated_since_test_models`.`name` LIKE \"%val%\"",
+		},
+		{
+			desc:     "unknown_field",
+			settings: &Settings[*UpdatedSinceTestModel]{UpdatedAtField: "modified_at"},
+			request:  request,
+			want:     "SELECT `updated_since_test_models`.`updated_at`,`updated_since_test_models`.`name`,`updated_since_test_models`.`id` FROM `updated_since_test_models` WHERE `updated_since_test_models`.`name` LIKE \"%val%\"",
+		},
+		{
+			desc:     "not_present",
+			settings: &Settings[*UpdatedSinceTestModel]{UpdatedAtField: "updated_at"},
+			request:  &Request{},
+			want:     "SELECT `updated_since_test_models`.`updated_at`,`updated_since_test_models`.`name`,`updated_since_test_models`.`id` FROM `updated_since_test_models`",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			db := openDryRunDB(t)
+			results := []*UpdatedSinceTestModel{}
+			tx, err := c.settings.Build(db, c.request, &results)
+			require.NoError(t, err)
+			tx = tx.Find(&results)
+			require.NoError(t, tx.Error)
+			assert.Equal(t, c.want, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+		})
+	}
+
+	t.Run("deletion_feed", func(t *testing.T) {
+		feed := &testDeletionFeed{}
+		settings := &Settings[*UpdatedSinceTestModel]{UpdatedAtField: "updated_at", DeletionFeed: feed}
+		results := []*UpdatedSinceTestModel{}
+		paginator, err := settings.Scope(openDryRunDB(t), request, &results)
+		require.NoError(t, err)
+		assert.Equal(t, since, feed.since)
+		deleted, ok := paginator.DB.Get(DeletedSetting)
+		require.True(t, ok)
+		assert.Equal(t, []any{uint(3), uint(7)}, deleted)
+	})
+
+	t.Run("deletion_feed_not_present", func(t *testing.T) {
+		settings := &Settings[*UpdatedSinceTestModel]{UpdatedAtField: "updated_at", DeletionFeed: &testDeletionFeed{}}
+		results := []*UpdatedSinceTestModel{}
+		paginator, err := settings.Scope(openDryRunDB(t), &Request{}, &results)
+		require.NoError(t, err)
+		_, ok := paginator.DB.Get(DeletedSetting)
+		assert.False(t, ok)
+	})
+
+	t.Run("deletion_feed_error", func(t *testing.T) {
+		feedErr := fmt.Errorf("test error")
+		settings := &Settings[*UpdatedSinceTestModel]{UpdatedAtField: "updated_at", DeletionFeed: &testDeletionFeed{err: feedErr}}
+		results := []*UpdatedSinceTestModel{}
+		_, err := settings.Scope(openDryRunDB(t), request, &results)
+		require.ErrorIs(t, err, feedErr)
+	})
+}
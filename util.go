@@ -1,11 +1,15 @@
 package filter
 
 import (
+	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
 )
 
@@ -19,6 +23,16 @@ func (d DataType) IsArray() bool {
 	return strings.HasSuffix(string(d), "[]")
 }
 
+// IsInteger returns true if this data type is a signed or unsigned integer (not an array).
+func (d DataType) IsInteger() bool {
+	switch d {
+	case DataTypeInt8, DataTypeInt16, DataTypeInt32, DataTypeInt64,
+		DataTypeUint8, DataTypeUint16, DataTypeUint32, DataTypeUint64:
+		return true
+	}
+	return false
+}
+
 // Supported DataTypes
 const (
 	DataTypeText      DataType = "text"
@@ -94,6 +108,19 @@ func addForeignKeys(sch *schema.Schema, fields []string) []string {
 	return fields
 }
 
+// missingKeys returns the primary and foreign keys of the model that would be added to
+// the given fields by `addPrimaryKeys()` and `addForeignKeys()`. Blacklisted keys and keys
+// that are not columns of the model are ignored.
+func missingKeys(sch *schema.Schema, fields []string, blacklist []string) []string {
+	foreignKeys := addForeignKeys(sch, []string{})
+	slices.Sort(foreignKeys)
+	keys := lo.Uniq(append(addPrimaryKeys(sch, []string{}), foreignKeys...))
+	return lo.Filter(keys, func(k string, _ int) bool {
+		_, ok := sch.FieldsByDBName[k]
+		return ok && !lo.Contains(fields, k) && !lo.Contains(blacklist, k)
+	})
+}
+
 func columnsContain(fields []*schema.Field, field *schema.Field) bool {
 	for _, f := range fields {
 		if f.DBName == field.DBName {
@@ -103,8 +130,46 @@ func columnsContain(fields []*schema.Field, field *schema.Field) bool {
 	return false
 }
 
+// isLazy returns true if the given field is tagged with `filterSelect:"lazy"` and is
+// not a primary key, meaning it can be pruned from the default selected columns.
+func isLazy(field *schema.Field) bool {
+	return !field.PrimaryKey && field.Tag.Get("filterSelect") == "lazy"
+}
+
+// pruneLazyFields returns the given fields without the lazy ones (see `isLazy`).
+func pruneLazyFields(fields []*schema.Field) []*schema.Field {
+	return lo.Reject(fields, func(f *schema.Field, _ int) bool { return isLazy(f) })
+}
+
+// QuoteColumn returns the given column qualified by the given table or relation alias and
+// quoted using the dialect of the given DB (e.g. "`Author`.`name`" or `"Author"."name"`).
+// If table is empty, the column is not qualified. Custom operators can use it with
+// `Filter.Table()` to reference other columns without ambiguity when joins are present.
+func QuoteColumn(tx *gorm.DB, table, column string) string {
+	return tx.Statement.Quote(clause.Column{Table: table, Name: column})
+}
+
+// columnExpression returns the SQL expression targeting the given field in the given table.
+// Both the table and the column names are quoted using the statement's dialect so
+// reserved keywords (e.g. "order", "group", "user") can safely be used as identifiers.
+// If the field is computed, the expression is wrapped in parenthesis and the current table
+// placeholder is replaced by the quoted table name.
+func columnExpression(stmt *gorm.Statement, table string, field *schema.Field) string {
+	if computed := field.StructField.Tag.Get("computed"); computed != "" {
+		return fmt.Sprintf("(%s)", strings.ReplaceAll(computed, clause.CurrentTable, stmt.Quote(table)))
+	}
+	return stmt.Quote(clause.Column{Table: table, Name: field.DBName})
+}
+
+// getDataType returns the data type of the given field, used to validate and convert
+// the filter arguments. The type defined by the "filterType" tag has precedence. For computed
+// fields, the "computedType" tag defines the type of the result of the SQL expression.
+// Otherwise, the type is deduced from the GORM data type of the field.
 func getDataType(field *schema.Field) DataType {
 	fromTag := DataType(strings.ToLower(field.Tag.Get("filterType")))
+	if fromTag == "" && field.Tag.Get("computed") != "" {
+		fromTag = DataType(strings.ToLower(field.Tag.Get("computedType")))
+	}
 	switch fromTag {
 	case DataTypeText, DataTypeTextArray,
 		DataTypeEnum, DataTypeEnumArray,
@@ -228,14 +293,19 @@ func validateFloat(arg string, bitSize int) (float64, bool) {
 }
 
 func validateTime(timeStr string) bool {
+	_, ok := parseTime(timeStr)
+	return ok
+}
+
+func parseTime(timeStr string) (time.Time, bool) {
 	for _, format := range []string{time.RFC3339, time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
-		_, err := time.Parse(format, timeStr)
+		t, err := time.Parse(format, timeStr)
 		if err == nil {
-			return true
+			return t, true
 		}
 	}
 
-	return false
+	return time.Time{}, false
 }
 
 // ConvertArgsToSafeType converts a slice of string arguments to safe type
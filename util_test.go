@@ -326,6 +326,22 @@ func TestGetDataType(t *testing.T) {
 			Field time.Time
 		}{}, want: DataTypeTime},
 
+		{desc: "computed type", model: struct {
+			Field string `gorm:"->;-:migration" computed:"LENGTH(~~~ct~~~.name)" computedType:"int64"`
+		}{}, want: DataTypeInt64},
+		{desc: "computed type invalid", model: struct {
+			Field string `gorm:"->;-:migration" computed:"LENGTH(~~~ct~~~.name)" computedType:"invalid"`
+		}{}, want: DataTypeUnsupported},
+		{desc: "computed type filter type precedence", model: struct {
+			Field string `gorm:"->;-:migration" computed:"LENGTH(~~~ct~~~.name)" computedType:"int64" filterType:"text"`
+		}{}, want: DataTypeText},
+		{desc: "computed type not computed", model: struct {
+			Field string `computedType:"int64"`
+		}{}, want: DataTypeText},
+		{desc: "computed without computed type", model: struct {
+			Field string `gorm:"->;-:migration" computed:"LENGTH(~~~ct~~~.name)"`
+		}{}, want: DataTypeText},
+
 		{desc: "filter type unsupported", model: struct {
 			Field string `filterType:"-"`
 		}{}, want: DataTypeUnsupported},
@@ -391,3 +407,9 @@ func TestGetDataType(t *testing.T) {
 		})
 	}
 }
+
+func TestQuoteColumn(t *testing.T) {
+	db := openDryRunDB(t)
+	assert.Equal(t, "`Author`.`name`", QuoteColumn(db, "Author", "name"))
+	assert.Equal(t, "`name`", QuoteColumn(db, "", "name"))
+}
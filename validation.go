@@ -2,7 +2,11 @@ package filter
 
 import (
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/samber/lo"
 	"goyave.dev/goyave/v5"
@@ -13,10 +17,26 @@ import (
 // Separator the separator used when parsing the query
 var Separator = "||"
 
+// MaxQueryParams the maximum number of filters (including "or" and filter groups),
+// sorts and joins combined a single query can contain. The limit is checked by
+// `Validation()` before the parameters are parsed. 0 means no limit.
+var MaxQueryParams = 0
+
+// MaxArgLength the maximum length in characters of the filter arguments (including "or" and
+// filter groups) and of the search query. The limit is checked by `Validation()`, so the requests
+// containing a longer value are rejected before being parsed. 0 means no limit.
+// See `Settings.MaxArgLength` and `Settings.FieldMaxArgLength` for limits specific to a model.
+var MaxArgLength = 0
+
 func init() {
 	lang.SetDefaultValidationRule("goyave-filter-filter.element", "The filter format is invalid.")
+	lang.SetDefaultValidationRule("goyave-filter-filter-groups", "The filter groups format is invalid.")
+	lang.SetDefaultValidationRule("goyave-filter-params-count", "The query cannot contain more than :max filters, sorts and joins.")
+	lang.SetDefaultValidationRule("goyave-filter-arg-length", "The filter arguments cannot be longer than :max characters.")
 	lang.SetDefaultValidationRule("goyave-filter-join.element", "The join format is invalid.")
 	lang.SetDefaultValidationRule("goyave-filter-sort.element", "The sort format is invalid.")
+	lang.SetDefaultValidationRule("goyave-filter-per-page", "The :field must be an integer between 1 and :max.")
+	lang.SetDefaultValidationRule("goyave-filter-per-page-all", "The :field must be an integer between 1 and :max, or \"all\".")
 }
 
 // FilterValidator checks the `filter` format and converts it to `*Filter` struct.
@@ -49,6 +69,170 @@ func (v *FilterValidator) Name() string { return "goyave-filter-filter" }
 // IsType returns true
 func (v *FilterValidator) IsType() bool { return true }
 
+// FilterGroupsValidator checks the format of the indexed filter groups found at the root
+// of the query (e.g. `filter[0]`) and converts them to `[]*Filter`.
+// The filters of a group are ORed together.
+type FilterGroupsValidator struct {
+	v.BaseValidator
+
+	// Param the name of the filter query parameter. Groups are identified
+	// by this name followed by an index in brackets.
+	Param string
+}
+
+// Validate checks the field under validation satisfies this validator's criteria.
+func (v *FilterGroupsValidator) Validate(ctx *v.Context) bool {
+	query, ok := ctx.Value.(map[string]any)
+	if !ok {
+		return true
+	}
+	for key, value := range query {
+		if _, ok := parseFilterGroupIndex(v.Param, key); !ok {
+			continue
+		}
+
+		var raw []string
+		switch val := value.(type) {
+		case []*Filter:
+			continue
+		case string:
+			raw = []string{val}
+		case []string:
+			raw = val
+		default:
+			return false
+		}
+
+		filters := make([]*Filter, 0, len(raw))
+		for _, str := range raw {
+			f, err := ParseFilter(str)
+			if err != nil {
+				return false
+			}
+			f.Or = true
+			filters = append(filters, f)
+		}
+		query[key] = filters
+	}
+	return true
+}
+
+// Name returns the string name of the validator.
+func (v *FilterGroupsValidator) Name() string { return "goyave-filter-filter-groups" }
+
+// parseFilterGroupIndex returns the index of the filter group identified by the
+// given query key (e.g. `filter[0]`). Returns false if the key doesn't identify a filter group.
+func parseFilterGroupIndex(param, key string) (int, bool) {
+	index, ok := strings.CutPrefix(key, param+"[")
+	if !ok {
+		return 0, false
+	}
+	index, ok = strings.CutSuffix(index, "]")
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 {
+		return 0, false
+	}
+	return i, true
+}
+
+// ParamsCountValidator checks the total number of values of the given query parameters
+// doesn't exceed `Max`. Indexed filter groups (e.g. `filter[0]`) of the parameters are counted too.
+// This validator is meant to be used on the root of the query so the values are counted
+// before being parsed.
+type ParamsCountValidator struct {
+	v.BaseValidator
+	Params []string
+	Max    int
+}
+
+// Validate checks the field under validation satisfies this validator's criteria.
+func (v *ParamsCountValidator) Validate(ctx *v.Context) bool {
+	query, ok := ctx.Value.(map[string]any)
+	if !ok {
+		return true
+	}
+	count := 0
+	for key, value := range query {
+		if !lo.ContainsBy(v.Params, func(param string) bool {
+			if key == param {
+				return true
+			}
+			_, ok := parseFilterGroupIndex(param, key)
+			return ok
+		}) {
+			continue
+		}
+		if val := reflect.ValueOf(value); val.Kind() == reflect.Slice {
+			count += val.Len()
+		} else {
+			count++
+		}
+		if count > v.Max {
+			return false
+		}
+	}
+	return true
+}
+
+// Name returns the string name of the validator.
+func (v *ParamsCountValidator) Name() string { return "goyave-filter-params-count" }
+
+// MessagePlaceholders returns the ":max" placeholder.
+func (v *ParamsCountValidator) MessagePlaceholders(_ *v.Context) []string {
+	return []string{":max", strconv.Itoa(v.Max)}
+}
+
+// ArgLengthValidator checks the arguments of the filters are not longer than `Max` characters.
+// The field under validation is either a parsed `*Filter` or, if `Param` is not empty, the
+// root of the query, in which case the parsed filter groups of `Param` (e.g. `filter[0]`)
+// are checked. It must therefore be used after `FilterValidator` or `FilterGroupsValidator`.
+type ArgLengthValidator struct {
+	v.BaseValidator
+	Param string
+	Max   int
+}
+
+// Validate checks the field under validation satisfies this validator's criteria.
+func (v *ArgLengthValidator) Validate(ctx *v.Context) bool {
+	switch val := ctx.Value.(type) {
+	case *Filter:
+		return v.validArgs(val)
+	case map[string]any:
+		if v.Param == "" {
+			return true
+		}
+		for key, value := range val {
+			if _, ok := parseFilterGroupIndex(v.Param, key); !ok {
+				continue
+			}
+			filters, _ := value.([]*Filter)
+			for _, f := range filters {
+				if !v.validArgs(f) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (v *ArgLengthValidator) validArgs(f *Filter) bool {
+	return !lo.SomeBy(f.Args, func(arg string) bool {
+		return utf8.RuneCountInString(arg) > v.Max
+	})
+}
+
+// Name returns the string name of the validator.
+func (v *ArgLengthValidator) Name() string { return "goyave-filter-arg-length" }
+
+// MessagePlaceholders returns the ":max" placeholder.
+func (v *ArgLengthValidator) MessagePlaceholders(_ *v.Context) []string {
+	return []string{":max", strconv.Itoa(v.Max)}
+}
+
 // SortValidator checks the `sort` format and converts it to `*Sort` struct.
 type SortValidator struct {
 	v.BaseValidator
@@ -132,21 +316,123 @@ func (v *JoinValidator) Name() string { return "goyave-filter-join" }
 // IsType returns true
 func (v *JoinValidator) IsType() bool { return true }
 
+// PerPageValidator checks the `per_page` is an integer between 1 and `Max` or, if `AllowAll`
+// is true, the string "all", and converts it to an `int`. "all" is converted to `PerPageAll`.
+type PerPageValidator struct {
+	v.BaseValidator
+	Max int
+	// AllowAll if true, "all" is accepted. It should match `Settings.AllowAll`,
+	// otherwise the scopes return an error wrapping `ErrAllNotAllowed`.
+	AllowAll bool
+}
+
+// Validate checks the field under validation satisfies this validator's criteria.
+func (v *PerPageValidator) Validate(ctx *v.Context) bool {
+	var perPage int
+	switch value := ctx.Value.(type) {
+	case int:
+		perPage = value
+	case float64:
+		if value != float64(int(value)) {
+			return false
+		}
+		perPage = int(value)
+	case string:
+		if value == "all" {
+			if !v.AllowAll {
+				return false
+			}
+			ctx.Value = PerPageAll
+			return true
+		}
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return false
+		}
+		perPage = i
+	default:
+		return false
+	}
+	if perPage < 1 || perPage > v.Max {
+		return false
+	}
+	ctx.Value = perPage
+	return true
+}
+
+// Name returns the string name of the validator.
+func (v *PerPageValidator) Name() string {
+	if v.AllowAll {
+		return "goyave-filter-per-page-all"
+	}
+	return "goyave-filter-per-page"
+}
+
+// IsType returns true
+func (v *PerPageValidator) IsType() bool { return true }
+
+// MessagePlaceholders returns the ":max" placeholder.
+func (v *PerPageValidator) MessagePlaceholders(_ *v.Context) []string {
+	return []string{":max", strconv.Itoa(v.Max)}
+}
+
 // Validation returns a new RuleSet for query validation.
-func Validation(_ *goyave.Request) v.RuleSet {
+// The names of the query parameters can be changed using `DefaultParamNames`.
+// `per_page=all` is rejected: use `Settings.Validation()` if `AllowAll` is enabled.
+func Validation(r *goyave.Request) v.RuleSet {
+	return ParamNames{}.Validation(r)
+}
+
+// Validation returns a new RuleSet for query validation using these parameter names.
+// `per_page=all` is rejected: use `Settings.Validation()` if `AllowAll` is enabled.
+func (p ParamNames) Validation(_ *goyave.Request) v.RuleSet {
+	return p.rules(false)
+}
+
+// Validation returns a new RuleSet for query validation using the parameter names of the
+// settings. `per_page=all` is only accepted if `AllowAll` is enabled.
+func (s *Settings[T]) Validation(_ *goyave.Request) v.RuleSet {
+	return s.ParamNames.rules(s.AllowAll)
+}
+
+// rules returns the query validation rules using these parameter names.
+// If allowAll is true, `per_page=all` is accepted.
+func (p ParamNames) rules(allowAll bool) v.RuleSet {
+	p = p.withDefaults()
+	rootRules := v.List{&FilterGroupsValidator{Param: p.Filter}}
+	if MaxQueryParams > 0 {
+		countValidator := &ParamsCountValidator{
+			Params: []string{p.Filter, p.Or, p.Sort, p.Join},
+			Max:    MaxQueryParams,
+		}
+		rootRules = append(v.List{countValidator}, rootRules...)
+	}
+	filterRules := v.List{&FilterValidator{}}
+	orRules := v.List{&FilterValidator{Or: true}}
+	searchMax := 255
+	if MaxArgLength > 0 {
+		rootRules = append(rootRules, &ArgLengthValidator{Param: p.Filter, Max: MaxArgLength})
+		filterRules = append(filterRules, &ArgLengthValidator{Max: MaxArgLength})
+		orRules = append(orRules, &ArgLengthValidator{Max: MaxArgLength})
+		searchMax = min(searchMax, MaxArgLength)
+	}
 	return v.RuleSet{
-		{Path: "filter", Rules: v.List{v.Array()}},
-		{Path: "filter[]", Rules: v.List{&FilterValidator{}}},
-		{Path: "or", Rules: v.List{v.Array()}},
-		{Path: "or[]", Rules: v.List{&FilterValidator{Or: true}}},
-		{Path: "sort", Rules: v.List{v.Array()}},
-		{Path: "sort[]", Rules: v.List{&SortValidator{}}},
-		{Path: "join", Rules: v.List{v.Array()}},
-		{Path: "join[]", Rules: v.List{&JoinValidator{}}},
-		{Path: "page", Rules: v.List{v.Int(), v.Min(1)}},
-		{Path: "per_page", Rules: v.List{v.Int(), v.Between(1, 500)}},
-		{Path: "search", Rules: v.List{v.String(), v.Max(255)}},
-		{Path: "fields", Rules: v.List{v.String(), &FieldsValidator{}}},
+		{Path: v.CurrentElement, Rules: rootRules},
+		{Path: p.Filter, Rules: v.List{v.Array()}},
+		{Path: p.Filter + "[]", Rules: filterRules},
+		{Path: p.Or, Rules: v.List{v.Array()}},
+		{Path: p.Or + "[]", Rules: orRules},
+		{Path: p.Sort, Rules: v.List{v.Array()}},
+		{Path: p.Sort + "[]", Rules: v.List{&SortValidator{}}},
+		{Path: p.Join, Rules: v.List{v.Array()}},
+		{Path: p.Join + "[]", Rules: v.List{&JoinValidator{}}},
+		{Path: p.Page, Rules: v.List{v.Int(), v.Min(1)}},
+		{Path: p.PerPage, Rules: v.List{&PerPageValidator{Max: 500, AllowAll: allowAll}}},
+		{Path: p.Search, Rules: v.List{v.String(), v.Max(searchMax)}},
+		{Path: p.Fields, Rules: v.List{v.String(), &FieldsValidator{}}},
+		{Path: p.DistinctOn, Rules: v.List{v.String(), &FieldsValidator{}}},
+		{Path: p.UpdatedSince, Rules: v.List{v.Date(time.RFC3339)}},
+		{Path: p.Snapshot, Rules: v.List{v.String()}},
 	}
 }
 
@@ -173,11 +459,15 @@ func ParseFilter(filter string) (*Filter, error) {
 		index = len(f)
 	}
 	op = strings.TrimSpace(f[:index])
-	operator, ok := Operators[op]
+	operator, ok := lookupOperator(op)
 	if !ok {
 		return nil, fmt.Errorf("unknown operator: %q", f[:index])
 	}
 	res.Operator = operator
+	if _, ok := Operators[op]; !ok {
+		// Keep the alias so its deprecation can be reported
+		res.queryOperator = op
+	}
 
 	if index < len(f) {
 		f = f[index+2:]
@@ -231,7 +521,8 @@ func ParseSort(sort string) (*Sort, error) {
 }
 
 // ParseJoin parse a string in format "relation||field1,field2,..." and return
-// a Join struct.
+// a Join struct. An option can be added after the fields: "relation||*||withTrashed"
+// includes the soft-deleted records of the relation. "*" selects all fields.
 func ParseJoin(join string) (*Join, error) {
 	separatorIndex := strings.Index(join, Separator)
 	if separatorIndex == -1 {
@@ -243,9 +534,23 @@ func ParseJoin(join string) (*Join, error) {
 		return nil, fmt.Errorf("invalid join syntax")
 	}
 
-	var fields []string
+	fieldsStr := ""
 	if separatorIndex+2 < len(join) {
-		fields = strings.Split(join[separatorIndex+2:], ",")
+		fieldsStr = join[separatorIndex+2:]
+	}
+	withTrashed := false
+	if optionIndex := strings.Index(fieldsStr, Separator); optionIndex != -1 {
+		option := strings.TrimSpace(fieldsStr[optionIndex+2:])
+		if option != JoinWithTrashed {
+			return nil, fmt.Errorf("invalid join option %q", option)
+		}
+		withTrashed = true
+		fieldsStr = fieldsStr[:optionIndex]
+	}
+
+	var fields []string
+	if fieldsStr != "" && strings.TrimSpace(fieldsStr) != "*" {
+		fields = strings.Split(fieldsStr, ",")
 		for i, f := range fields {
 			f = strings.TrimSpace(f)
 			if f == "" {
@@ -253,13 +558,12 @@ func ParseJoin(join string) (*Join, error) {
 			}
 			fields[i] = f
 		}
-	} else {
-		fields = nil
 	}
 
 	j := &Join{
-		Relation: relation,
-		Fields:   fields,
+		Relation:    relation,
+		Fields:      fields,
+		WithTrashed: withTrashed,
 	}
 	return j, nil
 }
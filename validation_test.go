@@ -12,12 +12,30 @@ import (
 func TestApplyValidation(t *testing.T) {
 	set := Validation(nil)
 
-	expectedFields := []string{"filter", "filter[]", "or", "or[]", "sort", "sort[]", "join", "join[]", "fields", "page", "per_page", "search"}
+	expectedFields := []string{"", "filter", "filter[]", "or", "or[]", "sort", "sort[]", "join", "join[]", "fields", "distinct_on", "updated_since", "snapshot", "page", "per_page", "search"}
 	assert.True(t, lo.EveryBy(set, func(f *validation.FieldRules) bool {
 		return lo.Contains(expectedFields, f.Path)
 	}))
 }
 
+func TestParamNamesValidation(t *testing.T) {
+	set := ParamNames{Search: "q", Sort: "order_by", PerPage: "limit"}.Validation(nil)
+
+	expectedFields := []string{"", "filter", "filter[]", "or", "or[]", "order_by", "order_by[]", "join", "join[]", "fields", "distinct_on", "updated_since", "snapshot", "page", "limit", "q"}
+	assert.ElementsMatch(t, expectedFields, lo.Map(set, func(f *validation.FieldRules, _ int) string {
+		return f.Path
+	}))
+
+	perPage := func(set validation.RuleSet) *PerPageValidator {
+		rules, _ := lo.Find(set, func(f *validation.FieldRules) bool { return f.Path == "page_size" })
+		return any(rules.Rules).(validation.List)[0].(*PerPageValidator)
+	}
+	names := ParamNames{PerPage: "page_size"}
+	assert.False(t, perPage(names.Validation(nil)).AllowAll)
+	assert.False(t, perPage((&Settings[*FilterTestModel]{ParamNames: names}).Validation(nil)).AllowAll)
+	assert.True(t, perPage((&Settings[*FilterTestModel]{ParamNames: names, AllowAll: true}).Validation(nil)).AllowAll)
+}
+
 func TestParseFilter(t *testing.T) {
 	f, err := ParseFilter("field||$eq||value1,value2")
 	assert.Nil(t, err)
@@ -166,6 +184,28 @@ func TestParseJoin(t *testing.T) {
 	if assert.NotNil(t, err) {
 		assert.Equal(t, "invalid join syntax", err.Error())
 	}
+
+	j, err = ParseJoin("relation||*")
+	assert.Nil(t, err)
+	assert.Equal(t, &Join{Relation: "relation"}, j)
+
+	j, err = ParseJoin("relation||*||withTrashed")
+	assert.Nil(t, err)
+	assert.Equal(t, &Join{Relation: "relation", WithTrashed: true}, j)
+
+	j, err = ParseJoin("relation|| field1,field2 || withTrashed")
+	assert.Nil(t, err)
+	assert.Equal(t, &Join{Relation: "relation", Fields: []string{"field1", "field2"}, WithTrashed: true}, j)
+
+	j, err = ParseJoin("relation||||withTrashed")
+	assert.Nil(t, err)
+	assert.Equal(t, &Join{Relation: "relation", WithTrashed: true}, j)
+
+	j, err = ParseJoin("relation||*||unknown")
+	assert.Nil(t, j)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `invalid join option "unknown"`, err.Error())
+	}
 }
 
 func TestValidateFilter(t *testing.T) {
@@ -428,3 +468,217 @@ func TestValidateFields(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateFilterGroups(t *testing.T) {
+	t.Run("Constructor", func(t *testing.T) {
+		v := &FilterGroupsValidator{Param: "filter"}
+		assert.NotNil(t, v)
+		assert.Equal(t, "goyave-filter-filter-groups", v.Name())
+		assert.False(t, v.IsType())
+		assert.False(t, v.IsTypeDependent())
+		assert.Empty(t, v.MessagePlaceholders(&validation.Context{}))
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		existing := []*Filter{{Field: "name", Operator: Operators["$eq"], Args: []string{"c"}, Or: true}}
+		query := map[string]any{
+			"filter":    "ignored",
+			"filter[0]": []string{"name||$cont||a", "name||$cont||b"},
+			"filter[1]": "age||$gt||18",
+			"filter[2]": existing,
+			"filter[a]": "ignored",
+		}
+		v := &FilterGroupsValidator{Param: "filter"}
+		ctx := &validation.Context{Value: query}
+		assert.True(t, v.Validate(ctx))
+		assert.Equal(t, map[string]any{
+			"filter": "ignored",
+			"filter[0]": []*Filter{
+				{Field: "name", Operator: Operators["$cont"], Args: []string{"a"}, Or: true},
+				{Field: "name", Operator: Operators["$cont"], Args: []string{"b"}, Or: true},
+			},
+			"filter[1]": []*Filter{
+				{Field: "age", Operator: Operators["$gt"], Args: []string{"18"}, Or: true},
+			},
+			"filter[2]": existing,
+			"filter[a]": "ignored",
+		}, ctx.Value)
+	})
+
+	t.Run("Validate_invalid", func(t *testing.T) {
+		v := &FilterGroupsValidator{Param: "filter"}
+		assert.False(t, v.Validate(&validation.Context{Value: map[string]any{"filter[0]": "name||$notanoperator||a"}}))
+		assert.False(t, v.Validate(&validation.Context{Value: map[string]any{"filter[0]": 123}}))
+		assert.True(t, v.Validate(&validation.Context{Value: "not a map"}))
+	})
+}
+
+func TestParseFilterGroupIndex(t *testing.T) {
+	cases := []struct {
+		key       string
+		wantIndex int
+		want      bool
+	}{
+		{key: "filter[0]", wantIndex: 0, want: true},
+		{key: "filter[12]", wantIndex: 12, want: true},
+		{key: "filter", want: false},
+		{key: "filter[]", want: false},
+		{key: "filter[a]", want: false},
+		{key: "filter[-1]", want: false},
+		{key: "filter[1", want: false},
+		{key: "or[1]", want: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.key, func(t *testing.T) {
+			index, ok := parseFilterGroupIndex("filter", c.key)
+			assert.Equal(t, c.want, ok)
+			assert.Equal(t, c.wantIndex, index)
+		})
+	}
+}
+
+func TestValidateParamsCount(t *testing.T) {
+	t.Run("Constructor", func(t *testing.T) {
+		v := &ParamsCountValidator{Max: 3}
+		assert.NotNil(t, v)
+		assert.Equal(t, "goyave-filter-params-count", v.Name())
+		assert.False(t, v.IsType())
+		assert.False(t, v.IsTypeDependent())
+		assert.Equal(t, []string{":max", "3"}, v.MessagePlaceholders(&validation.Context{}))
+	})
+
+	cases := []struct {
+		value any
+		desc  string
+		max   int
+		want  bool
+	}{
+		{desc: "not_a_map", value: "test", max: 1, want: true},
+		{desc: "empty", value: map[string]any{}, max: 1, want: true},
+		{desc: "single_values", value: map[string]any{"filter": "a", "sort": "b", "search": "c"}, max: 2, want: true},
+		{desc: "slices", value: map[string]any{"filter": []string{"a", "b"}, "or": []*Filter{{}}, "page": 1}, max: 3, want: true},
+		{desc: "groups", value: map[string]any{"filter[0]": []string{"a", "b"}, "filter[1]": "c"}, max: 3, want: true},
+		{desc: "exceeded", value: map[string]any{"filter": []string{"a", "b"}, "join": "c"}, max: 2, want: false},
+		{desc: "exceeded_groups", value: map[string]any{"filter[0]": []string{"a", "b"}, "filter[1]": "c"}, max: 2, want: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			v := &ParamsCountValidator{Params: []string{"filter", "or", "sort", "join"}, Max: c.max}
+			assert.Equal(t, c.want, v.Validate(&validation.Context{Value: c.value}))
+		})
+	}
+
+	t.Run("Validation", func(t *testing.T) {
+		prev := MaxQueryParams
+		MaxQueryParams = 5
+		t.Cleanup(func() {
+			MaxQueryParams = prev
+		})
+
+		set := ParamNames{Sort: "order_by"}.Validation(nil)
+		root := set[0]
+		assert.Equal(t, validation.CurrentElement, root.Path)
+		assert.Equal(t, validation.List{
+			&ParamsCountValidator{Params: []string{"filter", "or", "order_by", "join"}, Max: 5},
+			&FilterGroupsValidator{Param: "filter"},
+		}, root.Rules)
+	})
+}
+
+func TestValidateArgLength(t *testing.T) {
+	t.Run("Constructor", func(t *testing.T) {
+		v := &ArgLengthValidator{Max: 3}
+		assert.NotNil(t, v)
+		assert.Equal(t, "goyave-filter-arg-length", v.Name())
+		assert.False(t, v.IsType())
+		assert.False(t, v.IsTypeDependent())
+		assert.Equal(t, []string{":max", "3"}, v.MessagePlaceholders(&validation.Context{}))
+	})
+
+	cases := []struct {
+		value any
+		desc  string
+		param string
+		want  bool
+	}{
+		{desc: "not_a_filter", value: "test", want: true},
+		{desc: "filter", value: &Filter{Args: []string{"abc", "é€ß"}}, want: true},
+		{desc: "filter_exceeded", value: &Filter{Args: []string{"abc", "abcd"}}, want: false},
+		{desc: "groups", value: map[string]any{"filter[0]": []*Filter{{Args: []string{"abc"}}}, "or": "abcd"}, param: "filter", want: true},
+		{desc: "groups_exceeded", value: map[string]any{"filter[0]": []*Filter{{Args: []string{"abc"}}, {Args: []string{"abcd"}}}}, param: "filter", want: false},
+		{desc: "groups_no_param", value: map[string]any{"filter[0]": []*Filter{{Args: []string{"abcd"}}}}, want: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			v := &ArgLengthValidator{Param: c.param, Max: 3}
+			assert.Equal(t, c.want, v.Validate(&validation.Context{Value: c.value}))
+		})
+	}
+
+	t.Run("Validation", func(t *testing.T) {
+		prev := MaxArgLength
+		MaxArgLength = 100
+		t.Cleanup(func() {
+			MaxArgLength = prev
+		})
+
+		set := ParamNames{Filter: "where", Search: "q"}.Validation(nil)
+		rules := lo.SliceToMap(set, func(f *validation.FieldRules) (string, validation.List) {
+			return f.Path, f.Rules
+		})
+		assert.Equal(t, validation.List{
+			&FilterGroupsValidator{Param: "where"},
+			&ArgLengthValidator{Param: "where", Max: 100},
+		}, rules[validation.CurrentElement])
+		assert.Equal(t, validation.List{&FilterValidator{}, &ArgLengthValidator{Max: 100}}, rules["where[]"])
+		assert.Equal(t, validation.List{&FilterValidator{Or: true}, &ArgLengthValidator{Max: 100}}, rules["or[]"])
+		assert.Equal(t, validation.List{validation.String(), validation.Max(100)}, rules["q"])
+	})
+}
+
+func TestValidatePerPage(t *testing.T) {
+	t.Run("Constructor", func(t *testing.T) {
+		v := &PerPageValidator{Max: 500}
+		assert.NotNil(t, v)
+		assert.Equal(t, "goyave-filter-per-page", v.Name())
+		assert.Equal(t, "goyave-filter-per-page-all", (&PerPageValidator{AllowAll: true}).Name())
+		assert.True(t, v.IsType())
+		assert.False(t, v.IsTypeDependent())
+		assert.Equal(t, []string{":max", "500"}, v.MessagePlaceholders(&validation.Context{}))
+	})
+
+	cases := []struct {
+		value     any
+		want      any
+		desc      string
+		allowAll  bool
+		wantValid bool
+	}{
+		{desc: "all", value: "all", allowAll: true, want: PerPageAll, wantValid: true},
+		{desc: "all_not_allowed", value: "all", wantValid: false},
+		{desc: "string", value: "15", want: 15, wantValid: true},
+		{desc: "int", value: 15, want: 15, wantValid: true},
+		{desc: "float", value: 15.0, want: 15, wantValid: true},
+		{desc: "max", value: "500", want: 500, wantValid: true},
+		{desc: "too_big", value: "501", wantValid: false},
+		{desc: "zero", value: 0, wantValid: false},
+		{desc: "negative", value: "-1", wantValid: false},
+		{desc: "decimal", value: 1.5, wantValid: false},
+		{desc: "invalid_string", value: "ALL", wantValid: false},
+		{desc: "invalid_type", value: []string{"15"}, wantValid: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			v := &PerPageValidator{Max: 500, AllowAll: c.allowAll}
+			ctx := &validation.Context{Value: c.value}
+			assert.Equal(t, c.wantValid, v.Validate(ctx))
+			if c.wantValid {
+				assert.Equal(t, c.want, ctx.Value)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"maps"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// VirtualRelation a relation that is not defined on the model but can be used in
+// filters, sorts and search like any other "belongs to" relation. This is useful
+// to expose denormalized reporting fields stored in a SQL view or another table.
+//
+// The relation is joined with `LEFT JOIN <table> <name> ON <parent>.<ForeignKey> = <name>.<References>`.
+//
+// Virtual relations cannot be used in the "join" query parameter because they
+// cannot be preloaded into the model.
+type VirtualRelation struct {
+	// Model a pointer to a struct describing the columns of the joined table or view.
+	Model any
+
+	// Table the name of the table or view to join. If empty, the table name of
+	// the `Model` is used.
+	Table string
+
+	// ForeignKey the name of the column of the parent model used in the join condition.
+	ForeignKey string
+
+	// References the name of the column of the virtual relation matching the `ForeignKey`.
+	// If empty, the primary key of the `Model` is used.
+	References string
+}
+
+func (v *VirtualRelation) relationship(db *gorm.DB, name string, parent *schema.Schema) (*schema.Relationship, error) {
+	fieldSchema, err := parseModel(db, v.Model)
+	if err != nil {
+		return nil, errors.Errorf("%w: could not parse model of virtual relation %q: %w", ErrInvalidVirtualRelation, name, err)
+	}
+	if v.Table != "" {
+		s := *fieldSchema
+		s.Table = v.Table
+		fieldSchema = &s
+	}
+
+	foreignKey := parent.LookUpField(v.ForeignKey)
+	if foreignKey == nil {
+		return nil, errors.Errorf("%w: virtual relation %q: foreign key %q not found in model %q", ErrInvalidVirtualRelation, name, v.ForeignKey, parent.Name)
+	}
+
+	primaryKey := fieldSchema.PrioritizedPrimaryField
+	if v.References != "" {
+		primaryKey = fieldSchema.LookUpField(v.References)
+	}
+	if primaryKey == nil {
+		return nil, errors.Errorf("%w: virtual relation %q: could not find the referenced column", ErrInvalidVirtualRelation, name)
+	}
+
+	return &schema.Relationship{
+		Name:        name,
+		Type:        schema.BelongsTo,
+		Schema:      parent,
+		FieldSchema: fieldSchema,
+		References: []*schema.Reference{
+			{PrimaryKey: primaryKey, ForeignKey: foreignKey},
+		},
+	}, nil
+}
+
+// withVirtualRelations returns a copy of the given schema containing the virtual relations.
+// The original schema is returned if there are no virtual relations.
+// Returns an error wrapping `ErrInvalidVirtualRelation` if a virtual relation is invalid.
+func withVirtualRelations(db *gorm.DB, sch *schema.Schema, virtualRelations map[string]*VirtualRelation) (*schema.Schema, error) {
+	if len(virtualRelations) == 0 {
+		return sch, nil
+	}
+
+	s := *sch
+	s.Relationships.Relations = make(map[string]*schema.Relationship, len(sch.Relationships.Relations)+len(virtualRelations))
+	maps.Copy(s.Relationships.Relations, sch.Relationships.Relations)
+	for _, name := range slices.Sorted(maps.Keys(virtualRelations)) {
+		relation, err := virtualRelations[name].relationship(db, name, &s)
+		if err != nil {
+			return nil, err
+		}
+		s.Relationships.Relations[name] = relation
+	}
+	return &s, nil
+}
@@ -0,0 +1,151 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+	"goyave.dev/goyave/v5/util/typeutil"
+)
+
+type VirtualRelationTestModel struct {
+	Name string
+	ID   int `gorm:"primaryKey"`
+}
+
+type VirtualRelationTestStats struct {
+	UserID       int `gorm:"primaryKey"`
+	ArticleCount int
+}
+
+func TestVirtualRelationScope(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "Stats.article_count", Args: []string{"3"}, Operator: Operators["$gt"]},
+		}),
+		Sort: typeutil.NewUndefined([]*Sort{{Field: "Stats.article_count", Order: SortDescending}}),
+		Join: typeutil.NewUndefined([]*Join{{Relation: "Stats"}}),
+	}
+	settings := &Settings[*VirtualRelationTestModel]{
+		VirtualRelations: map[string]*VirtualRelation{
+			"Stats": {
+				Model:      &VirtualRelationTestStats{},
+				Table:      "user_stats_view",
+				ForeignKey: "id",
+			},
+		},
+	}
+
+	db := openDryRunDB(t)
+	results := []*VirtualRelationTestModel{}
+	db = settings.ScopeUnpaginated(db, request, &results)
+	require.NoError(t, db.Error)
+
+	expectedJoin := clause.Join{
+		Type:  clause.LeftJoin,
+		Table: clause.Table{Name: "user_stats_view", Alias: "Stats"},
+		ON: clause.Where{
+			Exprs: []clause.Expression{
+				clause.Eq{
+					Column: clause.Column{Table: "virtual_relation_test_models", Name: "id"},
+					Value:  clause.Column{Table: "Stats", Name: "user_id"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, clause.From{Joins: []clause.Join{expectedJoin}}, db.Statement.Clauses["FROM"].Expression)
+	assert.Equal(t, clause.Where{
+		Exprs: []clause.Expression{
+			clause.AndConditions{
+				Exprs: []clause.Expression{
+					clause.Expr{SQL: "`Stats`.`article_count` > ?", Vars: []any{int64(3)}},
+				},
+			},
+		},
+	}, db.Statement.Clauses["WHERE"].Expression)
+	assert.Equal(t, clause.OrderBy{
+		Columns: []clause.OrderByColumn{
+			{Column: clause.Column{Table: "Stats", Name: "article_count"}, Desc: true},
+		},
+	}, db.Statement.Clauses["ORDER BY"].Expression)
+
+	// Virtual relations cannot be preloaded
+	assert.Empty(t, db.Statement.Preloads)
+}
+
+func TestVirtualRelationBlacklist(t *testing.T) {
+	request := &Request{
+		Filter: typeutil.NewUndefined([]*Filter{
+			{Field: "Stats.article_count", Args: []string{"3"}, Operator: Operators["$gt"]},
+		}),
+	}
+	settings := &Settings[*VirtualRelationTestModel]{
+		VirtualRelations: map[string]*VirtualRelation{
+			"Stats": {Model: &VirtualRelationTestStats{}, ForeignKey: "id"},
+		},
+		Blacklist: Blacklist{RelationsBlacklist: []string{"Stats"}},
+	}
+
+	db := openDryRunDB(t)
+	results := []*VirtualRelationTestModel{}
+	db = settings.ScopeUnpaginated(db, request, &results)
+	require.NoError(t, db.Error)
+	assert.Equal(t, clause.From{}, db.Statement.Clauses["FROM"].Expression)
+	assert.NotContains(t, db.Statement.Clauses, "WHERE")
+}
+
+func TestVirtualRelationInvalid(t *testing.T) {
+	db := openDryRunDB(t)
+	sch, err := parseModel(db, &VirtualRelationTestModel{})
+	require.NoError(t, err)
+
+	same, err := withVirtualRelations(db, sch, nil)
+	require.NoError(t, err)
+	assert.Same(t, sch, same)
+
+	virtualSchema, err := withVirtualRelations(db, sch, map[string]*VirtualRelation{
+		"Stats": {Model: &VirtualRelationTestStats{}, ForeignKey: "id", References: "user_id"},
+	})
+	require.NoError(t, err)
+	assert.NotSame(t, sch, virtualSchema)
+	assert.Contains(t, virtualSchema.Relationships.Relations, "Stats")
+	assert.NotContains(t, sch.Relationships.Relations, "Stats")
+	assert.Equal(t, "virtual_relation_test_stats", virtualSchema.Relationships.Relations["Stats"].FieldSchema.Table)
+
+	_, err = withVirtualRelations(db, sch, map[string]*VirtualRelation{
+		"Stats": {Model: &VirtualRelationTestStats{}, ForeignKey: "unknown"},
+	})
+	require.ErrorIs(t, err, ErrInvalidVirtualRelation)
+	_, err = withVirtualRelations(db, sch, map[string]*VirtualRelation{
+		"Stats": {Model: &VirtualRelationTestStats{}, ForeignKey: "id", References: "unknown"},
+	})
+	require.ErrorIs(t, err, ErrInvalidVirtualRelation)
+
+	t.Run("scope", func(t *testing.T) {
+		settings := &Settings[*VirtualRelationTestModel]{
+			VirtualRelations: map[string]*VirtualRelation{
+				"Stats": {Model: &VirtualRelationTestStats{}, ForeignKey: "unknown"},
+			},
+		}
+		results := []*VirtualRelationTestModel{}
+		_, err := settings.Scope(db, &Request{}, &results)
+		require.ErrorIs(t, err, ErrInvalidVirtualRelation)
+
+		_, err = settings.Build(db, &Request{}, &results)
+		require.ErrorIs(t, err, ErrInvalidVirtualRelation)
+
+		require.ErrorIs(t, settings.ScopeUnion(db, &Request{}, &Request{}, &results).Error, ErrInvalidVirtualRelation)
+		require.ErrorIs(t, settings.Validate(db), ErrInvalidVirtualRelation)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		settings := &Settings[*VirtualRelationTestModel]{
+			VirtualRelations: map[string]*VirtualRelation{
+				"Stats": {Model: &VirtualRelationTestStats{}, ForeignKey: "id", References: "user_id"},
+			},
+		}
+		require.NoError(t, settings.Validate(db))
+		require.ErrorIs(t, (&Settings[*struct{ A chan int }]{}).Validate(db), ErrUnsupportedModel)
+	})
+}
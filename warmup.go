@@ -0,0 +1,89 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// WarmUp parses and caches the schema of the given models. Call it at startup so the
+// first requests don't pay the cost of parsing the models, and so problems in the model
+// definitions are detected immediately rather than when the first request is processed.
+//
+// The returned error contains all the problems found, each wrapping one of the following errors:
+//   - `ErrUnsupportedModel` if the model cannot be parsed by GORM
+//   - `ErrNoPrimaryKey` if the model doesn't have a primary key
+//   - `ErrInvalidComputedColumn` if a field has a `computed` tag but is not a read-only column,
+//     or if its `computedType` tag is not a valid `DataType`
+//
+// Returns nil if all the models are valid.
+func WarmUp(db *gorm.DB, models ...any) error {
+	errs := []error{}
+	for _, model := range models {
+		name := modelTypeName(model)
+		sch, err := parseModel(db, model)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w: %w", name, ErrUnsupportedModel, err))
+			continue
+		}
+		if len(sch.PrimaryFieldDBNames) == 0 {
+			errs = append(errs, fmt.Errorf("%s: %w", name, ErrNoPrimaryKey))
+		}
+		for _, f := range sch.Fields {
+			if message := checkComputed(f); message != "" {
+				errs = append(errs, fmt.Errorf("%s: %w: field %q %s", name, ErrInvalidComputedColumn, f.Name, message))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(errs)
+}
+
+// Validate checks these settings can be applied to the model `T`. Call it at startup so
+// configuration problems are detected immediately rather than when the first request is processed.
+// Returns an error wrapping `ErrUnsupportedModel` if the model cannot be parsed, or
+// `ErrInvalidVirtualRelation` if a virtual relation cannot be resolved.
+func (s *Settings[T]) Validate(db *gorm.DB) error {
+	sch, err := parseModel(db, new(T))
+	if err != nil {
+		return errors.Errorf("%w: %w", ErrUnsupportedModel, err)
+	}
+	if _, err := withVirtualRelations(db, sch, s.VirtualRelations); err != nil {
+		return errors.New(err)
+	}
+	return nil
+}
+
+// checkComputed returns a message describing why the given computed field cannot be used,
+// or an empty string if the field is not computed or is valid.
+func checkComputed(f *schema.Field) string {
+	if f.StructField.Tag.Get("computed") == "" {
+		return ""
+	}
+	if f.DBName == "" {
+		return "is ignored by GORM"
+	}
+	if f.Creatable || f.Updatable {
+		return "must be read-only (`gorm:\"->;-:migration\"`)"
+	}
+	if t := f.Tag.Get("computedType"); t != "" && t != string(DataTypeUnsupported) && f.Tag.Get("filterType") == "" && getDataType(f) == DataTypeUnsupported {
+		return fmt.Sprintf("has an invalid computed type %q", t)
+	}
+	return ""
+}
+
+func modelTypeName(model any) string {
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "<nil>"
+	}
+	return t.String()
+}
@@ -0,0 +1,54 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+)
+
+type WarmUpTestModel struct {
+	Computed string `gorm:"->;-:migration" computed:"UPPER(~~~ct~~~.name)"`
+	Length   string `gorm:"->;-:migration" computed:"LENGTH(~~~ct~~~.name)" computedType:"int64"`
+	Name     string
+	ID       uint `gorm:"primaryKey"`
+}
+
+type WarmUpTestNoPrimaryKey struct {
+	Name string
+}
+
+type WarmUpTestInvalidComputed struct {
+	Writable string `computed:"UPPER(~~~ct~~~.name)"`
+	Ignored  string `gorm:"-" computed:"UPPER(~~~ct~~~.name)"`
+	Length   string `gorm:"->;-:migration" computed:"LENGTH(~~~ct~~~.name)" computedType:"integer"`
+	Name     string
+	ID       uint `gorm:"primaryKey"`
+}
+
+func TestWarmUp(t *testing.T) {
+	db := openDryRunDB(t)
+
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, WarmUp(db, &WarmUpTestModel{}, []*WarmUpTestModel{}, &FilterTestModel{}))
+		_, ok := modelCache.Load(reflect.TypeOf(WarmUpTestModel{}))
+		assert.True(t, ok)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		model := []string{}
+		err := WarmUp(db, &WarmUpTestModel{}, &model, &WarmUpTestNoPrimaryKey{}, &WarmUpTestInvalidComputed{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnsupportedModel)
+		assert.ErrorIs(t, err, schema.ErrUnsupportedDataType)
+		assert.ErrorIs(t, err, ErrNoPrimaryKey)
+		assert.ErrorIs(t, err, ErrInvalidComputedColumn)
+		assert.ErrorContains(t, err, "filter.WarmUpTestNoPrimaryKey: could not find primary key")
+		assert.ErrorContains(t, err, "filter.WarmUpTestInvalidComputed: invalid computed column: field \"Writable\" must be read-only")
+		assert.ErrorContains(t, err, "filter.WarmUpTestInvalidComputed: invalid computed column: field \"Ignored\" is ignored by GORM")
+		assert.ErrorContains(t, err, "filter.WarmUpTestInvalidComputed: invalid computed column: field \"Length\" has an invalid computed type \"integer\"")
+		assert.NotContains(t, err.Error(), "filter.WarmUpTestModel")
+	})
+}